@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// schema est un document JSON Schema
+type schema map[string]interface{}
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	flexibleTimeType = reflect.TypeOf(models.FlexibleTime{})
+	objectIDType     = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// generator construit les définitions partagées ($defs) au fil des types rencontrés
+type generator struct {
+	defs map[string]schema
+}
+
+func main() {
+	output := flag.String("o", "docs/websocket-protocol.schema.json", "fichier de sortie")
+	flag.Parse()
+
+	log.Println("📐 Génération du JSON Schema du protocole WebSocket...")
+
+	g := &generator{defs: map[string]schema{}}
+
+	var clientRefs, serverRefs []schema
+	for _, spec := range models.WSInboundMessages {
+		name := "client." + spec.Type
+		g.defs[name] = g.message(spec, false)
+		clientRefs = append(clientRefs, schema{"$ref": "#/$defs/" + name})
+	}
+	for _, spec := range models.WSOutboundMessages {
+		name := "server." + spec.Type
+		g.defs[name] = g.message(spec, true)
+		serverRefs = append(serverRefs, schema{"$ref": "#/$defs/" + name})
+	}
+	g.defs["ClientMessage"] = schema{
+		"description": "Message envoyé par le client",
+		"oneOf":       clientRefs,
+	}
+	g.defs["ServerMessage"] = schema{
+		"description": "Message envoyé par le serveur",
+		"oneOf":       serverRefs,
+	}
+
+	doc := schema{
+		"$schema":                "https://json-schema.org/draft/2020-12/schema",
+		"$id":                    "websocket-protocol.schema.json",
+		"title":                  "Protocole WebSocket /ws",
+		"description":            "Fichier généré par cmd/generate-ws-schema à partir de models/ws_protocol.go, ne pas modifier à la main.",
+		"x-protocol-version":     models.WSProtocolVersion,
+		"x-min-protocol-version": models.WSMinProtocolVersion,
+		"oneOf": []schema{
+			{"$ref": "#/$defs/ClientMessage"},
+			{"$ref": "#/$defs/ServerMessage"},
+		},
+		"$defs": g.defs,
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("❌ Erreur encodage du schéma: %v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		log.Fatalf("❌ Erreur écriture %s: %v", *output, err)
+	}
+
+	log.Printf("✅ Schéma écrit dans %s (%d messages client, %d messages serveur)",
+		*output, len(models.WSInboundMessages), len(models.WSOutboundMessages))
+}
+
+// message décrit un type de message : sa structure, avec le champ type fixé
+func (g *generator) message(spec models.WSMessageSpec, server bool) schema {
+	// Côté client, un pointeur signale un champ optionnel et non une valeur null
+	s := g.object(reflect.TypeOf(spec.Payload), server)
+	s["description"] = spec.Description
+
+	properties := s["properties"].(schema)
+	properties["type"] = schema{"const": spec.Type}
+
+	// Les événements envoyés à un utilisateur sont numérotés pour la reprise (last_seq)
+	if server {
+		properties["seq"] = schema{
+			"type":        "integer",
+			"description": "Numéro de séquence de l'événement (événements adressés à l'utilisateur uniquement)",
+		}
+	}
+	return s
+}
+
+// object décrit une structure à partir de ses tags json
+func (g *generator) object(t reflect.Type, allowNull bool) schema {
+	properties := schema{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.typeSchema(field.Type)
+		omitempty := strings.Contains(opts, "omitempty")
+		if allowNull && !omitempty && nullable(field.Type) {
+			property = schema{"anyOf": []schema{property, {"type": "null"}}}
+		}
+		properties[name] = property
+
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+
+	s := schema{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// typeSchema décrit un type Go tel qu'il est encodé en JSON
+func (g *generator) typeSchema(t reflect.Type) schema {
+	switch t {
+	case timeType:
+		return schema{"type": "string", "format": "date-time"}
+	case flexibleTimeType:
+		return schema{"type": []string{"string", "null"}, "format": "date-time"}
+	case objectIDType:
+		return schema{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return schema{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // Réservé avant la description (types récursifs)
+			g.defs[t.Name()] = g.object(t, true)
+		}
+		return schema{"$ref": "#/$defs/" + t.Name()}
+	}
+
+	return schema{}
+}
+
+// nullable indique si un champ peut être encodé à null
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -29,6 +30,17 @@ type Config struct {
 	CloudinaryAPIKey          string
 	CloudinaryAPISecret       string
 	SlackWebhookURL           string
+	FrontendURL               string
+	SMTPHost                  string
+	SMTPPort                  string
+	SMTPUsername              string
+	SMTPPassword              string
+	MailFrom                  string
+	MailOutboxDir             string
+	WSBroadcaster             string
+	StorageLocalDir           string
+	StorageLocalURL           string
+	OutboxMaxAttempts         int
 }
 
 // Load charge la configuration depuis les variables d'environnement
@@ -55,6 +67,16 @@ func Load() (*Config, error) {
 		CloudinaryAPIKey:        getEnv("CLOUDINARY_API_KEY", ""),
 		CloudinaryAPISecret:     getEnv("CLOUDINARY_API_SECRET", ""),
 		SlackWebhookURL:         getEnv("SLACK_WEBHOOK_URL", ""),
+		FrontendURL:             getEnv("FRONTEND_URL", "http://localhost:3000"),
+		SMTPHost:                getEnv("SMTP_HOST", ""),
+		SMTPPort:                getEnv("SMTP_PORT", "587"),
+		SMTPUsername:            getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:            getEnv("SMTP_PASSWORD", ""),
+		MailFrom:                getEnv("MAIL_FROM", "noreply@localhost"),
+		MailOutboxDir:           getEnv("MAIL_OUTBOX_DIR", ""), // Sans SMTP : dossier où écrire les e-mails (.eml)
+		WSBroadcaster:           getEnv("WS_BROADCASTER", "memory"),
+		StorageLocalDir:         getEnv("STORAGE_LOCAL_DIR", "uploads"),                      // Sans Cloudinary : dossier des pièces jointes
+		StorageLocalURL:         getEnv("STORAGE_LOCAL_URL", "http://localhost:8090/uploads"), // URL publique de ce dossier
 	}
 
 	// Parser les origines CORS
@@ -69,6 +91,9 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Nombre d'essais d'envoi d'une notification avant abandon (outbox)
+	config.OutboxMaxAttempts, _ = strconv.Atoi(getEnv("OUTBOX_MAX_ATTEMPTS", "5"))
+
 	// Valider les configurations critiques
 	if config.JWTSecret == "" {
 		return nil, fmt.Errorf("JWT_SECRET est requis")
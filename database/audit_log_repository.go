@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"premier-an-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditLogRepository gère le journal des actions d'administration
+type AuditLogRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditLogRepository crée une nouvelle instance
+func NewAuditLogRepository(db *mongo.Database) *AuditLogRepository {
+	return &AuditLogRepository{
+		collection: db.Collection("audit_log"),
+	}
+}
+
+// Create enregistre une entrée dans le journal
+func (r *AuditLogRepository) Create(entry *models.AuditLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'enregistrement de l'audit: %w", err)
+	}
+
+	return nil
+}
+
+// Find retourne une page d'entrées correspondant aux filtres, de la plus récente à la plus ancienne,
+// ainsi que le nombre total d'entrées correspondantes
+func (r *AuditLogRepository) Find(filter models.AuditLogFilter, page, limit int) ([]models.AuditLog, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if filter.ActorEmail != "" {
+		query["actor_email"] = filter.ActorEmail
+	}
+	if filter.Action != "" {
+		query["action"] = filter.Action
+	}
+	if filter.TargetType != "" {
+		query["target_type"] = filter.TargetType
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	if filter.From != nil || filter.To != nil {
+		createdAt := bson.M{}
+		if filter.From != nil {
+			createdAt["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			createdAt["$lte"] = *filter.To
+		}
+		query["created_at"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erreur lors du comptage des entrées d'audit: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erreur lors de la recherche des entrées d'audit: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.AuditLog
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, fmt.Errorf("erreur lors du décodage: %w", err)
+	}
+
+	return entries, total, nil
+}
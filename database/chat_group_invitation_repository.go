@@ -294,3 +294,34 @@ func (r *ChatGroupInvitationRepository) Delete(invitationID primitive.ObjectID)
 
 	return nil
 }
+
+// DeletePendingByGroup supprime les invitations en attente d'un groupe
+func (r *ChatGroupInvitationRepository) DeletePendingByGroup(groupID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"group_id": groupID, "status": "pending"})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des invitations: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DeleteByUser supprime les invitations envoyées ou reçues par un utilisateur
+func (r *ChatGroupInvitationRepository) DeleteByUser(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{
+		"$or": []bson.M{
+			{"invited_user": userID},
+			{"invited_by": userID},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des invitations: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
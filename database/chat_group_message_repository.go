@@ -64,17 +64,190 @@ func (r *ChatGroupMessageRepository) Create(message *models.ChatGroupMessage) er
 	return nil
 }
 
-// FindByGroupID récupère les messages d'un groupe avec pagination
-func (r *ChatGroupMessageRepository) FindByGroupID(groupID primitive.ObjectID, limit int, before *primitive.ObjectID) ([]models.GroupMessageWithSender, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// FindByID récupère un message par son ID
+func (r *ChatGroupMessageRepository) FindByID(messageID primitive.ObjectID) (*models.ChatGroupMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var message models.ChatGroupMessage
+	err := r.collection.FindOne(ctx, bson.M{"_id": messageID}).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche du message: %w", err)
+	}
+
+	message.Summary = models.SummarizeReactions(message.Reactions)
+	return &message, nil
+}
+
+// Edit remplace le contenu d'un message et conserve l'ancienne version dans edit_history.
+// Les mentions sont remplacées par celles du nouveau contenu.
+// Retourne mongo.ErrNoDocuments si le message a été supprimé ou modifié entre-temps.
+func (r *ChatGroupMessageRepository) Edit(message *models.ChatGroupMessage, content string, mentions []models.Mention) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	now := time.Now()
+	previous := models.MessageEdit{Content: message.Content, EditedAt: now}
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": message.ID, "content": message.Content, "deleted_at": nil},
+		bson.M{
+			"$set":  bson.M{"content": content, "edited_at": now, "mentions": mentions},
+			"$push": bson.M{"edit_history": previous},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la modification du message: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	message.Content = content
+	message.Mentions = mentions
+	message.EditedAt = &now
+	message.EditHistory = append(message.EditHistory, previous)
+	return nil
+}
+
+// SoftDelete supprime un message en laissant une trace (contenu et historique effacés, deleted_at renseigné)
+func (r *ChatGroupMessageRepository) SoftDelete(message *models.ChatGroupMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": message.ID, "deleted_at": nil},
+		bson.M{
+			"$set":   bson.M{"content": "", "deleted_at": now},
+			"$unset": bson.M{"edit_history": "", "reactions": "", "mentions": "", "attachments": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la suppression du message: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	message.Content = ""
+	message.EditHistory = nil
+	message.Reactions = nil
+	message.Summary = nil
+	message.Mentions = nil
+	message.Attachments = nil
+	message.DeletedAt = &now
+	return nil
+}
+
+// AddReaction ajoute une réaction à un message (une seule par utilisateur et par emoji).
+// added vaut false si l'utilisateur avait déjà cette réaction ou si le message est supprimé ;
+// le message retourné est alors l'état actuel.
+func (r *ChatGroupMessageRepository) AddReaction(messageID primitive.ObjectID, reaction models.MessageReaction) (*models.ChatGroupMessage, bool, error) {
+	filter := bson.M{
+		"_id":        messageID,
+		"deleted_at": nil,
+		"reactions": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"user_id": reaction.UserID,
+			"emoji":   reaction.Emoji,
+		}}},
+	}
+	update := bson.M{"$push": bson.M{"reactions": reaction}}
+
+	return r.updateReactions(messageID, filter, update)
+}
+
+// RemoveReaction retire la réaction d'un utilisateur.
+// removed vaut false si l'utilisateur n'avait pas cette réaction.
+func (r *ChatGroupMessageRepository) RemoveReaction(messageID primitive.ObjectID, userID string, emoji string) (*models.ChatGroupMessage, bool, error) {
+	filter := bson.M{
+		"_id":       messageID,
+		"reactions": bson.M{"$elemMatch": bson.M{"user_id": userID, "emoji": emoji}},
+	}
+	update := bson.M{"$pull": bson.M{"reactions": bson.M{"user_id": userID, "emoji": emoji}}}
+
+	return r.updateReactions(messageID, filter, update)
+}
+
+// updateReactions applique une modification des réactions et retourne le message à jour
+func (r *ChatGroupMessageRepository) updateReactions(messageID primitive.ObjectID, filter bson.M, update bson.M) (*models.ChatGroupMessage, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var message models.ChatGroupMessage
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		// Rien à modifier : retourner l'état actuel
+		current, err := r.FindByID(messageID)
+		return current, false, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("erreur lors de la mise à jour des réactions: %w", err)
+	}
+
+	message.Summary = models.SummarizeReactions(message.Reactions)
+	return &message, true, nil
+}
+
+// FindByGroupID récupère les messages d'un groupe avec pagination
+func (r *ChatGroupMessageRepository) FindByGroupID(groupID primitive.ObjectID, limit int, before *primitive.ObjectID) ([]models.GroupMessageWithSender, error) {
 	// Construire le filtre
 	filter := bson.M{"group_id": groupID}
 	if before != nil {
 		filter["_id"] = bson.M{"$lt": *before}
 	}
 
+	return r.findWithSender(filter, limit)
+}
+
+// FindThread récupère les réponses d'un fil (du plus ancien au plus récent) avec pagination
+func (r *ChatGroupMessageRepository) FindThread(threadID primitive.ObjectID, limit int, before *primitive.ObjectID) ([]models.GroupMessageWithSender, error) {
+	filter := bson.M{"thread_id": threadID}
+	if before != nil {
+		filter["_id"] = bson.M{"$lt": *before}
+	}
+
+	return r.findWithSender(filter, limit)
+}
+
+// FindWithSenderByID récupère un message avec son expéditeur et l'aperçu du message cité
+func (r *ChatGroupMessageRepository) FindWithSenderByID(messageID primitive.ObjectID) (*models.GroupMessageWithSender, error) {
+	messages, err := r.findWithSender(bson.M{"_id": messageID}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	return &messages[0], nil
+}
+
+// IncrementReplyCount incrémente le nombre de réponses du premier message d'un fil
+func (r *ChatGroupMessageRepository) IncrementReplyCount(threadID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": threadID}, bson.M{"$inc": bson.M{"reply_count": 1}})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour du nombre de réponses: %w", err)
+	}
+
+	return nil
+}
+
+// findWithSender récupère les derniers messages correspondant au filtre (du plus ancien au plus récent),
+// avec l'expéditeur et l'aperçu du message cité
+func (r *ChatGroupMessageRepository) findWithSender(filter bson.M, limit int) ([]models.GroupMessageWithSender, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
 	// Pipeline d'agrégation pour joindre les infos de l'expéditeur
 	pipeline := []bson.M{
 		{"$match": filter},
@@ -100,6 +273,23 @@ func (r *ChatGroupMessageRepository) FindByGroupID(groupID primitive.ObjectID, l
 				"as": "sender",
 			},
 		},
+		// Message cité et son expéditeur
+		{
+			"$lookup": bson.M{
+				"from":         "chat_group_messages",
+				"localField":   "reply_to",
+				"foreignField": "_id",
+				"as":           "quoted",
+			},
+		},
+		{
+			"$lookup": bson.M{
+				"from":         "users",
+				"localField":   "quoted.sender_id",
+				"foreignField": "email",
+				"as":           "quoted_sender",
+			},
+		},
 		// Inverser l'ordre pour avoir du plus ancien au plus récent
 		{"$sort": bson.M{"created_at": 1}},
 	}
@@ -113,15 +303,26 @@ func (r *ChatGroupMessageRepository) FindByGroupID(groupID primitive.ObjectID, l
 	var messages []models.GroupMessageWithSender
 	for cursor.Next(ctx) {
 		var result struct {
-			ID          primitive.ObjectID `bson:"_id"`
-			SenderID    string             `bson:"sender_id"`
-			Content     string             `bson:"content"`
-			MessageType string             `bson:"message_type"`
-			Timestamp   time.Time          `bson:"timestamp"`
-			CreatedAt   time.Time          `bson:"created_at"`
-			DeliveredAt *time.Time         `bson:"delivered_at"`
-			ReadBy      []string           `bson:"read_by"`
-			Sender      []models.User      `bson:"sender"`
+			ID           primitive.ObjectID        `bson:"_id"`
+			SenderID     string                    `bson:"sender_id"`
+			Content      string                    `bson:"content"`
+			MessageType  string                    `bson:"message_type"`
+			Timestamp    time.Time                 `bson:"timestamp"`
+			CreatedAt    time.Time                 `bson:"created_at"`
+			DeliveredAt  *time.Time                `bson:"delivered_at"`
+			ReadBy       []string                  `bson:"read_by"`
+			EditedAt     *time.Time                `bson:"edited_at"`
+			EditHistory  []models.MessageEdit      `bson:"edit_history"`
+			DeletedAt    *time.Time                `bson:"deleted_at"`
+			Reactions    []models.MessageReaction  `bson:"reactions"`
+			ReplyToID    *primitive.ObjectID       `bson:"reply_to"`
+			ThreadID     *primitive.ObjectID       `bson:"thread_id"`
+			ReplyCount   int                       `bson:"reply_count"`
+			Mentions     []models.Mention          `bson:"mentions"`
+			Attachments  []models.Attachment       `bson:"attachments"`
+			Sender       []models.User             `bson:"sender"`
+			Quoted       []models.ChatGroupMessage `bson:"quoted"`
+			QuotedSender []models.User             `bson:"quoted_sender"`
 		}
 		if err := cursor.Decode(&result); err != nil {
 			continue
@@ -136,6 +337,15 @@ func (r *ChatGroupMessageRepository) FindByGroupID(groupID primitive.ObjectID, l
 			CreatedAt:   result.CreatedAt,
 			DeliveredAt: result.DeliveredAt,
 			ReadBy:      result.ReadBy,
+			EditedAt:    result.EditedAt,
+			EditHistory: result.EditHistory,
+			DeletedAt:   result.DeletedAt,
+			Reactions:   models.SummarizeReactions(result.Reactions),
+			ReplyToID:   result.ReplyToID,
+			ThreadID:    result.ThreadID,
+			ReplyCount:  result.ReplyCount,
+			Mentions:    result.Mentions,
+			Attachments: result.Attachments,
 		}
 
 		// Ajouter les infos de l'expéditeur si ce n'est pas un message système
@@ -148,6 +358,15 @@ func (r *ChatGroupMessageRepository) FindByGroupID(groupID primitive.ObjectID, l
 			}
 		}
 
+		// Aperçu du message cité (absent s'il n'existe plus)
+		if len(result.Quoted) > 0 {
+			var quotedSender *models.User
+			if result.Quoted[0].MessageType != "system" && len(result.QuotedSender) > 0 {
+				quotedSender = &result.QuotedSender[0]
+			}
+			msg.Quoted = models.NewMessageQuote(&result.Quoted[0], quotedSender)
+		}
+
 		messages = append(messages, msg)
 	}
 
@@ -311,3 +530,96 @@ func (r *ChatGroupMessageRepository) DeleteByGroupID(groupID primitive.ObjectID)
 
 	return nil
 }
+
+// AnonymizeSender remplace l'expéditeur des messages d'un utilisateur supprimé
+// et retire ses accusés de lecture, ses réactions et ses mentions (les messages restent visibles pour le groupe)
+func (r *ChatGroupMessageRepository) AnonymizeSender(userID string, replacement string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"sender_id": userID},
+		bson.M{"$set": bson.M{"sender_id": replacement}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de l'anonymisation des messages: %w", err)
+	}
+
+	if _, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"read_by": userID},
+		bson.M{"$pull": bson.M{"read_by": userID}},
+	); err != nil {
+		return 0, fmt.Errorf("erreur lors du retrait des lectures: %w", err)
+	}
+
+	if _, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"reactions.user_id": userID},
+		bson.M{"$pull": bson.M{"reactions": bson.M{"user_id": userID}}},
+	); err != nil {
+		return 0, fmt.Errorf("erreur lors du retrait des réactions: %w", err)
+	}
+
+	if _, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"mentions.user_id": userID},
+		bson.M{"$pull": bson.M{"mentions": bson.M{"user_id": userID}}},
+	); err != nil {
+		return 0, fmt.Errorf("erreur lors du retrait des mentions: %w", err)
+	}
+
+	if _, err := r.readReceiptCollection.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des accusés de lecture: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// FindBySender retourne tous les messages de groupe envoyés par un utilisateur
+func (r *ChatGroupMessageRepository) FindBySender(userID string) ([]models.ChatGroupMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"sender_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var messages []models.ChatGroupMessage
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// Search recherche des messages (index texte) dans des groupes, du plus récent au plus ancien.
+// senderID (email) restreint aux messages d'un expéditeur.
+func (r *ChatGroupMessageRepository) Search(groupIDs []primitive.ObjectID, senderID string, filter models.MessageSearchFilter) ([]models.ChatGroupMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := searchQuery(filter)
+	query["group_id"] = bson.M{"$in": groupIDs}
+	query["message_type"] = bson.M{"$ne": "system"}
+	if senderID != "" {
+		query["sender_id"] = senderID
+	}
+
+	cursor, err := r.collection.Find(ctx, query, searchOptions(filter))
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche de messages: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var messages []models.ChatGroupMessage
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des messages: %w", err)
+	}
+
+	return messages, nil
+}
@@ -282,6 +282,36 @@ func (r *ChatGroupRepository) RemoveMember(groupID primitive.ObjectID, userID st
 	return nil
 }
 
+// UpdateMemberRole change le rôle d'un membre ("admin" ou "member")
+func (r *ChatGroupRepository) UpdateMemberRole(groupID primitive.ObjectID, userID string, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.membersCollection.UpdateOne(
+		ctx,
+		bson.M{"group_id": groupID, "user_id": userID},
+		bson.M{"$set": bson.M{"role": role}},
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour du rôle: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveAllMembers retire tous les membres d'un groupe (dissolution)
+func (r *ChatGroupRepository) RemoveAllMembers(groupID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.membersCollection.DeleteMany(ctx, bson.M{"group_id": groupID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des membres: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
 // GetGroupsWithDetails récupère les groupes avec tous les détails pour un utilisateur
 func (r *ChatGroupRepository) GetGroupsWithDetails(userID string) ([]models.GroupWithDetails, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -337,6 +367,8 @@ func (r *ChatGroupRepository) GetGroupsWithDetails(userID string) ([]models.Grou
 	}
 	defer cursor.Close(ctx)
 
+	messagesCollection := r.membersCollection.Database().Collection("chat_group_messages")
+
 	var groups []models.GroupWithDetails
 	for cursor.Next(ctx) {
 		var result struct {
@@ -351,18 +383,28 @@ func (r *ChatGroupRepository) GetGroupsWithDetails(userID string) ([]models.Grou
 			continue
 		}
 
+		// Mentions non lues (pas de dernier message connu : le read receipt suffit)
+		unreadMentions, err := r.countUnreadMentions(ctx, result.GroupID, userID, primitive.NilObjectID, messagesCollection)
+		if err != nil {
+			unreadMentions = 0
+		}
+
 		groups = append(groups, models.GroupWithDetails{
-			ID:   result.Group.ID.Hex(),
-			Name: result.Group.Name,
+			ID:          result.Group.ID.Hex(),
+			Name:        result.Group.Name,
+			Description: result.Group.Description,
+			AvatarURL:   result.Group.AvatarURL,
+			ArchivedAt:  result.Group.ArchivedAt,
 			CreatedBy: models.GroupCreatorInfo{
 				ID:        result.Creator.Email,
 				Firstname: result.Creator.Firstname,
 				Lastname:  result.Creator.Lastname,
 				Email:     result.Creator.Email,
 			},
-			MemberCount: result.MemberCount,
-			UnreadCount: 0,
-			CreatedAt:   result.Group.CreatedAt,
+			MemberCount:    result.MemberCount,
+			UnreadCount:    0,
+			UnreadMentions: unreadMentions,
+			CreatedAt:      result.Group.CreatedAt,
 		})
 	}
 
@@ -481,6 +523,9 @@ func (r *ChatGroupRepository) GetUserGroups(userEmail string, messagesCollection
 		var result struct {
 			ID          primitive.ObjectID `bson:"_id"`
 			Name        string             `bson:"name"`
+			Description string             `bson:"description"`
+			AvatarURL   string             `bson:"avatar_url"`
+			ArchivedAt  *time.Time         `bson:"archived_at"`
 			CreatedBy   string             `bson:"created_by"`
 			CreatedAt   time.Time          `bson:"created_at"`
 			MemberCount int                `bson:"member_count"`
@@ -493,6 +538,9 @@ func (r *ChatGroupRepository) GetUserGroups(userEmail string, messagesCollection
 		group := models.GroupWithDetails{
 			ID:          result.ID.Hex(),
 			Name:        result.Name,
+			Description: result.Description,
+			AvatarURL:   result.AvatarURL,
+			ArchivedAt:  result.ArchivedAt,
 			MemberCount: result.MemberCount,
 			UnreadCount: 0,
 			CreatedAt:   result.CreatedAt,
@@ -529,6 +577,11 @@ func (r *ChatGroupRepository) GetUserGroups(userEmail string, messagesCollection
 				if err == nil {
 					group.UnreadCount = unreadCount
 				}
+				if unreadCount > 0 {
+					if mentions, err := r.countUnreadMentions(ctx, result.ID, userEmail, lastMessageID, messagesCollection); err == nil {
+						group.UnreadMentions = mentions
+					}
+				}
 			}
 		} else {
 			// Pas de dernier message → pas de badge
@@ -584,6 +637,41 @@ func (r *ChatGroupRepository) getLastGroupMessage(ctx context.Context, groupID p
 
 // countUnreadMessagesWithReceipt compte les messages non lus en vérifiant le read receipt
 func (r *ChatGroupRepository) countUnreadMessagesWithReceipt(ctx context.Context, groupID primitive.ObjectID, userEmail string, lastMessageID primitive.ObjectID, messagesCollection *mongo.Collection) (int, error) {
+	filter, upToDate := r.unreadFilter(ctx, groupID, userEmail, lastMessageID, messagesCollection)
+	if upToDate {
+		return 0, nil
+	}
+
+	count, err := messagesCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// countUnreadMentions compte les messages non lus qui mentionnent l'utilisateur
+func (r *ChatGroupRepository) countUnreadMentions(ctx context.Context, groupID primitive.ObjectID, userEmail string, lastMessageID primitive.ObjectID, messagesCollection *mongo.Collection) (int, error) {
+	filter, upToDate := r.unreadFilter(ctx, groupID, userEmail, lastMessageID, messagesCollection)
+	if upToDate {
+		return 0, nil
+	}
+
+	filter["mentions.user_id"] = userEmail
+	count, err := messagesCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// unreadFilter construit le filtre des messages des autres membres postérieurs au dernier message lu.
+// upToDate vaut true si l'utilisateur a lu jusqu'au dernier message.
+func (r *ChatGroupRepository) unreadFilter(ctx context.Context, groupID primitive.ObjectID, userEmail string, lastMessageID primitive.ObjectID, messagesCollection *mongo.Collection) (bson.M, bool) {
+	filter := bson.M{
+		"group_id":  groupID,
+		"sender_id": bson.M{"$ne": userEmail},
+	}
+
 	// Récupérer le read receipt de l'utilisateur dans ce groupe
 	readReceiptCollection := messagesCollection.Database().Collection("chat_group_read_receipts")
 	var receipt models.ChatGroupReadReceipt
@@ -593,37 +681,65 @@ func (r *ChatGroupRepository) countUnreadMessagesWithReceipt(ctx context.Context
 	}).Decode(&receipt)
 
 	// Si pas de read receipt, compter tous les messages des autres
-	if err == mongo.ErrNoDocuments {
-		count, _ := messagesCollection.CountDocuments(ctx, bson.M{
-			"group_id":  groupID,
-			"sender_id": bson.M{"$ne": userEmail},
-		})
-		return int(count), nil
+	if err != nil || receipt.LastReadMessageID == nil {
+		return filter, false
 	}
 
 	// Si l'utilisateur a lu jusqu'au dernier message → 0
-	if receipt.LastReadMessageID != nil && *receipt.LastReadMessageID == lastMessageID {
-		return 0, nil
+	if *receipt.LastReadMessageID == lastMessageID {
+		return nil, true
 	}
 
 	// Compter les messages après le dernier lu
-	var filter bson.M
-	if receipt.LastReadMessageID != nil {
-		filter = bson.M{
-			"group_id":  groupID,
-			"sender_id": bson.M{"$ne": userEmail},
-			"_id":       bson.M{"$gt": *receipt.LastReadMessageID},
-		}
-	} else {
-		filter = bson.M{
-			"group_id":  groupID,
-			"sender_id": bson.M{"$ne": userEmail},
-		}
+	filter["_id"] = bson.M{"$gt": *receipt.LastReadMessageID}
+	return filter, false
+}
+
+// RemoveUserFromAllGroups retire un utilisateur de tous ses groupes
+func (r *ChatGroupRepository) RemoveUserFromAllGroups(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.membersCollection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors du retrait des groupes: %w", err)
 	}
 
-	count, err := messagesCollection.CountDocuments(ctx, filter)
+	return result.DeletedCount, nil
+}
+
+// AnonymizeCreator remplace le créateur des groupes créés par un utilisateur supprimé
+func (r *ChatGroupRepository) AnonymizeCreator(userID string, replacement string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"created_by": userID},
+		bson.M{"$set": bson.M{"created_by": replacement}},
+	)
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("erreur lors de l'anonymisation des groupes: %w", err)
 	}
-	return int(count), nil
+
+	return result.ModifiedCount, nil
+}
+
+// FindMembershipsByUser retourne les appartenances d'un utilisateur à des groupes (rôle et date d'arrivée)
+func (r *ChatGroupRepository) FindMembershipsByUser(userID string) ([]models.ChatGroupMember, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.membersCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des membres: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var members []models.ChatGroupMember
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des membres: %w", err)
+	}
+
+	return members, nil
 }
@@ -144,6 +144,9 @@ func (r *ChatRepository) GetConversations(ctx context.Context, userID primitive.
 					participant.ProfileImageURL = profileImageURL
 				}
 			}
+		} else {
+			// L'autre participant a supprimé son compte
+			participant = *models.DeletedUserInfo()
 		}
 
 		// Extraire le dernier message
@@ -201,6 +204,11 @@ func (r *ChatRepository) GetMessages(ctx context.Context, conversationID primiti
 		messages[i], messages[j] = messages[j], messages[i]
 	}
 
+	// Regrouper les réactions par emoji
+	for i := range messages {
+		messages[i].Summary = models.SummarizeReactions(messages[i].Reactions)
+	}
+
 	// Marquer automatiquement comme distribués les messages reçus
 	now := time.Now()
 	_, err = r.messageCollection.UpdateMany(
@@ -544,6 +552,120 @@ func (r *ChatRepository) GetConversationByID(ctx context.Context, conversationID
 	return &conversation, nil
 }
 
+// GetMessageByID récupère un message par son ID
+func (r *ChatRepository) GetMessageByID(ctx context.Context, messageID primitive.ObjectID) (*models.Message, error) {
+	var message models.Message
+	err := r.messageCollection.FindOne(ctx, bson.M{"_id": messageID}).Decode(&message)
+	if err != nil {
+		return nil, err
+	}
+	message.Summary = models.SummarizeReactions(message.Reactions)
+	return &message, nil
+}
+
+// EditMessage remplace le contenu d'un message et conserve l'ancienne version dans edit_history.
+// Retourne mongo.ErrNoDocuments si le message a été supprimé ou modifié entre-temps.
+func (r *ChatRepository) EditMessage(ctx context.Context, message *models.Message, content string) error {
+	now := time.Now()
+	previous := models.MessageEdit{Content: message.Content, EditedAt: now}
+
+	result, err := r.messageCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": message.ID, "content": message.Content, "deleted_at": nil},
+		bson.M{
+			"$set":  bson.M{"content": content, "edited_at": now},
+			"$push": bson.M{"edit_history": previous},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	message.Content = content
+	message.EditedAt = &now
+	message.EditHistory = append(message.EditHistory, previous)
+	return nil
+}
+
+// DeleteMessage supprime un message en laissant une trace (contenu et historique effacés, deleted_at renseigné)
+func (r *ChatRepository) DeleteMessage(ctx context.Context, message *models.Message) error {
+	now := time.Now()
+
+	result, err := r.messageCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": message.ID, "deleted_at": nil},
+		bson.M{
+			"$set":   bson.M{"content": "", "deleted_at": now},
+			"$unset": bson.M{"edit_history": "", "reactions": "", "attachments": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	message.Content = ""
+	message.EditHistory = nil
+	message.Reactions = nil
+	message.Summary = nil
+	message.Attachments = nil
+	message.DeletedAt = &now
+	return nil
+}
+
+// AddReaction ajoute une réaction à un message (une seule par utilisateur et par emoji).
+// added vaut false si l'utilisateur avait déjà cette réaction ou si le message est supprimé ;
+// le message retourné est alors l'état actuel.
+func (r *ChatRepository) AddReaction(ctx context.Context, messageID primitive.ObjectID, reaction models.MessageReaction) (*models.Message, bool, error) {
+	filter := bson.M{
+		"_id":        messageID,
+		"deleted_at": nil,
+		"reactions": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"user_id": reaction.UserID,
+			"emoji":   reaction.Emoji,
+		}}},
+	}
+	update := bson.M{"$push": bson.M{"reactions": reaction}}
+
+	return r.updateReactions(ctx, messageID, filter, update)
+}
+
+// RemoveReaction retire la réaction d'un utilisateur.
+// removed vaut false si l'utilisateur n'avait pas cette réaction.
+func (r *ChatRepository) RemoveReaction(ctx context.Context, messageID primitive.ObjectID, userID string, emoji string) (*models.Message, bool, error) {
+	filter := bson.M{
+		"_id":       messageID,
+		"reactions": bson.M{"$elemMatch": bson.M{"user_id": userID, "emoji": emoji}},
+	}
+	update := bson.M{"$pull": bson.M{"reactions": bson.M{"user_id": userID, "emoji": emoji}}}
+
+	return r.updateReactions(ctx, messageID, filter, update)
+}
+
+// updateReactions applique une modification des réactions et retourne le message à jour
+func (r *ChatRepository) updateReactions(ctx context.Context, messageID primitive.ObjectID, filter bson.M, update bson.M) (*models.Message, bool, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var message models.Message
+	err := r.messageCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err == mongo.ErrNoDocuments {
+		// Rien à modifier : retourner l'état actuel
+		current, err := r.GetMessageByID(ctx, messageID)
+		return current, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	message.Summary = models.SummarizeReactions(message.Reactions)
+	return &message, true, nil
+}
+
 // MarkMessageAsRead marque un message comme lu
 func (r *ChatRepository) MarkMessageAsRead(ctx context.Context, messageID primitive.ObjectID, userID primitive.ObjectID) error {
 	update := bson.M{
@@ -565,3 +687,154 @@ func (r *ChatRepository) MarkMessageAsRead(ctx context.Context, messageID primit
 	)
 	return err
 }
+
+// AnonymizeUserConversations anonymise un utilisateur dans ses conversations privées :
+// l'autre participant conserve l'historique, les messages de l'utilisateur sont attribués à replacement
+// et ses accusés de lecture et réactions sont retirés. Les conversations jamais acceptées et
+// les invitations de chat de l'utilisateur sont supprimées.
+func (r *ChatRepository) AnonymizeUserConversations(ctx context.Context, userID, replacement primitive.ObjectID) (conversations int64, messages int64, invitations int64, err error) {
+	// Conversations sans historique (invitation en attente ou refusée) : rien à conserver
+	pendingFilter := bson.M{
+		"participants.user_id": userID,
+		"status":               bson.M{"$nin": []string{"accepted", "active"}},
+	}
+	cursor, err := r.conversationCollection.Find(ctx, pendingFilter)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var pendingConversations []models.Conversation
+	if err = cursor.All(ctx, &pendingConversations); err != nil {
+		return 0, 0, 0, err
+	}
+	if len(pendingConversations) > 0 {
+		pendingIDs := make([]primitive.ObjectID, 0, len(pendingConversations))
+		for _, conversation := range pendingConversations {
+			pendingIDs = append(pendingIDs, conversation.ID)
+		}
+		if _, err := r.messageCollection.DeleteMany(ctx, bson.M{"conversation_id": bson.M{"$in": pendingIDs}}); err != nil {
+			return 0, 0, 0, err
+		}
+		if _, err := r.conversationCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": pendingIDs}}); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	// Messages envoyés : attribués au remplaçant
+	messageResult, err := r.messageCollection.UpdateMany(ctx,
+		bson.M{"sender_id": userID},
+		bson.M{"$set": bson.M{"sender_id": replacement}},
+	)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	messages = messageResult.ModifiedCount
+
+	// Accusés de lecture et réactions de l'utilisateur sur les autres messages
+	_, err = r.messageCollection.UpdateMany(ctx,
+		bson.M{"$or": []bson.M{
+			{"read_by.user_id": userID},
+			{"reactions.user_id": userID.Hex()},
+		}},
+		bson.M{"$pull": bson.M{
+			"read_by":   bson.M{"user_id": userID},
+			"reactions": bson.M{"user_id": userID.Hex()},
+		}},
+	)
+	if err != nil {
+		return 0, messages, 0, err
+	}
+
+	// Conversations conservées : l'utilisateur devient un participant parti
+	conversationResult, err := r.conversationCollection.UpdateMany(ctx,
+		bson.M{"participants.user_id": userID},
+		bson.M{"$set": bson.M{
+			"participants.$[p].user_id": replacement,
+			"participants.$[p].status":  "left",
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"p.user_id": userID}},
+		}),
+	)
+	if err != nil {
+		return 0, messages, 0, err
+	}
+	conversations = conversationResult.ModifiedCount
+
+	if _, err := r.conversationCollection.UpdateMany(ctx,
+		bson.M{"created_by": userID},
+		bson.M{"$set": bson.M{"created_by": replacement}},
+	); err != nil {
+		return conversations, messages, 0, err
+	}
+
+	invitationResult, err := r.InvitationCollection.DeleteMany(ctx, bson.M{
+		"$or": []bson.M{
+			{"from_user_id": userID},
+			{"to_user_id": userID},
+		},
+	})
+	if err != nil {
+		return conversations, messages, 0, err
+	}
+
+	return conversations, messages, invitationResult.DeletedCount, nil
+}
+
+// FindMessagesBySender retourne tous les messages privés envoyés par un utilisateur
+func (r *ChatRepository) FindMessagesBySender(ctx context.Context, senderID primitive.ObjectID) ([]models.Message, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.messageCollection.Find(ctx, bson.M{"sender_id": senderID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []models.Message
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// GetConversationIDs retourne les IDs des conversations actives d'un utilisateur
+func (r *ChatRepository) GetConversationIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	ids, err := r.conversationCollection.Distinct(ctx, "_id", bson.M{
+		"participants.user_id": userID,
+		"status":               bson.M{"$in": []string{"accepted", "active"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conversationIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, ok := id.(primitive.ObjectID); ok {
+			conversationIDs = append(conversationIDs, objectID)
+		}
+	}
+	return conversationIDs, nil
+}
+
+// SearchMessages recherche des messages (index texte) dans des conversations, du plus récent au plus ancien.
+// senderID restreint aux messages d'un expéditeur.
+func (r *ChatRepository) SearchMessages(ctx context.Context, conversationIDs []primitive.ObjectID, senderID *primitive.ObjectID, filter models.MessageSearchFilter) ([]models.Message, error) {
+	query := searchQuery(filter)
+	query["conversation_id"] = bson.M{"$in": conversationIDs}
+	if senderID != nil {
+		query["sender_id"] = *senderID
+	}
+
+	cursor, err := r.messageCollection.Find(ctx, query, searchOptions(filter))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []models.Message
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
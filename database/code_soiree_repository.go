@@ -9,149 +9,315 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// CodeSoireeRepository gère les opérations sur les codes de soirée
-// Les codes sont stockés dans la collection "events" (champ code_soiree)
+// CodeSoireeRepository gère les opérations sur les codes de soirée (collection "codes_soiree")
 type CodeSoireeRepository struct {
+	collection      *mongo.Collection
 	eventCollection *mongo.Collection
-	userCollection  *mongo.Collection
 }
 
 // NewCodeSoireeRepository crée une nouvelle instance
 func NewCodeSoireeRepository(db *mongo.Database) *CodeSoireeRepository {
 	return &CodeSoireeRepository{
+		collection:      db.Collection("codes_soiree"),
 		eventCollection: db.Collection("events"),
-		userCollection:  db.Collection("users"),
 	}
 }
 
-// IsCodeValid vérifie si un code existe dans un événement (pas annulé)
+// usableFilter retourne le filtre des codes actifs, non expirés et dont le quota n'est pas atteint
+func usableFilter(code string, now time.Time) bson.M {
+	return bson.M{
+		"code":   code,
+		"active": true,
+		"$and": []bson.M{
+			{"$or": []bson.M{
+				{"expires_at": bson.M{"$exists": false}},
+				{"expires_at": nil},
+				{"expires_at": bson.M{"$gt": now}},
+			}},
+			{"$or": []bson.M{
+				{"max_utilisations": 0},
+				{"$expr": bson.M{"$lt": bson.A{"$utilisations", "$max_utilisations"}}},
+			}},
+		},
+	}
+}
+
+// Validate vérifie qu'un code est utilisable.
+// Retourne le code et une raison de refus (vide si le code est valide).
+func (r *CodeSoireeRepository) Validate(code string) (*models.CodeSoiree, string, error) {
+	codeSoiree, err := r.FindByCode(code)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if codeSoiree == nil || !codeSoiree.Active {
+		return codeSoiree, "Code de soirée invalide ou inactif", nil
+	}
+
+	if codeSoiree.ExpiresAt != nil && !codeSoiree.ExpiresAt.After(time.Now()) {
+		return codeSoiree, "Ce code de soirée a expiré", nil
+	}
+
+	if codeSoiree.MaxUtilisations > 0 && codeSoiree.Utilisations >= codeSoiree.MaxUtilisations {
+		return codeSoiree, "Ce code de soirée a atteint son nombre maximum d'utilisations", nil
+	}
+
+	// Le code doit donner accès à au moins un événement non annulé
+	if len(codeSoiree.EventIDs) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		count, err := r.eventCollection.CountDocuments(ctx, bson.M{
+			"_id":    bson.M{"$in": codeSoiree.EventIDs},
+			"statut": bson.M{"$ne": "annule"},
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("erreur lors de la vérification des événements du code: %w", err)
+		}
+		if count == 0 {
+			return codeSoiree, "Code de soirée invalide ou inactif", nil
+		}
+	}
+
+	return codeSoiree, "", nil
+}
+
+// IsCodeValid vérifie si un code est utilisable (actif, non expiré, quota non atteint)
 func (r *CodeSoireeRepository) IsCodeValid(code string) (bool, error) {
+	_, reason, err := r.Validate(code)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la vérification du code: %w", err)
+	}
+	return reason == "", nil
+}
+
+// Use enregistre l'utilisation d'un code par un utilisateur.
+// Le contrôle d'expiration et de quota se fait dans la même opération que l'incrément :
+// retourne false si le code n'est plus utilisable.
+func (r *CodeSoireeRepository) Use(code string, userEmail string) (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Chercher un événement avec ce code_soiree qui n'est pas annulé
-	filter := bson.M{
-		"code_soiree": code,
-		"statut": bson.M{"$ne": "annule"}, // Pas annulé
+	now := time.Now()
+	result, err := r.collection.UpdateOne(
+		ctx,
+		usableFilter(code, now),
+		bson.M{
+			"$inc":  bson.M{"utilisations": 1},
+			"$push": bson.M{"historique": models.CodeSoireeUsage{UserEmail: userEmail, UsedAt: now}},
+		},
+	)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de l'utilisation du code: %w", err)
 	}
-	
-	count, err := r.eventCollection.CountDocuments(ctx, filter)
 
+	return result.ModifiedCount > 0, nil
+}
+
+// ReleaseUsage annule l'utilisation d'un code (création de compte échouée)
+func (r *CodeSoireeRepository) ReleaseUsage(code string, userEmail string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"code": code, "historique.user_email": userEmail},
+		bson.M{
+			"$inc":  bson.M{"utilisations": -1},
+			"$pull": bson.M{"historique": bson.M{"user_email": userEmail}},
+		},
+	)
 	if err != nil {
-		return false, fmt.Errorf("erreur lors de la vérification du code: %w", err)
+		return fmt.Errorf("erreur lors de l'annulation de l'utilisation du code: %w", err)
+	}
+
+	return nil
+}
+
+// FindByCode recherche un code de soirée
+func (r *CodeSoireeRepository) FindByCode(code string) (*models.CodeSoiree, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var codeSoiree models.CodeSoiree
+	err := r.collection.FindOne(ctx, bson.M{"code": code}).Decode(&codeSoiree)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
 	}
 
-	// Debug log
-	fmt.Printf("🔍 IsCodeValid('%s'): count=%d, valid=%v\n", code, count, count > 0)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche du code: %w", err)
+	}
 
-	return count > 0, nil
+	return &codeSoiree, nil
 }
 
-// IncrementUsage ne fait rien (compteur géré par nb d'utilisateurs dans DB)
-func (r *CodeSoireeRepository) IncrementUsage(code string) error {
-	// Pas besoin d'incrémenter - le compteur est le nombre d'utilisateurs avec ce code dans la DB
-	return nil
+// FindByID recherche un code de soirée par son ID
+func (r *CodeSoireeRepository) FindByID(id primitive.ObjectID) (*models.CodeSoiree, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var codeSoiree models.CodeSoiree
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&codeSoiree)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche du code: %w", err)
+	}
+
+	return &codeSoiree, nil
 }
 
-// FindAll retourne tous les codes de soirée depuis les événements
+// FindAll retourne tous les codes de soirée, du plus récent au plus ancien
 func (r *CodeSoireeRepository) FindAll() ([]models.CodeSoiree, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Récupérer tous les code_soiree uniques depuis events
-	pipeline := []bson.M{
-		{"$match": bson.M{"code_soiree": bson.M{"$ne": ""}}}, // Ignorer les événements sans code
-		{"$group": bson.M{
-			"_id":        "$code_soiree",
-			"created_at": bson.M{"$first": "$created_at"},
-			"statut":     bson.M{"$first": "$statut"},
-		}},
-		{"$sort": bson.M{"created_at": -1}},
-	}
-
-	cursor, err := r.eventCollection.Aggregate(ctx, pipeline)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, fmt.Errorf("erreur lors de la recherche des codes: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var results []bson.M
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erreur lors du décodage: %w", err)
-	}
-
-	// Construire la liste des codes avec le compteur d'utilisations
 	var codes []models.CodeSoiree
-	for _, result := range results {
-		code := result["_id"].(string)
-		createdAt := result["created_at"].(primitive.DateTime).Time()
-		statut := result["statut"].(string)
-
-		// Compter le nombre d'utilisateurs avec ce code
-		utilisations, _ := r.userCollection.CountDocuments(ctx, bson.M{"code_soiree": code})
-
-		codes = append(codes, models.CodeSoiree{
-			Code:         code,
-			Utilisations: int(utilisations),
-			CreatedAt:    createdAt,
-			Active:       statut != "annule", // Actif si l'événement n'est pas annulé
-		})
+	if err = cursor.All(ctx, &codes); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage: %w", err)
 	}
 
 	return codes, nil
 }
 
-// FindCurrent retourne le code de soirée le plus récent
+// FindCurrent retourne le code de soirée actif le plus récent
 func (r *CodeSoireeRepository) FindCurrent() (*models.CodeSoiree, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Récupérer l'événement le plus récent avec un code_soiree
-	pipeline := []bson.M{
-		{"$match": bson.M{
-			"code_soiree": bson.M{"$ne": ""},
-			"statut": bson.M{"$ne": "annule"},
-		}},
-		{"$sort": bson.M{"created_at": -1}},
-		{"$limit": 1},
+	filter := usableFilter("", time.Now())
+	delete(filter, "code")
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	var codeSoiree models.CodeSoiree
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&codeSoiree)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil // Aucun code trouvé
 	}
 
-	cursor, err := r.eventCollection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("erreur lors de la recherche du code actuel: %w", err)
 	}
-	defer cursor.Close(ctx)
 
-	var results []bson.M
-	if err = cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("erreur lors du décodage: %w", err)
+	return &codeSoiree, nil
+}
+
+// Create crée un nouveau code de soirée
+func (r *CodeSoireeRepository) Create(code *models.CodeSoiree) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	code.ID = primitive.NewObjectID()
+	code.CreatedAt = time.Now()
+	code.Utilisations = 0
+	code.Historique = []models.CodeSoireeUsage{}
+
+	if code.EventIDs == nil {
+		code.EventIDs = []primitive.ObjectID{}
 	}
 
-	if len(results) == 0 {
-		return nil, nil // Aucun code trouvé
+	_, err := r.collection.InsertOne(ctx, code)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("ce code existe déjà")
+		}
+		return fmt.Errorf("erreur lors de la création du code: %w", err)
 	}
 
-	result := results[0]
-	code := result["code_soiree"].(string)
-	createdAt := result["created_at"].(primitive.DateTime).Time()
+	return nil
+}
+
+// Update met à jour un code de soirée
+func (r *CodeSoireeRepository) Update(id primitive.ObjectID, update bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-	// Compter les utilisations
-	utilisations, _ := r.userCollection.CountDocuments(ctx, bson.M{"code_soiree": code})
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour du code: %w", err)
+	}
 
-	return &models.CodeSoiree{
-		Code:         code,
-		Utilisations: int(utilisations),
-		CreatedAt:    createdAt,
-		Active:       true,
-	}, nil
+	return nil
 }
 
-// Create crée un nouveau code en créant un nouvel événement (non utilisé - codes créés avec events)
-func (r *CodeSoireeRepository) Create(code *models.CodeSoiree) error {
-	// Les codes sont créés automatiquement quand on crée un événement
-	// Cette méthode n'est plus utilisée
+// EnsureForEvent rattache un code à un événement, en créant le code s'il n'existe pas
+// (sans limite d'utilisation ni expiration, comme les codes saisis à la création d'un événement)
+func (r *CodeSoireeRepository) EnsureForEvent(code string, eventID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"code": code},
+		bson.M{
+			"$addToSet": bson.M{"event_ids": eventID},
+			"$setOnInsert": bson.M{
+				"max_utilisations": 0,
+				"utilisations":     0,
+				"historique":       []models.CodeSoireeUsage{},
+				"created_at":       time.Now(),
+				"active":           true,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors du rattachement du code à l'événement: %w", err)
+	}
+
 	return nil
 }
 
+// AnonymizeUsage remplace l'email d'un utilisateur dans l'historique des codes
+// (le compteur d'utilisations est conservé)
+func (r *CodeSoireeRepository) AnonymizeUsage(userEmail string, replacement string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"historique.user_email": userEmail},
+		bson.M{"$set": bson.M{"historique.$[usage].user_email": replacement}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"usage.user_email": userEmail}},
+		}),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de l'anonymisation de l'historique des codes: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// RemoveEvent détache un événement supprimé de tous les codes
+func (r *CodeSoireeRepository) RemoveEvent(eventID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"event_ids": eventID},
+		bson.M{"$pull": bson.M{"event_ids": eventID}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors du détachement de l'événement des codes: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -6,6 +6,8 @@ import (
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -43,6 +45,103 @@ func Connect(uri, dbName string) error {
 		return fmt.Errorf("erreur lors de la création des index: %w", err)
 	}
 
+	// Migrer les données existantes (non bloquant)
+	if err = migrateLegacyUsers(); err != nil {
+		log.Printf("⚠️  Migration des utilisateurs: %v", err)
+	}
+	if err = migrateLegacyCodesSoiree(); err != nil {
+		log.Printf("⚠️  Migration des codes de soirée: %v", err)
+	}
+
+	return nil
+}
+
+// migrateLegacyUsers considère comme vérifiés les comptes créés avant la vérification d'e-mail
+func migrateLegacyUsers() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := DB.Collection("users").UpdateMany(
+		ctx,
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la migration email_verified: %w", err)
+	}
+
+	if result.ModifiedCount > 0 {
+		log.Printf("✓ %d compte(s) existant(s) marqué(s) comme vérifié(s)", result.ModifiedCount)
+	}
+
+	return nil
+}
+
+// migrateLegacyCodesSoiree crée les documents "codes_soiree" à partir des codes
+// auparavant stockés uniquement sur les événements (champ code_soiree)
+func migrateLegacyCodesSoiree() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := DB.Collection("events").Find(ctx, bson.M{"code_soiree": bson.M{"$nin": bson.A{"", nil}}})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la lecture des événements: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []struct {
+		ID         primitive.ObjectID `bson:"_id"`
+		CodeSoiree string             `bson:"code_soiree"`
+		CreatedAt  time.Time          `bson:"created_at"`
+	}
+	if err = cursor.All(ctx, &events); err != nil {
+		return fmt.Errorf("erreur lors du décodage des événements: %w", err)
+	}
+
+	codesCollection := DB.Collection("codes_soiree")
+	migrated := 0
+	for _, event := range events {
+		// Ne pas écraser un code déjà présent dans la collection
+		count, err := codesCollection.CountDocuments(ctx, bson.M{"code": event.CodeSoiree})
+		if err != nil {
+			return fmt.Errorf("erreur lors de la vérification du code %s: %w", event.CodeSoiree, err)
+		}
+		if count > 0 {
+			_, err = codesCollection.UpdateOne(ctx,
+				bson.M{"code": event.CodeSoiree},
+				bson.M{"$addToSet": bson.M{"event_ids": event.ID}},
+			)
+			if err != nil {
+				return fmt.Errorf("erreur lors du rattachement du code %s: %w", event.CodeSoiree, err)
+			}
+			continue
+		}
+
+		// Les utilisations passées correspondent aux comptes créés avec ce code
+		utilisations, err := DB.Collection("users").CountDocuments(ctx, bson.M{"code_soiree": event.CodeSoiree})
+		if err != nil {
+			return fmt.Errorf("erreur lors du comptage des utilisations de %s: %w", event.CodeSoiree, err)
+		}
+
+		_, err = codesCollection.InsertOne(ctx, bson.M{
+			"code":             event.CodeSoiree,
+			"event_ids":        bson.A{event.ID},
+			"max_utilisations": 0,
+			"utilisations":     utilisations,
+			"historique":       bson.A{},
+			"created_at":       event.CreatedAt,
+			"active":           true,
+		})
+		if err != nil {
+			return fmt.Errorf("erreur lors de la migration du code %s: %w", event.CodeSoiree, err)
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		log.Printf("✓ %d code(s) de soirée migré(s) vers la collection codes_soiree", migrated)
+	}
+
 	return nil
 }
 
@@ -75,6 +174,105 @@ func createIndexes() error {
 		return fmt.Errorf("erreur lors de la création de l'index email: %w", err)
 	}
 
+	// Index unique sur le code de soirée
+	codeIndex := mongo.IndexModel{
+		Keys:    map[string]interface{}{"code": 1},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err = DB.Collection("codes_soiree").Indexes().CreateOne(ctx, codeIndex)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de l'index code: %w", err)
+	}
+
+	// Index du journal d'audit (tri par date, filtre par cible)
+	_, err = DB.Collection("audit_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création des index audit_log: %w", err)
+	}
+
+	// Fils de discussion des groupes (réponses d'un message)
+	_, err = DB.Collection("chat_group_messages").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de l'index thread_id: %w", err)
+	}
+
+	// Recherche plein texte dans l'historique du chat (conversations privées et groupes)
+	for _, collection := range []string{"messages", "chat_group_messages"} {
+		_, err = DB.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "content", Value: "text"}},
+			Options: options.Index().SetDefaultLanguage("french"),
+		})
+		if err != nil {
+			return fmt.Errorf("erreur lors de la création de l'index texte %s: %w", collection, err)
+		}
+	}
+
+	// Journal des événements WebSocket (reprise après reconnexion), purgé après 7 jours
+	_, err = DB.Collection("ws_event_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "seq", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7 * 24 * 3600)},
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création des index ws_event_log: %w", err)
+	}
+
+	// Connexions WebSocket par instance : comptage par utilisateur, compteurs des instances arrêtées purgés
+	_, err = DB.Collection("ws_presence").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "node_id", Value: 1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(WSPresenceTTL.Seconds()))},
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création des index ws_presence: %w", err)
+	}
+
+	// Centre de notifications : liste par utilisateur, compteur de non lues, purge après 90 jours
+	_, err = DB.Collection("notifications").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(90 * 24 * 3600)},
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création des index notifications: %w", err)
+	}
+
+	// Préférences de notifications : un document par utilisateur
+	_, err = DB.Collection("notification_preferences").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de l'index notification_preferences: %w", err)
+	}
+
+	// Outbox des notifications : une entrée par clé d'idempotence, file des envois dus, purge des envoyés après 30 jours
+	_, err = DB.Collection("notification_outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "idempotency_key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "sent_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(30 * 24 * 3600)},
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création des index notification_outbox: %w", err)
+	}
+
+	// Sessions de rafraîchissement : recherche par jeton courant ou précédent, révocation par utilisateur, purge à l'expiration
+	_, err = DB.Collection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "previous_refresh_hash", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "user_email", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création des index sessions: %w", err)
+	}
+
 	log.Println("✓ Index MongoDB créés")
 	return nil
 }
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"premier-an-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// EmailVerificationRepository gère les tokens de vérification d'adresse e-mail
+type EmailVerificationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewEmailVerificationRepository crée une nouvelle instance de EmailVerificationRepository
+func NewEmailVerificationRepository(db *mongo.Database) *EmailVerificationRepository {
+	return &EmailVerificationRepository{
+		collection: db.Collection("email_verification_tokens"),
+	}
+}
+
+// Create enregistre un nouveau token de vérification
+func (r *EmailVerificationRepository) Create(token *models.EmailVerificationToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création du token de vérification: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marque un token comme utilisé et le retourne.
+// Retourne nil si le token est inconnu, expiré ou déjà utilisé.
+func (r *EmailVerificationRepository) Consume(tokenHash string) (*models.EmailVerificationToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var token models.EmailVerificationToken
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"token_hash": tokenHash,
+			"used_at":    bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+	).Decode(&token)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la validation du token de vérification: %w", err)
+	}
+
+	return &token, nil
+}
+
+// InvalidateAllForUser invalide tous les tokens encore utilisables d'un utilisateur
+func (r *EmailVerificationRepository) InvalidateAllForUser(userEmail string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"user_email": userEmail, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'invalidation des tokens de vérification: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllForUser supprime tous les liens de vérification d'un utilisateur (compte supprimé)
+func (r *EmailVerificationRepository) DeleteAllForUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des liens de vérification: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
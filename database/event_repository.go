@@ -106,6 +106,55 @@ func (r *EventRepository) Update(id primitive.ObjectID, update bson.M) error {
 	return nil
 }
 
+// ReservePlaces incrémente le compteur d'inscrits de n uniquement si la capacité le permet.
+// Le contrôle et l'écriture se font en une seule opération : deux inscriptions
+// simultanées ne peuvent pas dépasser la capacité. Retourne false s'il n'y a pas assez de places.
+func (r *EventRepository) ReservePlaces(id primitive.ObjectID, n int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": id,
+			"$expr": bson.M{
+				"$lte": bson.A{bson.M{"$add": bson.A{"$inscrits", n}}, "$capacite"},
+			},
+		},
+		bson.M{
+			"$inc": bson.M{"inscrits": n},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la réservation des places: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ReleasePlaces décrémente le compteur d'inscrits de n sans descendre sous zéro
+func (r *EventRepository) ReleasePlaces(id primitive.ObjectID, n int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"inscrits":   bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$inscrits", n}}}},
+				"updated_at": time.Now(),
+			}}},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la libération des places: %w", err)
+	}
+
+	return nil
+}
+
 // Delete supprime un événement
 func (r *EventRepository) Delete(id primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
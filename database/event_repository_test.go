@@ -0,0 +1,84 @@
+package database
+
+import (
+	"sync"
+	"testing"
+
+	"premier-an-backend/models"
+)
+
+// TestReservePlacesNeverExceedsCapacity lance des réservations concurrentes sur un événement
+// et vérifie que la capacité n'est jamais dépassée
+func TestReservePlacesNeverExceedsCapacity(t *testing.T) {
+	repo := NewEventRepository(testDatabase(t))
+
+	const capacite = 10
+	const requests = 50
+
+	event := &models.Event{Titre: "Test concurrence", Capacite: capacite}
+	if err := repo.Create(event); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	successes := 0
+	start := make(chan struct{})
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			reserved, err := repo.ReservePlaces(event.ID, 1)
+			if err != nil {
+				t.Errorf("ReservePlaces: %v", err)
+				return
+			}
+			if reserved {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if successes != capacite {
+		t.Errorf("%d réservations acceptées, attendu %d", successes, capacite)
+	}
+
+	updated, err := repo.FindByID(event.ID)
+	if err != nil || updated == nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if updated.Inscrits != capacite {
+		t.Errorf("inscrits = %d, attendu %d", updated.Inscrits, capacite)
+	}
+}
+
+// TestReservePlacesGroupDoesNotOverflow vérifie qu'une réservation de plusieurs places est refusée
+// si elle dépasse la capacité restante, et que ReleasePlaces libère les places
+func TestReservePlacesGroupDoesNotOverflow(t *testing.T) {
+	repo := NewEventRepository(testDatabase(t))
+
+	event := &models.Event{Titre: "Test groupe", Capacite: 5}
+	if err := repo.Create(event); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if reserved, err := repo.ReservePlaces(event.ID, 4); err != nil || !reserved {
+		t.Fatalf("ReservePlaces(4) = %v, %v", reserved, err)
+	}
+	if reserved, err := repo.ReservePlaces(event.ID, 2); err != nil || reserved {
+		t.Fatalf("ReservePlaces(2) au-delà de la capacité = %v, %v", reserved, err)
+	}
+	if err := repo.ReleasePlaces(event.ID, 4); err != nil {
+		t.Fatalf("ReleasePlaces: %v", err)
+	}
+	if reserved, err := repo.ReservePlaces(event.ID, 5); err != nil || !reserved {
+		t.Fatalf("ReservePlaces(5) après libération = %v, %v", reserved, err)
+	}
+}
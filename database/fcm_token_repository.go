@@ -117,6 +117,25 @@ func (r *FCMTokenRepository) FindByToken(token string) (*models.FCMToken, error)
 	return &fcmToken, nil
 }
 
+// FindByTokens recherche les enregistrements de plusieurs tokens (propriétaires compris)
+func (r *FCMTokenRepository) FindByTokens(tokens []string) ([]models.FCMToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var records []models.FCMToken
+	cursor, err := r.collection.Find(ctx, bson.M{"token": bson.M{"$in": tokens}})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des tokens: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des tokens: %w", err)
+	}
+
+	return records, nil
+}
+
 // Delete supprime un token
 func (r *FCMTokenRepository) Delete(token string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -95,6 +95,10 @@ func (r *InscriptionRepository) Update(inscription *models.Inscription) error {
 
 	inscription.UpdatedAt = time.Now()
 
+	if inscription.Accompagnants == nil {
+		inscription.Accompagnants = []models.Accompagnant{}
+	}
+
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": inscription.ID},
@@ -112,6 +116,37 @@ func (r *InscriptionRepository) Update(inscription *models.Inscription) error {
 	return nil
 }
 
+// CheckIn marque le titulaire et tous ses accompagnants comme présents.
+// L'opération n'aboutit qu'une seule fois : retourne false si le billet a déjà été scanné.
+// Pipeline de mise à jour : fonctionne aussi quand accompagnants est null ou absent (anciennes inscriptions).
+func (r *InscriptionRepository) CheckIn(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "checked_in_at": bson.M{"$exists": false}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"checked_in_at": now,
+				"updated_at":    now,
+				"accompagnants": bson.M{"$map": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$accompagnants", bson.A{}}},
+					"as":    "accompagnant",
+					"in":    bson.M{"$mergeObjects": bson.A{"$$accompagnant", bson.M{"checked_in_at": now}}},
+				}},
+			}}},
+		},
+	)
+
+	if err != nil {
+		return false, fmt.Errorf("erreur lors du check-in: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
 // Delete supprime une inscription par event_id et user_email
 func (r *InscriptionRepository) Delete(eventID primitive.ObjectID, userEmail string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -247,3 +282,28 @@ func (r *InscriptionRepository) FindByEventID(eventID primitive.ObjectID) ([]mod
 	return inscriptions, nil
 }
 
+// DeleteByUser supprime toutes les inscriptions d'un utilisateur
+func (r *InscriptionRepository) DeleteByUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des inscriptions: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DeleteByEvent supprime toutes les inscriptions d'un événement
+func (r *InscriptionRepository) DeleteByEvent(eventID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"event_id": eventID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des inscriptions: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
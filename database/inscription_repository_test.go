@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestCheckInWithoutAccompagnants vérifie le check-in d'inscriptions dont accompagnants est null ou absent
+func TestCheckInWithoutAccompagnants(t *testing.T) {
+	db := testDatabase(t)
+	repo := NewInscriptionRepository(db)
+
+	tests := []struct {
+		name     string
+		document bson.M
+	}{
+		{"null", bson.M{"user_email": "null@example.com", "nombre_personnes": 1, "accompagnants": nil}},
+		{"absent", bson.M{"user_email": "absent@example.com", "nombre_personnes": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			id := primitive.NewObjectID()
+			tt.document["_id"] = id
+			tt.document["event_id"] = primitive.NewObjectID()
+			if _, err := db.Collection("inscriptions").InsertOne(ctx, tt.document); err != nil {
+				t.Fatalf("InsertOne: %v", err)
+			}
+
+			checkedIn, err := repo.CheckIn(id)
+			if err != nil {
+				t.Fatalf("CheckIn: %v", err)
+			}
+			if !checkedIn {
+				t.Fatal("CheckIn = false, attendu true")
+			}
+
+			inscription, err := repo.FindByID(id)
+			if err != nil || inscription == nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			if inscription.CheckedInAt == nil {
+				t.Error("checked_in_at non renseigné")
+			}
+			if inscription.Accompagnants == nil || len(inscription.Accompagnants) != 0 {
+				t.Errorf("accompagnants = %v, attendu []", inscription.Accompagnants)
+			}
+
+			// Second scan refusé
+			if checkedIn, err := repo.CheckIn(id); err != nil || checkedIn {
+				t.Errorf("second CheckIn = %v, %v, attendu false", checkedIn, err)
+			}
+		})
+	}
+}
+
+// TestUpdateStoresEmptyAccompagnants vérifie qu'une mise à jour sans accompagnants enregistre un tableau vide
+func TestUpdateStoresEmptyAccompagnants(t *testing.T) {
+	db := testDatabase(t)
+	repo := NewInscriptionRepository(db)
+
+	inscription := &models.Inscription{EventID: primitive.NewObjectID(), UserEmail: "solo@example.com", NombrePersonnes: 1}
+	if err := repo.Create(inscription); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	inscription.Accompagnants = nil
+	if err := repo.Update(inscription); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var raw bson.M
+	if err := db.Collection("inscriptions").FindOne(ctx, bson.M{"_id": inscription.ID}).Decode(&raw); err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if _, ok := raw["accompagnants"].(bson.A); !ok {
+		t.Errorf("accompagnants = %v, attendu un tableau", raw["accompagnants"])
+	}
+
+	if checkedIn, err := repo.CheckIn(inscription.ID); err != nil || !checkedIn {
+		t.Errorf("CheckIn = %v, %v, attendu true", checkedIn, err)
+	}
+}
@@ -123,3 +123,47 @@ func (r *MediaRepository) CountByEventAndType(eventID primitive.ObjectID, mediaT
 	return count, nil
 }
 
+// FindByUser récupère tous les médias d'un utilisateur
+func (r *MediaRepository) FindByUser(userEmail string) ([]models.Media, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des médias: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var medias []models.Media
+	if err := cursor.All(ctx, &medias); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des médias: %w", err)
+	}
+
+	return medias, nil
+}
+
+// DeleteByUser supprime tous les médias d'un utilisateur
+func (r *MediaRepository) DeleteByUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des médias: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DeleteByEvent supprime tous les médias d'un événement
+func (r *MediaRepository) DeleteByEvent(eventID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"event_id": eventID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des médias: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
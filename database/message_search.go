@@ -0,0 +1,41 @@
+package database
+
+import (
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// searchQuery construit le filtre commun de recherche de messages (texte, dates, curseur)
+func searchQuery(filter models.MessageSearchFilter) bson.M {
+	query := bson.M{
+		"$text":      bson.M{"$search": filter.Query},
+		"deleted_at": nil,
+	}
+
+	createdAt := bson.M{}
+	if filter.From != nil {
+		createdAt["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		createdAt["$lt"] = *filter.To
+	}
+	if len(createdAt) > 0 {
+		query["created_at"] = createdAt
+	}
+
+	if filter.Before != nil {
+		query["_id"] = bson.M{"$lt": *filter.Before}
+	}
+
+	return query
+}
+
+// searchOptions trie les résultats du plus récent au plus ancien ; un résultat de plus que la limite
+// est demandé pour savoir s'il reste une page
+func searchOptions(filter models.MessageSearchFilter) *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(filter.Limit + 1))
+}
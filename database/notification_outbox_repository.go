@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationOutboxRepository gère l'outbox des notifications push (envois durables avec relances)
+type NotificationOutboxRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationOutboxRepository crée une nouvelle instance de NotificationOutboxRepository
+func NewNotificationOutboxRepository(db *mongo.Database) *NotificationOutboxRepository {
+	return &NotificationOutboxRepository{
+		collection: db.Collection("notification_outbox"),
+	}
+}
+
+// Create enregistre un message. Retourne false (sans erreur) si un message existe déjà pour la même clé d'idempotence.
+func (r *NotificationOutboxRepository) Create(message *models.OutboxMessage) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	message.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, message); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erreur lors de l'enregistrement du message outbox: %w", err)
+	}
+	return true, nil
+}
+
+// ClaimNext verrouille le prochain message à envoyer (échéance atteinte, ou verrou expiré après un arrêt du serveur)
+// et incrémente son nombre d'essais. Retourne nil s'il n'y a rien à envoyer.
+func (r *NotificationOutboxRepository) ClaimNext(lease time.Duration) (*models.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.OutboxStatusPending, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"status": models.OutboxStatusProcessing, "locked_until": bson.M{"$lte": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.OutboxStatusProcessing,
+			"locked_until": now.Add(lease),
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var message models.OutboxMessage
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erreur lors de la réservation d'un message outbox: %w", err)
+	}
+	return &message, nil
+}
+
+// MarkSent marque un message comme envoyé
+func (r *NotificationOutboxRepository) MarkSent(id primitive.ObjectID, delivered, failed int) error {
+	now := time.Now()
+	return r.finish(id, bson.M{
+		"$set": bson.M{
+			"status":     models.OutboxStatusSent,
+			"delivered":  delivered,
+			"failed":     failed,
+			"sent_at":    now,
+			"updated_at": now,
+		},
+		"$unset": bson.M{"locked_until": "", "last_error": ""},
+	})
+}
+
+// ScheduleRetry remet un message en attente jusqu'au prochain essai
+func (r *NotificationOutboxRepository) ScheduleRetry(id primitive.ObjectID, nextAttemptAt time.Time, lastError string, delivered, failed int) error {
+	return r.finish(id, bson.M{
+		"$set": bson.M{
+			"status":          models.OutboxStatusPending,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+			"delivered":       delivered,
+			"failed":          failed,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{"locked_until": ""},
+	})
+}
+
+// MarkDead abandonne un message après le nombre maximal d'essais
+func (r *NotificationOutboxRepository) MarkDead(id primitive.ObjectID, lastError string, delivered, failed int) error {
+	return r.finish(id, bson.M{
+		"$set": bson.M{
+			"status":     models.OutboxStatusDead,
+			"last_error": lastError,
+			"delivered":  delivered,
+			"failed":     failed,
+			"updated_at": time.Now(),
+		},
+		"$unset": bson.M{"locked_until": ""},
+	})
+}
+
+// MarkRecorded note que la notification est enregistrée dans le centre de notifications (pas de doublon lors des relances).
+// Retourne false si elle l'était déjà : un seul appelant doit l'enregistrer.
+func (r *NotificationOutboxRepository) MarkRecorded(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "recorded": false}, bson.M{"$set": bson.M{"recorded": true}})
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la mise à jour du message outbox: %w", err)
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// FindUnrecorded retourne les messages en attente pas encore enregistrés dans le centre de notifications
+func (r *NotificationOutboxRepository) FindUnrecorded(limit int) ([]models.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"status": models.OutboxStatusPending, "recorded": false}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des messages outbox: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var messages []models.OutboxMessage
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des messages outbox: %w", err)
+	}
+	return messages, nil
+}
+
+// finish applique le résultat d'un essai à un message réservé
+func (r *NotificationOutboxRepository) finish(id primitive.ObjectID, update bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.OutboxStatusProcessing}, update)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour du message outbox: %w", err)
+	}
+	return nil
+}
+
+// Requeue relance un message abandonné : il repart pour un nouveau cycle d'essais.
+// Retourne false si le message n'existe pas ou n'est pas abandonné.
+func (r *NotificationOutboxRepository) Requeue(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.OutboxStatusDead},
+		bson.M{"$set": bson.M{
+			"status":          models.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la relance du message outbox: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// FindByID récupère un message par son ID
+func (r *NotificationOutboxRepository) FindByID(id primitive.ObjectID) (*models.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var message models.OutboxMessage
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&message)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erreur lors de la recherche du message outbox: %w", err)
+	}
+	return &message, nil
+}
+
+// FindByStatus liste les messages, du plus récent au plus ancien, éventuellement filtrés par statut.
+// before : curseur (messages plus anciens que cet ID). Retourne jusqu'à limit+1 éléments pour détecter une page suivante.
+func (r *NotificationOutboxRepository) FindByStatus(status string, before *primitive.ObjectID, limit int) ([]models.OutboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if before != nil {
+		filter["_id"] = bson.M{"$lt": *before}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit + 1))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des messages outbox: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	messages := []models.OutboxMessage{}
+	if err = cursor.All(ctx, &messages); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des messages outbox: %w", err)
+	}
+	return messages, nil
+}
+
+// CountByStatus compte les messages par statut (tous les statuts sont présents, même à zéro)
+func (r *NotificationOutboxRepository) CountByStatus() (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors du comptage des messages outbox: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des compteurs outbox: %w", err)
+	}
+
+	counts := map[string]int64{
+		models.OutboxStatusPending:    0,
+		models.OutboxStatusProcessing: 0,
+		models.OutboxStatusSent:       0,
+		models.OutboxStatusDead:       0,
+	}
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Champs des réglages par cible (conversations privées ou groupes)
+const (
+	NotificationTargetConversations = "conversations"
+	NotificationTargetGroups        = "groups"
+)
+
+// NotificationPreferenceRepository gère les préférences de notifications push
+type NotificationPreferenceRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationPreferenceRepository crée une nouvelle instance de NotificationPreferenceRepository
+func NewNotificationPreferenceRepository(db *mongo.Database) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{
+		collection: db.Collection("notification_preferences"),
+	}
+}
+
+// FindByUserID récupère les préférences d'un utilisateur (nil s'il n'en a pas)
+func (r *NotificationPreferenceRepository) FindByUserID(userID string) (*models.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var preferences models.NotificationPreferences
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&preferences)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des préférences: %w", err)
+	}
+	return &preferences, nil
+}
+
+// FindByUserIDs récupère les préférences de plusieurs utilisateurs, indexées par email
+func (r *NotificationPreferenceRepository) FindByUserIDs(userIDs []string) (map[string]*models.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des préférences: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var list []models.NotificationPreferences
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des préférences: %w", err)
+	}
+
+	preferences := make(map[string]*models.NotificationPreferences, len(list))
+	for i := range list {
+		preferences[list[i].UserID] = &list[i]
+	}
+	return preferences, nil
+}
+
+// UpdateGlobal enregistre les réglages globaux (sourdine, heures calmes, catégories)
+func (r *NotificationPreferenceRepository) UpdateGlobal(preferences *models.NotificationPreferences) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"muted":      preferences.Muted,
+		"categories": preferences.Categories,
+		"updated_at": time.Now(),
+	}
+	unset := bson.M{}
+	if preferences.MutedUntil != nil {
+		set["muted_until"] = preferences.MutedUntil
+	} else {
+		unset["muted_until"] = ""
+	}
+	if preferences.QuietHours != nil {
+		set["quiet_hours"] = preferences.QuietHours
+	} else {
+		unset["quiet_hours"] = ""
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"user_id": preferences.UserID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour des préférences: %w", err)
+	}
+	return nil
+}
+
+// SetTargetSetting enregistre le réglage d'une conversation ou d'un groupe (target : NotificationTarget*)
+func (r *NotificationPreferenceRepository) SetTargetSetting(userID, target, id string, setting models.ChatNotificationSetting) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			target + "." + id: setting,
+			"updated_at":      time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"muted":      false,
+			"categories": models.DefaultNotificationCategories,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"user_id": userID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour des préférences: %w", err)
+	}
+	return nil
+}
+
+// RemoveTargetSetting rétablit le comportement par défaut d'une conversation ou d'un groupe
+func (r *NotificationPreferenceRepository) RemoveTargetSetting(userID, target, id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$unset": bson.M{target + "." + id: ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"user_id": userID}, update)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la mise à jour des préférences: %w", err)
+	}
+	return nil
+}
+
+// DeleteByUserID supprime les préférences d'un utilisateur
+func (r *NotificationPreferenceRepository) DeleteByUserID(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des préférences: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationRepository gère le centre de notifications (historique des push par utilisateur)
+type NotificationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationRepository crée une nouvelle instance de NotificationRepository
+func NewNotificationRepository(db *mongo.Database) *NotificationRepository {
+	return &NotificationRepository{
+		collection: db.Collection("notifications"),
+	}
+}
+
+// CreateMany enregistre des notifications (les IDs sont attribués ici)
+func (r *NotificationRepository) CreateMany(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(notifications))
+	for i := range notifications {
+		notifications[i].ID = primitive.NewObjectID()
+		documents[i] = notifications[i]
+	}
+
+	if _, err := r.collection.InsertMany(ctx, documents); err != nil {
+		return fmt.Errorf("erreur lors de l'enregistrement des notifications: %w", err)
+	}
+	return nil
+}
+
+// FindByUser récupère les notifications d'un utilisateur, de la plus récente à la plus ancienne.
+// before : curseur (notifications plus anciennes que cet ID). Retourne jusqu'à limit+1 éléments pour détecter une page suivante.
+func (r *NotificationRepository) FindByUser(userID string, before *primitive.ObjectID, unreadOnly bool, limit int) ([]models.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	if before != nil {
+		filter["_id"] = bson.M{"$lt": *before}
+	}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit + 1))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des notifications: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// CountUnread compte les notifications non lues d'un utilisateur
+func (r *NotificationRepository) CountUnread(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors du comptage des notifications: %w", err)
+	}
+	return count, nil
+}
+
+// CountUnreadByUsers compte les notifications non lues de plusieurs utilisateurs en une requête.
+// Un utilisateur sans notification non lue est absent du résultat.
+func (r *NotificationRepository) CountUnreadByUsers(userIDs []string) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"user_id": bson.M{"$in": userIDs}, "read": false}},
+		{"$group": bson.M{"_id": "$user_id", "count": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors du comptage des notifications: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		UserID string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des compteurs: %w", err)
+	}
+
+	counts := make(map[string]int64, len(results))
+	for _, result := range results {
+		counts[result.UserID] = result.Count
+	}
+	return counts, nil
+}
+
+// MarkAsRead marque une notification de l'utilisateur comme lue. Retourne false si elle n'existe pas.
+func (r *NotificationRepository) MarkAsRead(userID string, notificationID primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": notificationID, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la mise à jour de la notification: %w", err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// MarkAllAsRead marque toutes les notifications non lues de l'utilisateur comme lues
+func (r *NotificationRepository) MarkAllAsRead(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la mise à jour des notifications: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// FindAllByUser récupère toutes les notifications d'un utilisateur (export RGPD)
+func (r *NotificationRepository) FindAllByUser(userID string) ([]models.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des notifications: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// DeleteByUserID supprime toutes les notifications d'un utilisateur
+func (r *NotificationRepository) DeleteByUserID(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des notifications: %w", err)
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"premier-an-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PasswordResetRepository gère les tokens de réinitialisation de mot de passe
+type PasswordResetRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPasswordResetRepository crée une nouvelle instance de PasswordResetRepository
+func NewPasswordResetRepository(db *mongo.Database) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		collection: db.Collection("password_reset_tokens"),
+	}
+}
+
+// Create enregistre un nouveau token de réinitialisation
+func (r *PasswordResetRepository) Create(token *models.PasswordResetToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, token)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création du token de réinitialisation: %w", err)
+	}
+
+	return nil
+}
+
+// Consume marque un token comme utilisé et le retourne.
+// Retourne nil si le token est inconnu, expiré ou déjà utilisé.
+func (r *PasswordResetRepository) Consume(tokenHash string) (*models.PasswordResetToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var token models.PasswordResetToken
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"token_hash": tokenHash,
+			"used_at":    bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+	).Decode(&token)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la validation du token de réinitialisation: %w", err)
+	}
+
+	return &token, nil
+}
+
+// InvalidateAllForUser invalide tous les tokens encore utilisables d'un utilisateur
+func (r *PasswordResetRepository) InvalidateAllForUser(userEmail string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"user_email": userEmail, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'invalidation des tokens de réinitialisation: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllForUser supprime tous les liens de réinitialisation d'un utilisateur (compte supprimé)
+func (r *PasswordResetRepository) DeleteAllForUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des liens de réinitialisation: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"premier-an-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RefreshTokenDuration est la durée de vie d'une session sans renouvellement
+const RefreshTokenDuration = 30 * 24 * time.Hour
+
+// SessionRepository gère les sessions de connexion
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSessionRepository crée une nouvelle instance de SessionRepository
+func NewSessionRepository(db *mongo.Database) *SessionRepository {
+	return &SessionRepository{
+		collection: db.Collection("sessions"),
+	}
+}
+
+// Create crée une nouvelle session
+func (r *SessionRepository) Create(session *models.Session) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = now
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(RefreshTokenDuration)
+
+	_, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de la session: %w", err)
+	}
+
+	return nil
+}
+
+// FindByRefreshHash recherche une session par l'empreinte de son refresh token courant
+func (r *SessionRepository) FindByRefreshHash(hash string) (*models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"refresh_token_hash": hash}).Decode(&session)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche de la session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// FindByPreviousRefreshHash recherche une session dont le refresh token a déjà été remplacé
+func (r *SessionRepository) FindByPreviousRefreshHash(hash string) (*models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"previous_refresh_hash": hash}).Decode(&session)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche de la session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// Rotate remplace le refresh token d'une session active.
+// Retourne false si le token a déjà été utilisé ou si la session n'est plus valide.
+func (r *SessionRepository) Rotate(id primitive.ObjectID, oldHash string, newHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":                id,
+			"refresh_token_hash": oldHash,
+			"revoked_at":         bson.M{"$exists": false},
+			"expires_at":         bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{
+			"refresh_token_hash":    newHash,
+			"previous_refresh_hash": oldHash,
+			"last_used_at":          now,
+			"expires_at":            now.Add(RefreshTokenDuration),
+		}},
+	)
+
+	if err != nil {
+		return false, fmt.Errorf("erreur lors du renouvellement de la session: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// IsActive indique si une session existe, n'est pas révoquée et n'a pas expiré
+func (r *SessionRepository) IsActive(sessionID string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la vérification de la session: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// FindActiveByUser retourne les sessions actives d'un utilisateur
+func (r *SessionRepository) FindActiveByUser(userEmail string) ([]models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"user_email": userEmail,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Revoke révoque une session
+func (r *SessionRepository) Revoke(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+
+	if err != nil {
+		return fmt.Errorf("erreur lors de la révocation de la session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllForUser révoque toutes les sessions d'un utilisateur
+func (r *SessionRepository) RevokeAllForUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(
+		ctx,
+		bson.M{"user_email": userEmail, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la révocation des sessions: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// DeleteAllForUser supprime toutes les sessions d'un utilisateur (compte supprimé)
+func (r *SessionRepository) DeleteAllForUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des sessions: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
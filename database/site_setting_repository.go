@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // SiteSettingRepository gère les opérations sur les paramètres du site
@@ -81,3 +82,33 @@ func (r *SiteSettingRepository) GetAllSettings(ctx context.Context) ([]models.Si
 	
 	return settings, nil
 }
+
+// GetSetting récupère la valeur d'un paramètre, ou defaultValue s'il n'est pas défini
+func (r *SiteSettingRepository) GetSetting(ctx context.Context, key string, defaultValue string) (string, error) {
+	var setting models.SiteSetting
+
+	err := r.collection.FindOne(ctx, bson.M{"key": key}).Decode(&setting)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return defaultValue, nil
+		}
+		return "", err
+	}
+
+	return setting.Value, nil
+}
+
+// SetSetting définit la valeur d'un paramètre (création si nécessaire)
+func (r *SiteSettingRepository) SetSetting(ctx context.Context, key string, value string, updatedBy *primitive.ObjectID) error {
+	filter := bson.M{"key": key}
+	update := bson.M{
+		"$set": bson.M{
+			"value":      value,
+			"updated_at": time.Now(),
+			"updated_by": updatedBy,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
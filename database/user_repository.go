@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"premier-an-backend/models"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // UserRepository gère les opérations sur les utilisateurs
@@ -190,3 +192,34 @@ func (r *UserRepository) UpdateByEmail(email string, updateData map[string]inter
 
 	return nil
 }
+
+// ClaimDueForDeletion verrouille le prochain compte dont la suppression programmée est arrivée à échéance
+// (ou dont le verrou a expiré après un arrêt du serveur), pour qu'une seule instance le supprime.
+// Retourne nil s'il n'y a rien à supprimer.
+func (r *UserRepository) ClaimDueForDeletion(lease time.Duration) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"deletion_scheduled_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"deletion_locked_until": bson.M{"$exists": false}},
+			bson.M{"deletion_locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"deletion_locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "deletion_scheduled_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("erreur lors de la réservation d'un compte à supprimer: %w", err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestClaimDueForDeletion vérifie qu'un compte à supprimer n'est réservé que par une seule instance
+func TestClaimDueForDeletion(t *testing.T) {
+	db := testDatabase(t)
+	repo := NewUserRepository(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	_, err := db.Collection("users").InsertMany(ctx, []interface{}{
+		bson.M{"email": "due@example.com", "deletion_scheduled_at": now.Add(-time.Hour)},
+		bson.M{"email": "later@example.com", "deletion_scheduled_at": now.Add(time.Hour)},
+		bson.M{"email": "kept@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	user, err := repo.ClaimDueForDeletion(time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDueForDeletion: %v", err)
+	}
+	if user == nil || user.Email != "due@example.com" {
+		t.Fatalf("compte réservé = %+v, attendu due@example.com", user)
+	}
+
+	// Déjà réservé : une autre instance ne le reprend pas tant que le verrou est valide
+	again, err := repo.ClaimDueForDeletion(time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDueForDeletion: %v", err)
+	}
+	if again != nil {
+		t.Fatalf("compte %s réservé deux fois", again.Email)
+	}
+
+	// Verrou expiré (arrêt du serveur pendant la suppression) : le compte est repris
+	if _, err := db.Collection("users").UpdateOne(ctx,
+		bson.M{"email": "due@example.com"},
+		bson.M{"$set": bson.M{"deletion_locked_until": now.Add(-time.Second)}},
+	); err != nil {
+		t.Fatalf("UpdateOne: %v", err)
+	}
+	retried, err := repo.ClaimDueForDeletion(time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimDueForDeletion: %v", err)
+	}
+	if retried == nil || retried.Email != "due@example.com" {
+		t.Fatalf("compte repris = %+v, attendu due@example.com", retried)
+	}
+}
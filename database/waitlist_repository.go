@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"premier-an-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Statuts d'une entrée de liste d'attente
+const (
+	WaitlistStatutEnAttente = "en_attente"
+	WaitlistStatutPromu     = "promu"
+)
+
+// WaitlistRepository gère les opérations sur les listes d'attente
+type WaitlistRepository struct {
+	collection *mongo.Collection
+}
+
+// NewWaitlistRepository crée une nouvelle instance de WaitlistRepository
+func NewWaitlistRepository(db *mongo.Database) *WaitlistRepository {
+	return &WaitlistRepository{
+		collection: db.Collection("waitlist_entries"),
+	}
+}
+
+// Create ajoute une entrée en liste d'attente
+func (r *WaitlistRepository) Create(entry *models.WaitlistEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry.ID = primitive.NewObjectID()
+	entry.Statut = WaitlistStatutEnAttente
+	entry.CreatedAt = time.Now()
+
+	if entry.Accompagnants == nil {
+		entry.Accompagnants = []models.Accompagnant{}
+	}
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'ajout en liste d'attente: %w", err)
+	}
+
+	return nil
+}
+
+// FindPendingByEventAndUser recherche l'entrée en attente d'un utilisateur pour un événement
+func (r *WaitlistRepository) FindPendingByEventAndUser(eventID primitive.ObjectID, userEmail string) (*models.WaitlistEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var entry models.WaitlistEntry
+	err := r.collection.FindOne(ctx, bson.M{
+		"event_id":   eventID,
+		"user_email": userEmail,
+		"statut":     WaitlistStatutEnAttente,
+	}).Decode(&entry)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche de l'entrée en liste d'attente: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// FindPendingByEvent retourne les entrées en attente d'un événement, dans l'ordre d'arrivée
+func (r *WaitlistRepository) FindPendingByEvent(eventID primitive.ObjectID) ([]models.WaitlistEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"event_id": eventID,
+		"statut":   WaitlistStatutEnAttente,
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche de la liste d'attente: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.WaitlistEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage de la liste d'attente: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetPosition retourne la position (1-based) d'une entrée dans la liste d'attente
+func (r *WaitlistRepository) GetPosition(entry *models.WaitlistEntry) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"event_id": entry.EventID,
+		"statut":   WaitlistStatutEnAttente,
+		"$or": []bson.M{
+			{"created_at": bson.M{"$lt": entry.CreatedAt}},
+			{"created_at": entry.CreatedAt, "_id": bson.M{"$lt": entry.ID}},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors du calcul de la position: %w", err)
+	}
+
+	return int(count) + 1, nil
+}
+
+// MarkPromoted marque une entrée comme promue (uniquement si elle est encore en attente)
+func (r *WaitlistRepository) MarkPromoted(id primitive.ObjectID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "statut": WaitlistStatutEnAttente},
+		bson.M{"$set": bson.M{"statut": WaitlistStatutPromu, "promoted_at": now}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("erreur lors de la promotion de l'entrée: %w", err)
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// DeleteByID supprime une entrée de liste d'attente
+func (r *WaitlistRepository) DeleteByID(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la suppression de l'entrée: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("entrée de liste d'attente non trouvée")
+	}
+
+	return nil
+}
+
+// DeleteByUser supprime toutes les demandes de liste d'attente d'un utilisateur
+func (r *WaitlistRepository) DeleteByUser(userEmail string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression des demandes: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DeleteByEvent supprime toute la liste d'attente d'un événement
+func (r *WaitlistRepository) DeleteByEvent(eventID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"event_id": eventID})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la suppression de la liste d'attente: %w", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// FindByUser récupère toutes les demandes de liste d'attente d'un utilisateur
+func (r *WaitlistRepository) FindByUser(userEmail string) ([]models.WaitlistEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_email": userEmail})
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des demandes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.WaitlistEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des demandes: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"premier-an-backend/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WSEventLogRepository conserve les derniers événements WebSocket de chaque utilisateur
+// (collection "ws_event_log") et leur numéro de séquence (collection "ws_sequences")
+type WSEventLogRepository struct {
+	collection         *mongo.Collection
+	sequenceCollection *mongo.Collection
+}
+
+// NewWSEventLogRepository crée une nouvelle instance
+func NewWSEventLogRepository(db *mongo.Database) *WSEventLogRepository {
+	return &WSEventLogRepository{
+		collection:         db.Collection("ws_event_log"),
+		sequenceCollection: db.Collection("ws_sequences"),
+	}
+}
+
+// Append attribue le numéro de séquence suivant à un événement et l'enregistre.
+// Seuls les models.WSEventLogSize derniers événements de l'utilisateur sont conservés.
+func (r *WSEventLogRepository) Append(userID string, payload []byte) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.sequenceCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de l'attribution du numéro de séquence: %w", err)
+	}
+
+	_, err = r.collection.InsertOne(ctx, models.WSEvent{
+		UserID:    userID,
+		Seq:       counter.Seq,
+		Payload:   string(payload),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de l'enregistrement de l'événement: %w", err)
+	}
+
+	// Purge des plus anciens, par lots pour ne pas ajouter une requête à chaque envoi
+	if counter.Seq%50 == 0 {
+		r.collection.DeleteMany(ctx, bson.M{
+			"user_id": userID,
+			"seq":     bson.M{"$lte": counter.Seq - models.WSEventLogSize},
+		})
+	}
+
+	return counter.Seq, nil
+}
+
+// CurrentSeq retourne le dernier numéro de séquence attribué à un utilisateur (0 si aucun)
+func (r *WSEventLogRepository) CurrentSeq(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.sequenceCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&counter)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors de la lecture du numéro de séquence: %w", err)
+	}
+
+	return counter.Seq, nil
+}
+
+// FindAfter retourne les événements d'un utilisateur postérieurs à lastSeq, dans l'ordre
+func (r *WSEventLogRepository) FindAfter(userID string, lastSeq int64, limit int64) ([]models.WSEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{
+		"user_id": userID,
+		"seq":     bson.M{"$gt": lastSeq},
+	}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la recherche des événements: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []models.WSEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("erreur lors du décodage des événements: %w", err)
+	}
+
+	return events, nil
+}
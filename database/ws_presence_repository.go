@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WSPresenceTTL durée de validité du compteur d'une instance sans battement de cœur
+// (instance arrêtée brutalement : ses connexions ne comptent plus au-delà)
+const WSPresenceTTL = 3 * time.Minute
+
+// WSPresenceRepository compte les connexions WebSocket de chaque utilisateur par instance du serveur
+// (collection "ws_presence", un document par instance et par utilisateur)
+type WSPresenceRepository struct {
+	collection *mongo.Collection
+}
+
+// NewWSPresenceRepository crée une nouvelle instance
+func NewWSPresenceRepository(db *mongo.Database) *WSPresenceRepository {
+	return &WSPresenceRepository{
+		collection: db.Collection("ws_presence"),
+	}
+}
+
+// presenceID identifiant du compteur d'un utilisateur sur une instance
+func presenceID(nodeID, userID string) string {
+	return nodeID + ":" + userID
+}
+
+// Connect compte une nouvelle connexion d'un utilisateur sur une instance
+func (r *WSPresenceRepository) Connect(nodeID, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": presenceID(nodeID, userID)},
+		bson.M{
+			"$inc": bson.M{"connections": 1},
+			"$set": bson.M{"node_id": nodeID, "user_id": userID, "updated_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'enregistrement de la connexion: %w", err)
+	}
+	return nil
+}
+
+// Disconnect décompte une connexion d'un utilisateur sur une instance.
+// Retourne le nombre de connexions qui lui restent sur l'ensemble des instances.
+func (r *WSPresenceRepository) Disconnect(nodeID, userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id := presenceID(nodeID, userID)
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"connections": -1},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors du retrait de la connexion: %w", err)
+	}
+
+	// Dernière connexion sur cette instance : le compteur est supprimé
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "connections": bson.M{"$lte": 0}}); err != nil {
+		return 0, fmt.Errorf("erreur lors du retrait de la connexion: %w", err)
+	}
+
+	return r.Count(userID)
+}
+
+// Count retourne le nombre de connexions d'un utilisateur sur l'ensemble des instances actives
+func (r *WSPresenceRepository) Count(userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{
+			"user_id":     userID,
+			"connections": bson.M{"$gt": 0},
+			"updated_at":  bson.M{"$gte": time.Now().Add(-WSPresenceTTL)},
+		}},
+		{"$group": bson.M{"_id": nil, "connections": bson.M{"$sum": "$connections"}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("erreur lors du comptage des connexions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Connections int64 `bson:"connections"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("erreur lors du décodage du comptage des connexions: %w", err)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	return results[0].Connections, nil
+}
+
+// Touch prolonge les compteurs d'une instance (battement de cœur)
+func (r *WSPresenceRepository) Touch(nodeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateMany(ctx, bson.M{"node_id": nodeID}, bson.M{"$set": bson.M{"updated_at": time.Now()}})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la prolongation des connexions: %w", err)
+	}
+	return nil
+}
+
+// DeleteNode supprime les compteurs d'une instance (arrêt du serveur)
+func (r *WSPresenceRepository) DeleteNode(nodeID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"node_id": nodeID})
+	if err != nil {
+		return fmt.Errorf("erreur lors de la suppression des connexions de l'instance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"premier-an-backend/database"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// auditSensitiveFields ne sont jamais copiés dans le journal d'audit
+var auditSensitiveFields = []string{"password"}
+
+// recordAudit enregistre une action d'administration.
+// before et after sont des instantanés de la cible (nil pour une création ou une suppression).
+// Une erreur d'écriture est journalisée sans faire échouer la requête.
+func recordAudit(repo *database.AuditLogRepository, r *http.Request, action, targetType, targetID string, before, after interface{}) {
+	entry := &models.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		IP:         clientIP(r),
+		UserAgent:  r.UserAgent(),
+	}
+	if claims := middleware.GetUserFromContext(r.Context()); claims != nil {
+		entry.ActorEmail = claims.Email
+	}
+	if entry.Before != nil && entry.After != nil {
+		entry.Changes = auditDiff(entry.Before, entry.After)
+	}
+
+	if err := repo.Create(entry); err != nil {
+		log.Printf("❌ Erreur audit %s sur %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+// auditSnapshot convertit une valeur en document (noms de champs MongoDB) sans les champs sensibles
+func auditSnapshot(value interface{}) map[string]interface{} {
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil() {
+		return nil
+	}
+
+	data, err := bson.Marshal(value)
+	if err != nil {
+		log.Printf("Erreur instantané audit: %v", err)
+		return nil
+	}
+
+	var snapshot bson.M
+	if err := bson.Unmarshal(data, &snapshot); err != nil {
+		log.Printf("Erreur instantané audit: %v", err)
+		return nil
+	}
+
+	for _, field := range auditSensitiveFields {
+		delete(snapshot, field)
+	}
+
+	return snapshot
+}
+
+// auditDiff retourne les champs dont la valeur diffère entre les deux instantanés
+func auditDiff(before, after map[string]interface{}) map[string]models.AuditChange {
+	changes := map[string]models.AuditChange{}
+	for key, oldValue := range before {
+		if newValue, ok := after[key]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = models.AuditChange{Before: oldValue, After: after[key]}
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			changes[key] = models.AuditChange{Before: nil, After: newValue}
+		}
+	}
+	return changes
+}
+
+// GetAuditLog retourne le journal d'audit, paginé et filtrable
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	query := r.URL.Query()
+
+	// Paramètres de pagination
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		if parsedPage, err := strconv.Atoi(pageStr); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 200 {
+			limit = parsedLimit
+		}
+	}
+
+	filter := models.AuditLogFilter{
+		ActorEmail: query.Get("actor"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Paramètre from invalide (format RFC3339)")
+			return
+		}
+		filter.From = &from
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Paramètre to invalide (format RFC3339)")
+			return
+		}
+		filter.To = &to
+	}
+
+	entries, total, err := h.auditRepo.Find(filter, page, limit)
+	if err != nil {
+		log.Printf("Erreur lors de la lecture du journal d'audit: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if entries == nil {
+		entries = []models.AuditLog{}
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parseEventIDs convertit une liste d'IDs hexadécimaux en ObjectIDs
+func parseEventIDs(ids []string) ([]primitive.ObjectID, bool) {
+	eventIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		eventID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, false
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	return eventIDs, true
+}
+
+// CreateCodeSoiree crée un code d'invitation avec quota et expiration
+func (h *AdminHandler) CreateCodeSoiree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	var req models.CreateCodeSoireeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	if req.MaxUtilisations < 0 {
+		utils.RespondError(w, http.StatusBadRequest, "max_utilisations doit être positif (0 = illimité)")
+		return
+	}
+
+	eventIDs, ok := parseEventIDs(req.EventIDs)
+	if !ok {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		code = generateRandomCode(10)
+	}
+
+	codeSoiree := &models.CodeSoiree{
+		Code:            code,
+		Label:           req.Label,
+		EventIDs:        eventIDs,
+		MaxUtilisations: req.MaxUtilisations,
+		Active:          true,
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.Time.IsZero() {
+		t := req.ExpiresAt.Time
+		codeSoiree.ExpiresAt = &t
+	}
+	if claims := middleware.GetUserFromContext(r.Context()); claims != nil {
+		codeSoiree.CreatedBy = claims.Email
+	}
+
+	if err := h.codeSoireeRepo.Create(codeSoiree); err != nil {
+		log.Printf("Erreur lors de la création du code soirée: %v", err)
+		if strings.Contains(err.Error(), "existe déjà") {
+			utils.RespondError(w, http.StatusConflict, "Ce code existe déjà")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	recordAudit(h.auditRepo, r, models.AuditCodeSoireeCreate, "code_soiree", codeSoiree.ID.Hex(), nil, codeSoiree)
+
+	log.Printf("✓ Code soirée créé: %s (max: %d)", codeSoiree.Code, codeSoiree.MaxUtilisations)
+
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "Code de soirée créé",
+		"code":    codeSoiree,
+	})
+}
+
+// GetCodeSoiree retourne un code avec son historique d'utilisation
+func (h *AdminHandler) GetCodeSoiree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	codeID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID code invalide")
+		return
+	}
+
+	codeSoiree, err := h.codeSoireeRepo.FindByID(codeID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération du code soirée: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if codeSoiree == nil {
+		utils.RespondError(w, http.StatusNotFound, "Code non trouvé")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"code":    codeSoiree,
+	})
+}
+
+// UpdateCodeSoiree modifie le quota, l'expiration, les événements ou l'activation d'un code
+func (h *AdminHandler) UpdateCodeSoiree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	codeID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID code invalide")
+		return
+	}
+
+	var req models.UpdateCodeSoireeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	existing, err := h.codeSoireeRepo.FindByID(codeID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération du code soirée: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if existing == nil {
+		utils.RespondError(w, http.StatusNotFound, "Code non trouvé")
+		return
+	}
+
+	update := bson.M{}
+	if req.Label != nil {
+		update["label"] = *req.Label
+	}
+	if req.EventIDs != nil {
+		eventIDs, ok := parseEventIDs(req.EventIDs)
+		if !ok {
+			utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+			return
+		}
+		update["event_ids"] = eventIDs
+	}
+	if req.MaxUtilisations != nil {
+		if *req.MaxUtilisations < 0 {
+			utils.RespondError(w, http.StatusBadRequest, "max_utilisations doit être positif (0 = illimité)")
+			return
+		}
+		update["max_utilisations"] = *req.MaxUtilisations
+	}
+	if req.ExpiresAt != nil {
+		// Une date vide supprime l'expiration
+		if req.ExpiresAt.Time.IsZero() {
+			update["expires_at"] = nil
+		} else {
+			update["expires_at"] = req.ExpiresAt.Time
+		}
+	}
+	if req.Active != nil {
+		update["active"] = *req.Active
+	}
+
+	if len(update) == 0 {
+		utils.RespondError(w, http.StatusBadRequest, "Aucune donnée à mettre à jour")
+		return
+	}
+
+	if err := h.codeSoireeRepo.Update(codeID, update); err != nil {
+		log.Printf("Erreur lors de la mise à jour du code soirée: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	updatedCode, err := h.codeSoireeRepo.FindByID(codeID)
+	if err != nil || updatedCode == nil {
+		log.Printf("Erreur lors de la récupération du code soirée: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	recordAudit(h.auditRepo, r, models.AuditCodeSoireeUpdate, "code_soiree", codeID.Hex(), existing, updatedCode)
+
+	log.Printf("✓ Code soirée modifié: %s", updatedCode.Code)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Code de soirée modifié",
+		"code":    updatedCode,
+	})
+}
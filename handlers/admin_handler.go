@@ -6,7 +6,9 @@ import (
 	"log"
 	"net/http"
 	"premier-an-backend/database"
+	"premier-an-backend/middleware"
 	"premier-an-backend/models"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
 	"strings"
 	"time"
@@ -26,15 +28,22 @@ type AdminHandler struct {
 	codeSoireeRepo  *database.CodeSoireeRepository
 	fcmService      interface {
 		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	}
-	fcmTokenRepo *database.FCMTokenRepository
-	wsHub        WebSocketHub
+		FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string
+	}
+	fcmTokenRepo    *database.FCMTokenRepository
+	wsHub           WebSocketHub
+	waitlistService *services.WaitlistService
+	sessionRepo     *database.SessionRepository
+	siteSettingRepo *database.SiteSettingRepository
+	auditRepo       *database.AuditLogRepository
+	deletionService *services.DeletionService
 }
 
 // NewAdminHandler crée une nouvelle instance de AdminHandler
 func NewAdminHandler(db *mongo.Database, fcmService interface {
 	SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-}, wsHub WebSocketHub) *AdminHandler {
+	FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string
+}, outbox *services.NotificationOutbox, wsHub WebSocketHub, deletionService *services.DeletionService) *AdminHandler {
 	return &AdminHandler{
 		userRepo:        database.NewUserRepository(db),
 		eventRepo:       database.NewEventRepository(db),
@@ -44,6 +53,11 @@ func NewAdminHandler(db *mongo.Database, fcmService interface {
 		fcmService:      fcmService,
 		fcmTokenRepo:    database.NewFCMTokenRepository(db),
 		wsHub:           wsHub,
+		waitlistService: services.NewWaitlistService(db, outbox),
+		sessionRepo:     database.NewSessionRepository(db),
+		siteSettingRepo: database.NewSiteSettingRepository(db),
+		auditRepo:       database.NewAuditLogRepository(db),
+		deletionService: deletionService,
 	}
 }
 
@@ -91,17 +105,20 @@ func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Récupérer l'utilisateur AVANT la mise à jour pour comparer le statut admin
-	var oldAdminStatus int
-	var adminStatusChanged bool
-	if req.Admin != nil {
-		oldUser, err := h.userRepo.FindByID(userID)
-		if err == nil && oldUser != nil {
-			oldAdminStatus = oldUser.Admin
-			adminStatusChanged = (oldAdminStatus != *req.Admin)
-		}
+	// Récupérer l'utilisateur AVANT la mise à jour (statut admin, journal d'audit)
+	oldUser, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		log.Printf("Erreur lors de la recherche de l'utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if oldUser == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur non trouvé")
+		return
 	}
 
+	adminStatusChanged := req.Admin != nil && oldUser.Admin != *req.Admin
+
 	// Construire l'update
 	update := bson.M{}
 	if req.Firstname != "" {
@@ -140,13 +157,23 @@ func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditUserUpdate, "user", userID.Hex(), oldUser, updatedUser)
+
+	// Les droits ont changé : les sessions ouvertes doivent se reconnecter
+	if adminStatusChanged {
+		if _, err := h.sessionRepo.RevokeAllForUser(updatedUser.Email); err != nil {
+			log.Printf("Erreur révocation sessions: %v", err)
+		}
+	}
+
 	// 🔌 Envoyer l'événement WebSocket si les droits admin ont changé
 	if adminStatusChanged && h.wsHub != nil && req.Admin != nil {
-		payload := map[string]interface{}{
-			"type":       "admin_rights_changed",
-			"user_id":    userID.Hex(),
-			"user_email": updatedUser.Email,
-			"admin":      *req.Admin,
+		payload := models.WSAdminRightsChanged{
+			Type:      models.WSTypeAdminRightsChanged,
+			UserID:    userID.Hex(),
+			UserEmail: updatedUser.Email,
+			Admin:     *req.Admin,
+			Roles:     updatedUser.AllRoles(),
 		}
 		// ⚠️ IMPORTANT : Utiliser l'EMAIL de l'utilisateur, pas l'ObjectID
 		// Le WebSocket identifie les utilisateurs par leur email
@@ -160,7 +187,7 @@ func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DeleteUser supprime un utilisateur
+// DeleteUser supprime un utilisateur avec ses données (inscriptions, médias, tokens, chats...)
 func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
@@ -175,17 +202,33 @@ func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Supprimer l'utilisateur
-	if err := h.userRepo.Delete(userID); err != nil {
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		log.Printf("Erreur lors de la recherche de l'utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if user == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur non trouvé")
+		return
+	}
+
+	// Suppression en cascade (les sessions sont supprimées : ses tokens ne sont plus acceptés)
+	report, err := h.deletionService.DeleteUser(user)
+	if err != nil {
 		log.Printf("Erreur lors de la suppression de l'utilisateur: %v", err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditUserDelete, "user", userID.Hex(), user, nil)
+
 	log.Printf("✓ Utilisateur supprimé: ID %s", userID.Hex())
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Utilisateur supprimé",
+		"rapport": report,
 	})
 }
 
@@ -232,6 +275,22 @@ func (h *AdminHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Un organisateur limité à certains événements ne voit que ceux-ci
+	if eventIDs, all := middleware.GetCurrentUser(r.Context()).EventScope(models.PermissionEventsRead); !all {
+		allowed := make(map[primitive.ObjectID]bool, len(eventIDs))
+		for _, id := range eventIDs {
+			allowed[id] = true
+		}
+
+		scoped := []models.Event{}
+		for _, event := range events {
+			if allowed[event.ID] {
+				scoped = append(scoped, event)
+			}
+		}
+		events = scoped
+	}
+
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"evenements": events,
 	})
@@ -283,6 +342,13 @@ func (h *AdminHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Rattacher le code de soirée à l'événement (créé s'il n'existe pas)
+	if err := h.codeSoireeRepo.EnsureForEvent(event.CodeSoiree, event.ID); err != nil {
+		log.Printf("Erreur lors du rattachement du code soirée: %v", err)
+	}
+
+	recordAudit(h.auditRepo, r, models.AuditEventCreate, "event", event.ID.Hex(), nil, event)
+
 	log.Printf("✓ Événement créé: %s (ID: %s)", event.Titre, event.ID.Hex())
 	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
 		"success":   true,
@@ -348,6 +414,18 @@ func (h *AdminHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Instantané avant modification pour le journal d'audit
+	oldEvent, err := h.eventRepo.FindByID(eventID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération de l'événement: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if oldEvent == nil {
+		utils.RespondError(w, http.StatusNotFound, "Événement non trouvé")
+		return
+	}
+
 	// Mettre à jour
 	if err := h.eventRepo.Update(eventID, update); err != nil {
 		log.Printf("Erreur lors de la mise à jour de l'événement: %v", err)
@@ -363,7 +441,21 @@ func (h *AdminHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditEventUpdate, "event", eventID.Hex(), oldEvent, updatedEvent)
+
 	log.Printf("✓ Événement modifié: %s (ID: %s)", updatedEvent.Titre, eventID.Hex())
+
+	if req.CodeSoiree != "" {
+		if err := h.codeSoireeRepo.EnsureForEvent(req.CodeSoiree, eventID); err != nil {
+			log.Printf("Erreur lors du rattachement du code soirée: %v", err)
+		}
+	}
+
+	// Des places ont pu se libérer : promouvoir la liste d'attente
+	if req.Capacite > 0 {
+		go h.waitlistService.PromoteFromWaitlist(eventID)
+	}
+
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success":   true,
 		"message":   "Événement modifié",
@@ -371,7 +463,7 @@ func (h *AdminHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DeleteEvent supprime un événement
+// DeleteEvent supprime un événement avec ses inscriptions, sa liste d'attente, ses médias et son trailer
 func (h *AdminHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
@@ -386,17 +478,33 @@ func (h *AdminHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Supprimer l'événement
-	if err := h.eventRepo.Delete(eventID); err != nil {
+	event, err := h.eventRepo.FindByID(eventID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération de l'événement: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if event == nil {
+		utils.RespondError(w, http.StatusNotFound, "Événement non trouvé")
+		return
+	}
+
+	// Suppression en cascade
+	report, err := h.deletionService.DeleteEvent(event)
+	if err != nil {
 		log.Printf("Erreur lors de la suppression de l'événement: %v", err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditEventDelete, "event", eventID.Hex(), event, nil)
+
 	log.Printf("✓ Événement supprimé: ID %s", eventID.Hex())
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Événement supprimé",
+		"rapport": report,
 	})
 }
 
@@ -533,7 +641,7 @@ func (h *AdminHandler) SendAdminNotification(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Récupérer les tokens
-	var tokens []string
+	var candidates []models.FCMToken
 
 	if len(req.UserIDs) == 1 && req.UserIDs[0] == "all" {
 		// Envoyer à tous
@@ -543,9 +651,7 @@ func (h *AdminHandler) SendAdminNotification(w http.ResponseWriter, r *http.Requ
 			utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 			return
 		}
-		for _, t := range allTokens {
-			tokens = append(tokens, t.Token)
-		}
+		candidates = allTokens
 	} else {
 		// Envoyer à des utilisateurs spécifiques
 		for _, userID := range req.UserIDs {
@@ -553,17 +659,18 @@ func (h *AdminHandler) SendAdminNotification(w http.ResponseWriter, r *http.Requ
 			if err != nil {
 				continue
 			}
-			for _, t := range userTokens {
-				tokens = append(tokens, t.Token)
-			}
+			candidates = append(candidates, userTokens...)
 		}
 	}
 
-	if len(tokens) == 0 {
+	if len(candidates) == 0 {
 		utils.RespondError(w, http.StatusBadRequest, "Aucun token trouvé pour ces utilisateurs")
 		return
 	}
 
+	// Les utilisateurs en sourdine ou en heures calmes sont ignorés
+	tokens := h.fcmService.FilterTokens(candidates, models.NotificationContext{Category: models.NotificationCategoryGeneral})
+
 	// Envoyer les notifications
 	title := req.Title
 	if title == "" {
@@ -577,6 +684,15 @@ func (h *AdminHandler) SendAdminNotification(w http.ResponseWriter, r *http.Requ
 	success, failed, _ := h.fcmService.SendToAll(tokens, title, message, req.Data)
 
 	log.Printf("📊 Admin notification: %d succès, %d échecs", success, failed)
+
+	recordAudit(h.auditRepo, r, models.AuditNotificationSend, "notification", "", nil, map[string]interface{}{
+		"user_ids": req.UserIDs,
+		"title":    title,
+		"message":  message,
+		"data":     req.Data,
+		"success":  success,
+		"failed":   failed,
+	})
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": fmt.Sprintf("Notification envoyée à %d utilisateurs", success),
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ========== RÔLES ET PERMISSIONS ==========
+
+// GetRoles retourne les rôles disponibles et leurs permissions
+func (h *AdminHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	roles := map[string][]string{
+		models.RoleSuperAdmin: {"*"},
+	}
+	for role, permissions := range models.RolePermissions {
+		roles[role] = permissions
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"roles":   roles,
+	})
+}
+
+// UpdateUserRoles remplace les rôles d'un utilisateur
+func (h *AdminHandler) UpdateUserRoles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	userID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID utilisateur invalide")
+		return
+	}
+
+	var req models.UpdateRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	user, err := h.userRepo.FindByID(userID)
+	if err != nil {
+		log.Printf("Erreur lors de la récupération de l'utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if user == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur non trouvé")
+		return
+	}
+
+	// Le rôle super-admin correspond au champ admin, les autres sont stockés dans roles
+	admin := 0
+	roles := []models.RoleAssignment{}
+	for _, role := range req.Roles {
+		if !models.IsValidRole(role.Role) {
+			utils.RespondError(w, http.StatusBadRequest, "Rôle inconnu: "+role.Role)
+			return
+		}
+		if role.Role == models.RoleSuperAdmin {
+			admin = 1
+			continue
+		}
+
+		eventIDs, ok := parseEventIDs(role.EventIDs)
+		if !ok {
+			utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+			return
+		}
+		roles = append(roles, models.RoleAssignment{Role: role.Role, EventIDs: eventIDs})
+	}
+
+	// Empêcher un super-admin de se retirer ses propres droits
+	if claims := middleware.GetUserFromContext(r.Context()); claims != nil && claims.Email == user.Email && admin == 0 && user.IsSuperAdmin() {
+		utils.RespondError(w, http.StatusBadRequest, "Vous ne pouvez pas retirer votre propre rôle super-admin")
+		return
+	}
+
+	if err := h.userRepo.UpdateFields(userID, bson.M{"admin": admin, "roles": roles}); err != nil {
+		log.Printf("Erreur lors de la mise à jour des rôles: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	updatedUser, err := h.userRepo.FindByID(userID)
+	if err != nil || updatedUser == nil {
+		log.Printf("Erreur lors de la récupération de l'utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	recordAudit(h.auditRepo, r, models.AuditUserRolesUpdate, "user", userID.Hex(),
+		map[string]interface{}{"admin": user.Admin, "roles": user.Roles},
+		map[string]interface{}{"admin": updatedUser.Admin, "roles": updatedUser.Roles})
+
+	// Les droits admin ont changé : les sessions ouvertes doivent se reconnecter
+	if user.Admin != admin {
+		if _, err := h.sessionRepo.RevokeAllForUser(updatedUser.Email); err != nil {
+			log.Printf("Erreur révocation sessions: %v", err)
+		}
+	}
+
+	if h.wsHub != nil {
+		h.wsHub.SendToUser(updatedUser.Email, models.WSAdminRightsChanged{
+			Type:      models.WSTypeAdminRightsChanged,
+			UserID:    userID.Hex(),
+			UserEmail: updatedUser.Email,
+			Admin:     admin,
+			Roles:     updatedUser.AllRoles(),
+		})
+	}
+
+	log.Printf("✓ Rôles mis à jour pour %s: %d rôle(s), admin=%d", updatedUser.Email, len(roles), admin)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"message":     "Rôles mis à jour",
+		"utilisateur": updatedUser,
+		"roles":       updatedUser.AllRoles(),
+	})
+}
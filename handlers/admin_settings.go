@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ========== PARAMÈTRES D'INSCRIPTION ==========
+
+// GetInscriptionSettings retourne les paramètres d'inscription aux événements
+func (h *AdminHandler) GetInscriptionSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	value, err := h.siteSettingRepo.GetSetting(r.Context(), models.SettingAllowUnverifiedInscriptions, "true")
+	if err != nil {
+		log.Printf("Erreur lecture paramètre: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	allowUnverified, _ := strconv.ParseBool(value)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"settings": models.InscriptionSettings{AllowUnverified: allowUnverified},
+	})
+}
+
+// UpdateInscriptionSettings modifie les paramètres d'inscription aux événements
+func (h *AdminHandler) UpdateInscriptionSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	var req models.InscriptionSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	previous, err := h.siteSettingRepo.GetSetting(r.Context(), models.SettingAllowUnverifiedInscriptions, "true")
+	if err != nil {
+		log.Printf("Erreur lecture paramètre: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	previousAllowUnverified, _ := strconv.ParseBool(previous)
+
+	// Identifier l'admin pour l'historique
+	var updatedBy *primitive.ObjectID
+	if claims := middleware.GetUserFromContext(r.Context()); claims != nil {
+		if admin, err := h.userRepo.FindByEmail(claims.Email); err == nil && admin != nil {
+			updatedBy = &admin.ID
+		}
+	}
+
+	if err := h.siteSettingRepo.SetSetting(r.Context(), models.SettingAllowUnverifiedInscriptions, strconv.FormatBool(req.AllowUnverified), updatedBy); err != nil {
+		log.Printf("Erreur mise à jour paramètre: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	recordAudit(h.auditRepo, r, models.AuditSettingsUpdate, "settings", models.SettingAllowUnverifiedInscriptions,
+		map[string]interface{}{"allow_unverified": previousAllowUnverified},
+		map[string]interface{}{"allow_unverified": req.AllowUnverified})
+
+	log.Printf("✓ Paramètre inscriptions: allow_unverified=%t", req.AllowUnverified)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":  true,
+		"message":  "Paramètres mis à jour",
+		"settings": req,
+	})
+}
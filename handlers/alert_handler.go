@@ -20,12 +20,14 @@ type AlertHandler struct {
 	fcmTokenRepo *database.FCMTokenRepository
 	fcmService   interface {
 		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
+		FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string
 	}
 }
 
 // NewAlertHandler crée une nouvelle instance
 func NewAlertHandler(db *mongo.Database, fcmService interface {
 	SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
+	FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string
 }) *AlertHandler {
 	return &AlertHandler{
 		alertRepo:    database.NewAlertRepository(db),
@@ -131,11 +133,8 @@ func (h *AlertHandler) SendCriticalAlert(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Extraire les tokens
-	var tokens []string
-	for _, t := range fcmTokens {
-		tokens = append(tokens, t.Token)
-	}
+	// Extraire les tokens (sauf sourdine ou heures calmes de l'admin)
+	tokens := h.fcmService.FilterTokens(fcmTokens, models.NotificationContext{Category: models.NotificationCategoryGeneral})
 
 	// Construire la notification
 	title := "🚨 Alerte Critique - Site"
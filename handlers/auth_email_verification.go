@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"strings"
+	"time"
+)
+
+// emailVerificationDuration est la durée de validité d'un lien de vérification
+const emailVerificationDuration = 48 * time.Hour
+
+// sendVerificationEmail génère un nouveau lien de vérification et l'envoie à l'utilisateur
+func (h *AuthHandler) sendVerificationEmail(user *models.User) error {
+	// Un seul lien valide à la fois
+	if err := h.emailVerificationRepo.InvalidateAllForUser(user.Email); err != nil {
+		log.Printf("Erreur invalidation tokens: %v", err)
+	}
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return err
+	}
+
+	verificationToken := &models.EmailVerificationToken{
+		UserEmail: user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(emailVerificationDuration),
+	}
+	if err := h.emailVerificationRepo.Create(verificationToken); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", strings.TrimRight(h.frontendURL, "/"), token)
+	body := fmt.Sprintf("Bonjour %s,\n\n"+
+		"Bienvenue ! Confirmez votre adresse e-mail en cliquant sur ce lien (valable 48 heures) :\n\n%s\n\n"+
+		"Si vous n'avez pas créé de compte, ignorez cet e-mail.\n",
+		user.Firstname, link)
+
+	go func() {
+		if err := h.mailer.Send(user.Email, "Confirmez votre adresse e-mail", body); err != nil {
+			log.Printf("❌ Erreur envoi e-mail de vérification à %s: %v", user.Email, err)
+		}
+	}()
+
+	return nil
+}
+
+// VerifyEmail confirme l'adresse e-mail à partir du token reçu par e-mail
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Token requis")
+		return
+	}
+
+	// Consommer le token (usage unique)
+	verificationToken, err := h.emailVerificationRepo.Consume(utils.HashToken(req.Token))
+	if err != nil {
+		log.Printf("Erreur validation token: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if verificationToken == nil {
+		utils.RespondError(w, http.StatusBadRequest, "Lien invalide ou expiré")
+		return
+	}
+
+	now := time.Now()
+	if err := h.userRepo.UpdateByEmail(verificationToken.UserEmail, map[string]interface{}{
+		"email_verified":    true,
+		"email_verified_at": now,
+	}); err != nil {
+		log.Printf("Erreur mise à jour utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	log.Printf("✓ Adresse e-mail vérifiée: %s", verificationToken.UserEmail)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Adresse e-mail vérifiée",
+	})
+}
+
+// ResendVerification renvoie le lien de vérification à l'utilisateur connecté
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(claims.Email)
+	if err != nil || user == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur non trouvé")
+		return
+	}
+
+	if user.EmailVerified {
+		utils.RespondError(w, http.StatusBadRequest, "Adresse e-mail déjà vérifiée")
+		return
+	}
+
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("Erreur envoi vérification: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	log.Printf("✓ Lien de vérification renvoyé à %s", user.Email)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "E-mail de vérification envoyé",
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"premier-an-backend/database"
 	"premier-an-backend/middleware"
 	"premier-an-backend/models"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
 	"strings"
 
@@ -22,21 +23,33 @@ type AuthHandler struct {
 	jwtSecret      string
 	fcmService     interface {
 		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	}
-	fcmTokenRepo *database.FCMTokenRepository
+		FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string
+	}
+	fcmTokenRepo          *database.FCMTokenRepository
+	sessionRepo           *database.SessionRepository
+	passwordResetRepo     *database.PasswordResetRepository
+	emailVerificationRepo *database.EmailVerificationRepository
+	mailer                services.Mailer
+	frontendURL           string
 }
 
 // NewAuthHandler crée une nouvelle instance de AuthHandler
 func NewAuthHandler(db *mongo.Database, jwtSecret string, fcmService interface {
 	SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-}) *AuthHandler {
+	FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string
+}, mailer services.Mailer, frontendURL string) *AuthHandler {
 	return &AuthHandler{
-		userRepo:       database.NewUserRepository(db),
-		eventRepo:      database.NewEventRepository(db),
-		codeSoireeRepo: database.NewCodeSoireeRepository(db),
-		jwtSecret:      jwtSecret,
-		fcmService:     fcmService,
-		fcmTokenRepo:   database.NewFCMTokenRepository(db),
+		userRepo:              database.NewUserRepository(db),
+		eventRepo:             database.NewEventRepository(db),
+		codeSoireeRepo:        database.NewCodeSoireeRepository(db),
+		jwtSecret:             jwtSecret,
+		fcmService:            fcmService,
+		fcmTokenRepo:          database.NewFCMTokenRepository(db),
+		sessionRepo:           database.NewSessionRepository(db),
+		passwordResetRepo:     database.NewPasswordResetRepository(db),
+		emailVerificationRepo: database.NewEmailVerificationRepository(db),
+		mailer:                mailer,
+		frontendURL:           frontendURL,
 	}
 }
 
@@ -57,7 +70,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Logger les données reçues pour débogage
-	log.Printf("📥 Inscription reçue - Code: '%s', Email: '%s', Prénom: '%s', Nom: '%s'", 
+	log.Printf("📥 Inscription reçue - Code: '%s', Email: '%s', Prénom: '%s', Nom: '%s'",
 		req.CodeSoiree, req.Email, req.Firstname, req.Lastname)
 
 	// Valider les données
@@ -66,20 +79,20 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Vérifier que le code soirée existe et est actif
+	// Vérifier que le code soirée existe, est actif, non expiré et sous son quota
 	log.Printf("🔍 Vérification du code soirée: '%s'", req.CodeSoiree)
-	codeValid, err := h.codeSoireeRepo.IsCodeValid(req.CodeSoiree)
+	_, reason, err := h.codeSoireeRepo.Validate(req.CodeSoiree)
 	if err != nil {
 		log.Printf("❌ Erreur lors de la vérification du code soirée '%s': %v", req.CodeSoiree, err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 		return
 	}
-	if !codeValid {
-		log.Printf("❌ Code soirée invalide ou inactif: '%s'", req.CodeSoiree)
-		utils.RespondError(w, http.StatusBadRequest, "Code de soirée invalide ou inactif")
+	if reason != "" {
+		log.Printf("❌ Code soirée refusé '%s': %s", req.CodeSoiree, reason)
+		utils.RespondError(w, http.StatusBadRequest, reason)
 		return
 	}
-	
+
 	log.Printf("✅ Code soirée valide: '%s'", req.CodeSoiree)
 
 	// Vérifier si l'email existe déjà
@@ -102,43 +115,57 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	// Consommer une utilisation du code (quota vérifié de façon atomique)
+	used, err := h.codeSoireeRepo.Use(req.CodeSoiree, email)
+	if err != nil {
+		log.Printf("❌ Erreur lors de l'utilisation du code soirée '%s': %v", req.CodeSoiree, err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if !used {
+		log.Printf("❌ Code soirée épuisé entre-temps: '%s'", req.CodeSoiree)
+		utils.RespondError(w, http.StatusBadRequest, "Ce code de soirée n'est plus disponible")
+		return
+	}
+
 	// Créer l'utilisateur
 	user := &models.User{
-		CodeSoiree: req.CodeSoiree,
-		Firstname:  req.Firstname,
-		Lastname:   req.Lastname,
-		Email:      strings.ToLower(strings.TrimSpace(req.Email)),
-		Phone:      req.Phone,
-		Password:   hashedPassword,
-		Admin:      0, // Par défaut, les nouveaux utilisateurs ne sont pas admin
+		CodeSoiree:    req.CodeSoiree,
+		Firstname:     req.Firstname,
+		Lastname:      req.Lastname,
+		Email:         email,
+		Phone:         req.Phone,
+		Password:      hashedPassword,
+		Admin:         0,     // Par défaut, les nouveaux utilisateurs ne sont pas admin
+		EmailVerified: false, // Confirmée via le lien envoyé par e-mail
 	}
 
 	if err := h.userRepo.Create(user); err != nil {
 		log.Printf("Erreur lors de la création de l'utilisateur: %v", err)
+		// Rendre l'utilisation du code
+		if releaseErr := h.codeSoireeRepo.ReleaseUsage(req.CodeSoiree, email); releaseErr != nil {
+			log.Printf("Erreur lors de la libération du code soirée: %v", releaseErr)
+		}
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la création du compte")
 		return
 	}
 
-	// Incrémenter le compteur d'utilisations du code soirée
-	if err := h.codeSoireeRepo.IncrementUsage(req.CodeSoiree); err != nil {
-		log.Printf("Erreur lors de l'incrémentation du code soirée: %v", err)
-		// Ne pas bloquer l'inscription si l'incrémentation échoue
+	// Envoyer le lien de vérification de l'adresse e-mail
+	if err := h.sendVerificationEmail(user); err != nil {
+		log.Printf("Erreur envoi e-mail de vérification: %v", err)
+		// Ne pas bloquer l'inscription : l'utilisateur peut redemander un lien
 	}
 
-	// Générer le token JWT (utiliser l'email comme UserID pour cohérence)
-	token, err := utils.GenerateToken(user.Email, user.Email, h.jwtSecret)
+	// Ouvrir une session et générer les tokens (l'email sert d'UserID pour cohérence)
+	response, err := h.issueSession(r, user)
 	if err != nil {
 		log.Printf("Erreur lors de la génération du token: %v", err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 		return
 	}
 
-	// Répondre avec le token et les informations de l'utilisateur
-	response := models.AuthResponse{
-		Token: token,
-		User:  *user,
-	}
-
 	log.Printf("✓ Nouvel utilisateur inscrit: %s (ID: %s)", user.Email, user.ID.Hex())
 
 	utils.RespondJSON(w, http.StatusCreated, response)
@@ -162,17 +189,16 @@ func (h *AuthHandler) notifyAdminsNewUser(user *models.User) {
 		return
 	}
 
-	// Récupérer les tokens FCM des admins
-	var adminTokens []string
+	// Récupérer les tokens FCM des admins qui acceptent les notifications d'inscription
+	var candidates []models.FCMToken
 	for _, admin := range admins {
 		tokens, err := h.fcmTokenRepo.FindByUserID(admin.Email)
 		if err != nil {
 			continue
 		}
-		for _, t := range tokens {
-			adminTokens = append(adminTokens, t.Token)
-		}
+		candidates = append(candidates, tokens...)
 	}
+	adminTokens := h.fcmService.FilterTokens(candidates, models.NotificationContext{Category: models.NotificationCategoryNewInscription})
 
 	if len(adminTokens) == 0 {
 		log.Println("⚠️  Aucun token FCM pour les admins")
@@ -236,20 +262,14 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Générer le token JWT
-	token, err := utils.GenerateToken(user.Email, user.Email, h.jwtSecret)
+	// Ouvrir une session et générer les tokens
+	response, err := h.issueSession(r, user)
 	if err != nil {
 		log.Printf("Erreur lors de la génération du token: %v", err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 		return
 	}
 
-	// Répondre avec le token et les informations de l'utilisateur
-	response := models.AuthResponse{
-		Token: token,
-		User:  *user,
-	}
-
 	log.Printf("✓ Utilisateur connecté: %s (ID: %s)", user.Email, user.ID.Hex())
 	utils.RespondJSON(w, http.StatusOK, response)
 }
@@ -371,7 +391,7 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 
 	// Gestion du changement de mot de passe
 	hasPasswordFields := req.CurrentPassword != "" || req.NewPassword != "" || req.ConfirmPassword != ""
-	
+
 	if hasPasswordFields {
 		// Validation : tous les champs de mot de passe requis
 		if req.CurrentPassword == "" || req.NewPassword == "" || req.ConfirmPassword == "" {
@@ -424,6 +444,13 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Un mot de passe changé rend caducs les liens de réinitialisation en cours
+	if hasPasswordFields {
+		if err := h.passwordResetRepo.InvalidateAllForUser(userEmail); err != nil {
+			log.Printf("Erreur invalidation tokens: %v", err)
+		}
+	}
+
 	log.Printf("✅ Profil mis à jour: %s", updatedUser.Email)
 
 	// Réponse
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"strings"
+	"time"
+)
+
+// passwordResetDuration est la durée de validité d'un lien de réinitialisation
+const passwordResetDuration = time.Hour
+
+// ForgotPassword envoie un lien de réinitialisation de mot de passe par e-mail
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	if err := utils.ValidateEmail(req.Email); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Même réponse que le compte existe ou non (pas d'énumération des e-mails)
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Si un compte existe pour cet e-mail, un lien de réinitialisation a été envoyé",
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	user, err := h.userRepo.FindByEmail(email)
+	if err != nil {
+		log.Printf("Erreur lors de la recherche de l'utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if user == nil {
+		utils.RespondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	// Un seul lien valide à la fois
+	if err := h.passwordResetRepo.InvalidateAllForUser(user.Email); err != nil {
+		log.Printf("Erreur invalidation tokens: %v", err)
+	}
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		log.Printf("Erreur génération token: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	resetToken := &models.PasswordResetToken{
+		UserEmail: user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetDuration),
+	}
+	if err := h.passwordResetRepo.Create(resetToken); err != nil {
+		log.Printf("Erreur création token: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(h.frontendURL, "/"), token)
+	body := fmt.Sprintf("Bonjour %s,\n\n"+
+		"Vous avez demandé la réinitialisation de votre mot de passe.\n"+
+		"Cliquez sur ce lien pour en choisir un nouveau (valable 1 heure) :\n\n%s\n\n"+
+		"Si vous n'êtes pas à l'origine de cette demande, ignorez cet e-mail.\n",
+		user.Firstname, link)
+
+	go func() {
+		if err := h.mailer.Send(user.Email, "Réinitialisation de votre mot de passe", body); err != nil {
+			log.Printf("❌ Erreur envoi e-mail de réinitialisation à %s: %v", user.Email, err)
+		}
+	}()
+
+	log.Printf("✓ Lien de réinitialisation demandé pour %s", user.Email)
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// ResetPassword définit un nouveau mot de passe à partir du token reçu par e-mail
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	if req.Token == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Token requis")
+		return
+	}
+
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Consommer le token (usage unique)
+	resetToken, err := h.passwordResetRepo.Consume(utils.HashToken(req.Token))
+	if err != nil {
+		log.Printf("Erreur validation token: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if resetToken == nil {
+		utils.RespondError(w, http.StatusBadRequest, "Lien invalide ou expiré")
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		log.Printf("Erreur lors du hachage du mot de passe: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if err := h.userRepo.UpdateByEmail(resetToken.UserEmail, map[string]interface{}{
+		"password": hashedPassword,
+	}); err != nil {
+		log.Printf("Erreur mise à jour mot de passe: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	// Le mot de passe a changé : invalider les autres liens et déconnecter tous les appareils
+	h.invalidateAfterPasswordChange(resetToken.UserEmail)
+
+	log.Printf("✓ Mot de passe réinitialisé pour %s", resetToken.UserEmail)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Mot de passe réinitialisé, vous pouvez vous reconnecter",
+	})
+}
+
+// invalidateAfterPasswordChange invalide les liens de réinitialisation et les sessions d'un utilisateur
+func (h *AuthHandler) invalidateAfterPasswordChange(userEmail string) {
+	if err := h.passwordResetRepo.InvalidateAllForUser(userEmail); err != nil {
+		log.Printf("Erreur invalidation tokens: %v", err)
+	}
+	if _, err := h.sessionRepo.RevokeAllForUser(userEmail); err != nil {
+		log.Printf("Erreur révocation sessions: %v", err)
+	}
+}
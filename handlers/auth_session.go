@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// issueSession ouvre une nouvelle session et génère le couple token d'accès / refresh token
+func (h *AuthHandler) issueSession(r *http.Request, user *models.User) (*models.AuthResponse, error) {
+	refreshToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserEmail:        user.Email,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        r.UserAgent(),
+		IP:               clientIP(r),
+	}
+	if err := h.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateToken(user.Email, user.Email, session.ID.Hex(), h.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(utils.AccessTokenDuration.Seconds()),
+		User:         *user,
+	}, nil
+}
+
+// Refresh échange un refresh token contre un nouveau couple de tokens (rotation)
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Refresh token requis")
+		return
+	}
+
+	hash := utils.HashToken(req.RefreshToken)
+
+	session, err := h.sessionRepo.FindByRefreshHash(hash)
+	if err != nil {
+		log.Printf("Erreur recherche session: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if session == nil {
+		// Un ancien refresh token réutilisé signale un vol : on coupe la session
+		stolen, err := h.sessionRepo.FindByPreviousRefreshHash(hash)
+		if err == nil && stolen != nil {
+			log.Printf("⚠️  Réutilisation d'un refresh token pour %s, session révoquée", stolen.UserEmail)
+			h.sessionRepo.Revoke(stolen.ID)
+		}
+		utils.RespondError(w, http.StatusUnauthorized, "Session invalide ou expirée")
+		return
+	}
+
+	newRefreshToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		log.Printf("Erreur génération refresh token: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	rotated, err := h.sessionRepo.Rotate(session.ID, hash, utils.HashToken(newRefreshToken))
+	if err != nil {
+		log.Printf("Erreur rotation session: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if !rotated {
+		utils.RespondError(w, http.StatusUnauthorized, "Session invalide ou expirée")
+		return
+	}
+
+	user, err := h.userRepo.FindByEmail(session.UserEmail)
+	if err != nil || user == nil {
+		h.sessionRepo.Revoke(session.ID)
+		utils.RespondError(w, http.StatusUnauthorized, "Utilisateur non trouvé")
+		return
+	}
+
+	token, err := utils.GenerateToken(user.Email, user.Email, session.ID.Hex(), h.jwtSecret)
+	if err != nil {
+		log.Printf("Erreur lors de la génération du token: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, models.AuthResponse{
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int(utils.AccessTokenDuration.Seconds()),
+		User:         *user,
+	})
+}
+
+// Logout révoque la session courante
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Session invalide")
+		return
+	}
+
+	if err := h.sessionRepo.Revoke(sessionID); err != nil {
+		log.Printf("Erreur révocation session: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	log.Printf("✓ Déconnexion: %s", claims.Email)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Déconnecté",
+	})
+}
+
+// LogoutAll révoque toutes les sessions de l'utilisateur (tous les appareils)
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	count, err := h.sessionRepo.RevokeAllForUser(claims.Email)
+	if err != nil {
+		log.Printf("Erreur révocation sessions: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	log.Printf("✓ Déconnexion de tous les appareils: %s (%d sessions)", claims.Email, count)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":            true,
+		"message":            "Déconnecté de tous les appareils",
+		"sessions_revoquees": count,
+	})
+}
+
+// clientIP retourne l'adresse IP du client (derrière le proxy Render si présent)
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
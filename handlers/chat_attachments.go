@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"premier-an-backend/models"
+	"premier-an-backend/services"
+)
+
+// maxMessageFormSize taille maximale d'un envoi multipart (pièces jointes + champs texte)
+const maxMessageFormSize = models.MaxAttachmentsPerMessage*models.MaxFileAttachmentSize + 1<<20
+
+// messageForm message envoyé en multipart/form-data (champs "content", "reply_to" et fichiers "files")
+type messageForm struct {
+	Content string
+	ReplyTo string
+	files   []attachmentFile
+}
+
+// attachmentFile fichier reçu, avec son type MIME détecté à partir du contenu
+type attachmentFile struct {
+	header   *multipart.FileHeader
+	mimeType string
+}
+
+// formError erreur de validation d'un envoi multipart
+type formError struct {
+	status  int
+	message string
+}
+
+// hasFiles indique si le formulaire contient des pièces jointes
+func (f *messageForm) hasFiles() bool {
+	return f != nil && len(f.files) > 0
+}
+
+// isMultipartRequest indique si la requête est envoyée en multipart/form-data
+func isMultipartRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parseMessageForm lit un message multipart et valide ses pièces jointes (nombre, type MIME, taille)
+func parseMessageForm(w http.ResponseWriter, r *http.Request) (*messageForm, *formError) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxMessageFormSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, &formError{http.StatusRequestEntityTooLarge, "Pièces jointes trop volumineuses"}
+		}
+		return nil, &formError{http.StatusBadRequest, "Formulaire invalide"}
+	}
+
+	form := &messageForm{
+		Content: r.FormValue("content"),
+		ReplyTo: r.FormValue("reply_to"),
+	}
+
+	var headers []*multipart.FileHeader
+	headers = append(headers, r.MultipartForm.File["files"]...)
+	headers = append(headers, r.MultipartForm.File["file"]...)
+	if len(headers) > models.MaxAttachmentsPerMessage {
+		return nil, &formError{http.StatusBadRequest, fmt.Sprintf("%d pièces jointes maximum par message", models.MaxAttachmentsPerMessage)}
+	}
+
+	for _, header := range headers {
+		mimeType, err := detectMimeType(header)
+		if err != nil {
+			log.Printf("Erreur lecture pièce jointe %s: %v", header.Filename, err)
+			return nil, &formError{http.StatusBadRequest, "Pièce jointe illisible"}
+		}
+		if !models.AttachmentMimeTypes[mimeType] {
+			return nil, &formError{http.StatusUnsupportedMediaType, fmt.Sprintf("Type de fichier non autorisé : %s", mimeType)}
+		}
+		if maxSize := models.MaxAttachmentSize(mimeType); header.Size > maxSize {
+			return nil, &formError{http.StatusRequestEntityTooLarge, fmt.Sprintf("%s dépasse la taille maximale (%d MB)", header.Filename, maxSize>>20)}
+		}
+		form.files = append(form.files, attachmentFile{header: header, mimeType: mimeType})
+	}
+
+	return form, nil
+}
+
+// detectMimeType détecte le type MIME d'un fichier à partir de ses premiers octets (l'extension et l'en-tête du client sont ignorés)
+func detectMimeType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buffer := make([]byte, 512)
+	n, err := file.Read(buffer)
+	if err != nil && n == 0 {
+		return "", err
+	}
+
+	mimeType, _, _ := strings.Cut(http.DetectContentType(buffer[:n]), ";")
+	return mimeType, nil
+}
+
+// uploadAttachments enregistre les fichiers dans le stockage. En cas d'échec, les fichiers déjà envoyés sont supprimés.
+func uploadAttachments(storage services.FileStorage, files []attachmentFile, folder string) ([]models.Attachment, error) {
+	if storage == nil {
+		return nil, errors.New("stockage des pièces jointes non configuré")
+	}
+
+	attachments := make([]models.Attachment, 0, len(files))
+	for _, f := range files {
+		attachment, err := uploadAttachment(storage, f, folder)
+		if err != nil {
+			deleteAttachments(storage, attachments)
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, nil
+}
+
+// uploadAttachment enregistre un fichier dans le stockage
+func uploadAttachment(storage services.FileStorage, f attachmentFile, folder string) (*models.Attachment, error) {
+	file, err := f.header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	filename := path.Base(strings.ReplaceAll(f.header.Filename, "\\", "/"))
+	stored, err := storage.Upload(file, filename, folder)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de l'envoi de %s: %w", filename, err)
+	}
+
+	return &models.Attachment{
+		URL:          stored.URL,
+		ThumbnailURL: stored.ThumbnailURL,
+		Filename:     filename,
+		MimeType:     f.mimeType,
+		Size:         f.header.Size,
+		Width:        stored.Width,
+		Height:       stored.Height,
+		StorageID:    stored.ID,
+		ResourceType: stored.ResourceType,
+	}, nil
+}
+
+// deleteAttachments supprime des pièces jointes du stockage (les erreurs sont seulement journalisées)
+func deleteAttachments(storage services.FileStorage, attachments []models.Attachment) {
+	if storage == nil {
+		return
+	}
+	for _, attachment := range attachments {
+		if err := storage.Delete(attachment.StorageID, attachment.ResourceType); err != nil {
+			log.Printf("⚠️  Erreur suppression pièce jointe %s: %v", attachment.StorageID, err)
+		}
+	}
+}
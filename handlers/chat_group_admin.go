@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UpdateGroup renomme un groupe ou change sa photo et sa description (admins du groupe)
+func (h *ChatGroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	group, actor, ok := h.loadAdministeredGroup(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	updates := bson.M{}
+	var changes []string
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > models.MaxGroupNameLength {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Le nom du groupe est requis (%d caractères maximum)", models.MaxGroupNameLength))
+			return
+		}
+		if name != group.Name {
+			updates["name"] = name
+			changes = append(changes, fmt.Sprintf("a renommé le groupe en « %s »", name))
+			group.Name = name
+		}
+	}
+
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if utf8.RuneCountInString(description) > models.MaxGroupDescriptionLength {
+			utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("Description trop longue (%d caractères maximum)", models.MaxGroupDescriptionLength))
+			return
+		}
+		if description != group.Description {
+			updates["description"] = description
+			changes = append(changes, "a modifié la description du groupe")
+			group.Description = description
+		}
+	}
+
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if avatarURL != "" && !isHTTPURL(avatarURL) {
+			utils.RespondError(w, http.StatusBadRequest, "URL de la photo invalide")
+			return
+		}
+		if avatarURL != group.AvatarURL {
+			updates["avatar_url"] = avatarURL
+			changes = append(changes, "a changé la photo du groupe")
+			group.AvatarURL = avatarURL
+		}
+	}
+
+	if len(updates) == 0 {
+		utils.RespondSuccess(w, "Aucune modification", map[string]interface{}{"group": group})
+		return
+	}
+
+	if err := h.groupRepo.Update(group.ID, updates); err != nil {
+		log.Printf("Erreur mise à jour groupe: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	h.announceGroupChange(group, models.WSGroupUpdated{
+		Action:  models.GroupActionUpdated,
+		ActorID: actor,
+	}, fmt.Sprintf("%s %s", h.memberName(actor), strings.Join(changes, ", ")))
+
+	log.Printf("✓ Groupe %s modifié par %s", group.ID.Hex(), actor)
+	utils.RespondSuccess(w, "Groupe modifié", map[string]interface{}{"group": group})
+}
+
+// UpdateMemberRole promeut un membre administrateur ou le rétrograde (admins du groupe).
+// Le rôle du créateur ne peut pas être modifié et seul lui peut rétrograder un autre administrateur.
+func (h *ChatGroupHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	group, actor, ok := h.loadAdministeredGroup(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+	if req.Role != models.GroupRoleAdmin && req.Role != models.GroupRoleMember {
+		utils.RespondError(w, http.StatusBadRequest, "Rôle invalide (admin ou member)")
+		return
+	}
+
+	target, role, ok := h.loadTargetMember(w, r, group)
+	if !ok {
+		return
+	}
+	if strings.EqualFold(target, group.CreatedBy) {
+		utils.RespondError(w, http.StatusForbidden, "Le rôle du créateur du groupe ne peut pas être modifié")
+		return
+	}
+	if role == req.Role {
+		utils.RespondError(w, http.StatusConflict, "Ce membre a déjà ce rôle")
+		return
+	}
+	// Même règle que l'exclusion : sinon un admin pourrait rétrograder puis exclure un autre admin
+	if role == models.GroupRoleAdmin && !strings.EqualFold(actor, group.CreatedBy) {
+		utils.RespondError(w, http.StatusForbidden, "Seul le créateur du groupe peut rétrograder un administrateur")
+		return
+	}
+
+	if err := h.groupRepo.UpdateMemberRole(group.ID, target, req.Role); err != nil {
+		log.Printf("Erreur mise à jour rôle: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	content := fmt.Sprintf("%s a nommé %s administrateur", h.memberName(actor), h.memberName(target))
+	if req.Role == models.GroupRoleMember {
+		content = fmt.Sprintf("%s a retiré les droits d'administrateur à %s", h.memberName(actor), h.memberName(target))
+	}
+	h.announceGroupChange(group, models.WSGroupUpdated{
+		Action:       models.GroupActionMemberRoleChanged,
+		ActorID:      actor,
+		TargetUserID: target,
+		Role:         req.Role,
+	}, content)
+
+	log.Printf("✓ Rôle de %s dans le groupe %s : %s", target, group.ID.Hex(), req.Role)
+	utils.RespondSuccess(w, "Rôle modifié", map[string]interface{}{
+		"user_id": target,
+		"role":    req.Role,
+	})
+}
+
+// RemoveGroupMember exclut un membre du groupe (admins du groupe).
+// Le créateur ne peut pas être exclu et seul lui peut exclure un autre administrateur.
+func (h *ChatGroupHandler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	group, actor, ok := h.loadAdministeredGroup(w, r, false)
+	if !ok {
+		return
+	}
+
+	target, role, ok := h.loadTargetMember(w, r, group)
+	if !ok {
+		return
+	}
+	if target == actor {
+		utils.RespondError(w, http.StatusBadRequest, "Utilisez « quitter le groupe » pour partir")
+		return
+	}
+	if strings.EqualFold(target, group.CreatedBy) {
+		utils.RespondError(w, http.StatusForbidden, "Le créateur du groupe ne peut pas être exclu")
+		return
+	}
+	if role == models.GroupRoleAdmin && !strings.EqualFold(actor, group.CreatedBy) {
+		utils.RespondError(w, http.StatusForbidden, "Seul le créateur du groupe peut exclure un administrateur")
+		return
+	}
+
+	if err := h.groupRepo.RemoveMember(group.ID, target); err != nil {
+		log.Printf("Erreur exclusion membre: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	// Le membre exclu n'est plus dans le groupe : il reçoit l'événement en plus des membres
+	h.announceGroupChange(group, models.WSGroupUpdated{
+		Action:       models.GroupActionMemberRemoved,
+		ActorID:      actor,
+		TargetUserID: target,
+	}, fmt.Sprintf("%s a retiré %s du groupe", h.memberName(actor), h.memberName(target)), target)
+	if h.wsHub != nil {
+		h.wsHub.RemoveFromGroup(target, group.ID.Hex())
+	}
+
+	log.Printf("✓ %s exclu du groupe %s par %s", target, group.ID.Hex(), actor)
+	utils.RespondSuccess(w, "Membre retiré du groupe", nil)
+}
+
+// TransferOwnership transfère la propriété du groupe à un autre membre (créateur uniquement).
+// Le nouveau propriétaire devient administrateur ; l'ancien le reste.
+func (h *ChatGroupHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	group, actor, ok := h.loadAdministeredGroup(w, r, true)
+	if !ok {
+		return
+	}
+
+	var req models.TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	target := strings.ToLower(strings.TrimSpace(req.UserID))
+	if target == "" || target == actor {
+		utils.RespondError(w, http.StatusBadRequest, "Nouveau propriétaire invalide")
+		return
+	}
+
+	role, err := h.groupRepo.GetUserRole(group.ID, target)
+	if err != nil {
+		log.Printf("Erreur récupération rôle: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if role == "" {
+		utils.RespondError(w, http.StatusNotFound, "Ce membre ne fait pas partie du groupe")
+		return
+	}
+
+	if role != models.GroupRoleAdmin {
+		if err := h.groupRepo.UpdateMemberRole(group.ID, target, models.GroupRoleAdmin); err != nil {
+			log.Printf("Erreur mise à jour rôle: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+			return
+		}
+	}
+
+	if err := h.groupRepo.Update(group.ID, bson.M{"created_by": target}); err != nil {
+		log.Printf("Erreur transfert propriété: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	group.CreatedBy = target
+
+	h.announceGroupChange(group, models.WSGroupUpdated{
+		Action:       models.GroupActionOwnershipTransferred,
+		ActorID:      actor,
+		TargetUserID: target,
+	}, fmt.Sprintf("%s a transféré la propriété du groupe à %s", h.memberName(actor), h.memberName(target)))
+
+	log.Printf("✓ Propriété du groupe %s transférée de %s à %s", group.ID.Hex(), actor, target)
+	utils.RespondSuccess(w, "Propriété du groupe transférée", map[string]interface{}{"group": group})
+}
+
+// ArchiveGroup archive un groupe : il reste consultable mais n'accepte plus de messages (admins du groupe)
+func (h *ChatGroupHandler) ArchiveGroup(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// UnarchiveGroup réactive un groupe archivé (admins du groupe)
+func (h *ChatGroupHandler) UnarchiveGroup(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+// setArchived archive ou désarchive un groupe
+func (h *ChatGroupHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	if (archived && r.Method != http.MethodPost) || (!archived && r.Method != http.MethodDelete) {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	group, actor, ok := h.loadAdministeredGroup(w, r, false)
+	if !ok {
+		return
+	}
+
+	if (group.ArchivedAt != nil) == archived {
+		if archived {
+			utils.RespondError(w, http.StatusConflict, "Groupe déjà archivé")
+		} else {
+			utils.RespondError(w, http.StatusConflict, "Le groupe n'est pas archivé")
+		}
+		return
+	}
+
+	action := models.GroupActionUnarchived
+	content := fmt.Sprintf("%s a réactivé le groupe", h.memberName(actor))
+	group.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		action = models.GroupActionArchived
+		content = fmt.Sprintf("%s a archivé le groupe", h.memberName(actor))
+		group.ArchivedAt = &now
+	}
+
+	if err := h.groupRepo.Update(group.ID, bson.M{"archived_at": group.ArchivedAt}); err != nil {
+		log.Printf("Erreur archivage groupe: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	h.announceGroupChange(group, models.WSGroupUpdated{
+		Action:  action,
+		ActorID: actor,
+	}, content)
+
+	log.Printf("✓ Groupe %s %s par %s", group.ID.Hex(), action, actor)
+	utils.RespondSuccess(w, "Groupe mis à jour", map[string]interface{}{"group": group})
+}
+
+// DissolveGroup dissout un groupe (créateur uniquement) : tous les membres sont retirés,
+// les invitations en attente supprimées et le groupe n'apparaît plus dans les listes.
+func (h *ChatGroupHandler) DissolveGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	group, actor, ok := h.loadAdministeredGroup(w, r, true)
+	if !ok {
+		return
+	}
+
+	members, err := h.groupRepo.GetMembers(group.ID)
+	if err != nil {
+		log.Printf("Erreur récupération membres: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if err := h.groupRepo.Delete(group.ID); err != nil {
+		log.Printf("Erreur dissolution groupe: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	group.IsActive = false
+
+	// Diffusé avant de retirer les membres, pour qu'ils le reçoivent tous
+	h.announceGroupChange(group, models.WSGroupUpdated{
+		Action:  models.GroupActionDissolved,
+		ActorID: actor,
+	}, fmt.Sprintf("%s a dissous le groupe", h.memberName(actor)))
+
+	if _, err := h.groupRepo.RemoveAllMembers(group.ID); err != nil {
+		log.Printf("⚠️ Erreur suppression membres du groupe dissous %s: %v", group.ID.Hex(), err)
+	}
+	if _, err := h.invitationRepo.DeletePendingByGroup(group.ID); err != nil {
+		log.Printf("⚠️ Erreur suppression invitations du groupe dissous %s: %v", group.ID.Hex(), err)
+	}
+	if h.wsHub != nil {
+		for _, member := range members {
+			h.wsHub.RemoveFromGroup(member.ID, group.ID.Hex())
+		}
+	}
+
+	log.Printf("✓ Groupe %s dissous par %s", group.ID.Hex(), actor)
+	utils.RespondSuccess(w, "Groupe dissous", nil)
+}
+
+// loadAdministeredGroup charge le groupe de la route et vérifie que l'utilisateur l'administre
+// (ou en est le créateur si creatorOnly). Retourne aussi l'email normalisé de l'utilisateur.
+func (h *ChatGroupHandler) loadAdministeredGroup(w http.ResponseWriter, r *http.Request, creatorOnly bool) (*models.ChatGroup, string, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return nil, "", false
+	}
+	actor := strings.ToLower(strings.TrimSpace(claims.Email))
+
+	groupID, err := primitive.ObjectIDFromHex(mux.Vars(r)["group_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de groupe invalide")
+		return nil, "", false
+	}
+
+	group, err := h.groupRepo.FindByID(groupID)
+	if err != nil || group == nil || !group.IsActive {
+		utils.RespondError(w, http.StatusNotFound, "Groupe non trouvé")
+		return nil, "", false
+	}
+
+	if creatorOnly {
+		if !strings.EqualFold(group.CreatedBy, actor) {
+			utils.RespondError(w, http.StatusForbidden, "Action réservée au créateur du groupe")
+			return nil, "", false
+		}
+		return group, actor, true
+	}
+
+	role, err := h.groupRepo.GetUserRole(groupID, actor)
+	if err != nil {
+		log.Printf("Erreur récupération rôle: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, "", false
+	}
+	if role != models.GroupRoleAdmin && !strings.EqualFold(group.CreatedBy, actor) {
+		utils.RespondError(w, http.StatusForbidden, "Action réservée aux administrateurs du groupe")
+		return nil, "", false
+	}
+
+	return group, actor, true
+}
+
+// loadTargetMember lit le membre ciblé par la route ({user_id} = email) et son rôle
+func (h *ChatGroupHandler) loadTargetMember(w http.ResponseWriter, r *http.Request, group *models.ChatGroup) (string, string, bool) {
+	target := strings.ToLower(strings.TrimSpace(mux.Vars(r)["user_id"]))
+
+	role, err := h.groupRepo.GetUserRole(group.ID, target)
+	if err != nil {
+		log.Printf("Erreur récupération rôle: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return "", "", false
+	}
+	if role == "" {
+		utils.RespondError(w, http.StatusNotFound, "Ce membre ne fait pas partie du groupe")
+		return "", "", false
+	}
+
+	return target, role, true
+}
+
+// announceGroupChange enregistre le message système d'une action d'administration
+// et envoie l'événement group_updated à chaque membre du groupe (et aux destinataires supplémentaires)
+func (h *ChatGroupHandler) announceGroupChange(group *models.ChatGroup, event models.WSGroupUpdated, content string, extraRecipients ...string) {
+	systemMessage := &models.ChatGroupMessage{
+		GroupID:     group.ID,
+		SenderID:    "system",
+		Content:     content,
+		MessageType: "system",
+	}
+	if err := h.messageRepo.Create(systemMessage); err != nil {
+		log.Printf("Erreur création message système: %v", err)
+	}
+
+	if h.wsHub == nil {
+		return
+	}
+
+	event.Type = models.WSTypeGroupUpdated
+	event.GroupID = group.ID.Hex()
+	event.Group = models.WSGroupInfo{
+		ID:          group.ID.Hex(),
+		Name:        group.Name,
+		Description: group.Description,
+		AvatarURL:   group.AvatarURL,
+		CreatedBy:   group.CreatedBy,
+		ArchivedAt:  group.ArchivedAt,
+		IsActive:    group.IsActive,
+	}
+	event.SystemMessage = models.WSSystemMessage{
+		ID:          systemMessage.ID.Hex(),
+		SenderID:    "system",
+		Content:     systemMessage.Content,
+		MessageType: "system",
+		CreatedAt:   systemMessage.CreatedAt,
+	}
+
+	// Envoi par membre (journalisé) : rejoué à la reconnexion comme les autres événements du groupe
+	h.broadcastToMembers(group.ID, event)
+	for _, recipient := range extraRecipients {
+		h.wsHub.SendToUser(recipient, event)
+	}
+}
+
+// memberName retourne le prénom et le nom d'un utilisateur, ou son email s'il est introuvable
+func (h *ChatGroupHandler) memberName(email string) string {
+	user, err := h.userRepo.FindByEmail(email)
+	if err != nil || user == nil {
+		return email
+	}
+	return strings.TrimSpace(user.Firstname + " " + user.Lastname)
+}
+
+// isHTTPURL indique si une chaîne est une URL http(s) absolue
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
@@ -28,6 +28,7 @@ type ChatGroupHandler struct {
 	fcmTokenRepo   *database.FCMTokenRepository
 	fcmService     *services.FCMService
 	wsHub          *websocket.Hub
+	storage        services.FileStorage
 }
 
 // NewChatGroupHandler crée une nouvelle instance
@@ -35,6 +36,7 @@ func NewChatGroupHandler(
 	db *mongo.Database,
 	fcmService *services.FCMService,
 	wsHub *websocket.Hub,
+	storage services.FileStorage,
 ) *ChatGroupHandler {
 	return &ChatGroupHandler{
 		groupRepo:      database.NewChatGroupRepository(db),
@@ -44,6 +46,7 @@ func NewChatGroupHandler(
 		fcmTokenRepo:   database.NewFCMTokenRepository(db),
 		fcmService:     fcmService,
 		wsHub:          wsHub,
+		storage:        storage,
 	}
 }
 
@@ -155,24 +158,24 @@ func (h *ChatGroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		creator, err := h.userRepo.FindByEmail(claims.Email)
 		if err == nil && creator != nil {
 			// Construire un GroupWithDetails complet comme dans GetUserGroups
-			groupDetails := map[string]interface{}{
-				"id":           group.ID.Hex(),
-				"name":         group.Name,
-				"member_count": memberCount,
-				"unread_count": 0,
-				"created_at":   group.CreatedAt,
-				"created_by": map[string]interface{}{
-					"id":        creator.Email,
-					"firstname": creator.Firstname,
-					"lastname":  creator.Lastname,
-					"email":     creator.Email,
+			groupDetails := models.GroupWithDetails{
+				ID:          group.ID.Hex(),
+				Name:        group.Name,
+				MemberCount: memberCount,
+				UnreadCount: 0,
+				CreatedAt:   group.CreatedAt,
+				CreatedBy: models.GroupCreatorInfo{
+					ID:        creator.Email,
+					Firstname: creator.Firstname,
+					Lastname:  creator.Lastname,
+					Email:     creator.Email,
 				},
-				"last_message": nil, // Pas de message au moment de la création
+				LastMessage: nil, // Pas de message au moment de la création
 			}
 
-			payload := map[string]interface{}{
-				"type":  "group_created",
-				"group": groupDetails,
+			payload := models.WSGroupCreated{
+				Type:  models.WSTypeGroupCreated,
+				Group: groupDetails,
 			}
 			h.wsHub.SendToUser(claims.Email, payload)
 		}
@@ -254,6 +257,10 @@ func (h *ChatGroupHandler) InviteToGroup(w http.ResponseWriter, r *http.Request)
 		utils.RespondError(w, http.StatusNotFound, "Groupe non trouvé")
 		return
 	}
+	if group.ArchivedAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Groupe archivé : invitations impossibles")
+		return
+	}
 
 	// Vérifier que l'utilisateur existe
 	user, err := h.userRepo.FindByEmail(req.UserID)
@@ -571,19 +578,22 @@ func (h *ChatGroupHandler) LeaveGroup(w http.ResponseWriter, r *http.Request) {
 		log.Printf("Erreur création message système: %v", err)
 	}
 
+	// Ne plus recevoir les envois du groupe, sur toutes les instances
+	h.wsHub.RemoveFromGroup(claims.Email, groupID.Hex())
+
 	// Notifier les autres membres via WebSocket
 	members, _ := h.groupRepo.GetMembers(groupID)
-	payload := map[string]interface{}{
-		"type":      "group_member_left",
-		"group_id":  groupID.Hex(),
-		"user_id":   claims.Email,
-		"user_name": userName,
-		"message": map[string]interface{}{
-			"id":           systemMessage.ID.Hex(),
-			"sender_id":    "system",
-			"content":      systemMessage.Content,
-			"message_type": "system",
-			"created_at":   systemMessage.CreatedAt,
+	payload := models.WSGroupMemberLeft{
+		Type:     models.WSTypeGroupMemberLeft,
+		GroupID:  groupID.Hex(),
+		UserID:   claims.Email,
+		UserName: userName,
+		Message: models.WSSystemMessage{
+			ID:          systemMessage.ID.Hex(),
+			SenderID:    "system",
+			Content:     systemMessage.Content,
+			MessageType: "system",
+			CreatedAt:   systemMessage.CreatedAt,
 		},
 	}
 
@@ -703,14 +713,23 @@ func (h *ChatGroupHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Body JSON, ou multipart/form-data avec des pièces jointes
 	var req models.SendGroupMessageRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var form *messageForm
+	if isMultipartRequest(r) {
+		var formErr *formError
+		if form, formErr = parseMessageForm(w, r); formErr != nil {
+			utils.RespondError(w, formErr.status, formErr.message)
+			return
+		}
+		req.Content, req.ReplyTo = form.Content, form.ReplyTo
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
 		return
 	}
 
 	// Valider
-	if req.Content == "" {
+	if req.Content == "" && !form.hasFiles() {
 		utils.RespondError(w, http.StatusBadRequest, "Le contenu est requis")
 		return
 	}
@@ -722,6 +741,17 @@ func (h *ChatGroupHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Un groupe archivé est en lecture seule
+	group, err := h.groupRepo.FindByID(groupID)
+	if err != nil || group == nil {
+		utils.RespondError(w, http.StatusNotFound, "Groupe non trouvé")
+		return
+	}
+	if group.ArchivedAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Groupe archivé : lecture seule")
+		return
+	}
+
 	// Normaliser l'email pour la cohérence
 	normalizedEmail := strings.ToLower(strings.TrimSpace(claims.Email))
 
@@ -733,12 +763,52 @@ func (h *ChatGroupHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		MessageType: "message",
 	}
 
+	// Mentions (@prénom ou @email) validées contre les membres du groupe
+	members, err := h.groupRepo.GetMembers(groupID)
+	if err != nil {
+		log.Printf("Erreur récupération membres: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	message.Mentions = models.ParseMentions(req.Content, members)
+
+	// Réponse : le fil est celui du message cité (ou le message cité lui-même)
+	var quoted *models.ChatGroupMessage
+	if req.ReplyTo != "" {
+		var ok bool
+		if quoted, ok = h.loadReplyTarget(w, groupID, req.ReplyTo); !ok {
+			return
+		}
+		message.ReplyToID = &quoted.ID
+		message.ThreadID = &quoted.ID
+		if quoted.ThreadID != nil {
+			message.ThreadID = quoted.ThreadID
+		}
+	}
+
+	// Enregistrer les pièces jointes
+	if form.hasFiles() {
+		message.Attachments, err = uploadAttachments(h.storage, form.files, "chat/groups/"+groupID.Hex())
+		if err != nil {
+			log.Printf("❌ Erreur envoi pièces jointes: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de l'envoi des pièces jointes")
+			return
+		}
+	}
+
 	if err := h.messageRepo.Create(message); err != nil {
 		log.Printf("Erreur création message: %v", err)
+		deleteAttachments(h.storage, message.Attachments)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
 		return
 	}
 
+	if message.ThreadID != nil {
+		if err := h.messageRepo.IncrementReplyCount(*message.ThreadID); err != nil {
+			log.Printf("⚠️ Erreur mise à jour du fil %s: %v", message.ThreadID.Hex(), err)
+		}
+	}
+
 	// Récupérer les infos de l'expéditeur (utiliser l'email normalisé)
 	sender, err := h.userRepo.FindByEmail(normalizedEmail)
 	if err != nil {
@@ -759,6 +829,15 @@ func (h *ChatGroupHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:   message.CreatedAt,
 		DeliveredAt: message.DeliveredAt,
 		ReadBy:      message.ReadBy,
+		ReplyToID:   message.ReplyToID,
+		ThreadID:    message.ThreadID,
+		Mentions:    message.Mentions,
+		Attachments: message.Attachments,
+	}
+
+	if quoted != nil {
+		quotedSender, _ := h.userRepo.FindByEmail(quoted.SenderID)
+		messageWithSender.Quoted = models.NewMessageQuote(quoted, quotedSender)
 	}
 
 	if sender != nil {
@@ -779,10 +858,7 @@ func (h *ChatGroupHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	h.broadcastGroupMessage(groupID, &messageWithSender)
 
 	// Envoyer FCM aux membres non connectés
-	group, _ := h.groupRepo.FindByID(groupID)
-	if group != nil {
-		h.sendGroupMessageFCM(group, sender, message)
-	}
+	h.sendGroupMessageFCM(group, sender, message, quoted)
 
 	log.Printf("✓ Message envoyé dans le groupe %s par %s", groupID.Hex(), claims.Email)
 	utils.RespondSuccess(w, "Message envoyé", messageWithSender)
@@ -854,37 +930,10 @@ func (h *ChatGroupHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	log.Printf("✅ Messages récupérés: %d", len(messages))
 
 	// Enrichir les messages avec les données de l'expéditeur
-	var enrichedMessages []models.GroupMessageWithSender
-	for _, msg := range messages {
-		enrichedMsg := models.GroupMessageWithSender{
-			ID:          msg.ID,
-			SenderID:    msg.SenderID,
-			Content:     msg.Content,
-			MessageType: msg.MessageType,
-			Timestamp:   msg.Timestamp,
-			CreatedAt:   msg.CreatedAt,
-			DeliveredAt: msg.DeliveredAt,
-			ReadBy:      msg.ReadBy,
-		}
-
-		// Récupérer les infos de l'expéditeur
-		sender, err := h.userRepo.FindByEmail(msg.SenderID)
-		if err == nil && sender != nil {
-			enrichedMsg.Sender = &models.UserBasicInfo{
-				ID:              sender.Email,
-				Firstname:       sender.Firstname,
-				Lastname:        sender.Lastname,
-				Email:           sender.Email,
-				ProfilePicture:  sender.ProfileImageURL,
-				ProfileImageURL: sender.ProfileImageURL,
-			}
-		}
-
-		enrichedMessages = append(enrichedMessages, enrichedMsg)
-	}
+	h.enrichSenders(messages)
 
 	utils.RespondSuccess(w, "Messages récupérés", map[string]interface{}{
-		"messages": enrichedMessages,
+		"messages": messages,
 	})
 }
 
@@ -28,25 +28,25 @@ func (h *ChatGroupHandler) sendGroupInvitationNotification(group *models.ChatGro
 	memberCount, _ := h.groupRepo.GetMemberCount(group.ID)
 
 	// Préparer le payload
-	payload := map[string]interface{}{
-		"type": "group_invitation",
-		"invitation": map[string]interface{}{
-			"id": invitation.ID.Hex(),
-			"group": map[string]interface{}{
-				"id":   group.ID.Hex(),
-				"name": group.Name,
-				"created_by": map[string]interface{}{
-					"firstname": creator.Firstname,
-					"lastname":  creator.Lastname,
+	payload := models.WSGroupInvitation{
+		Type: models.WSTypeGroupInvitation,
+		Invitation: models.WSGroupInvitationDetails{
+			ID: invitation.ID.Hex(),
+			Group: models.WSInvitedGroup{
+				ID:   group.ID.Hex(),
+				Name: group.Name,
+				CreatedBy: models.WSUserRef{
+					Firstname: creator.Firstname,
+					Lastname:  creator.Lastname,
 				},
-				"member_count": memberCount,
+				MemberCount: memberCount,
 			},
-			"invited_by": map[string]interface{}{
-				"firstname": inviter.Firstname,
-				"lastname":  inviter.Lastname,
+			InvitedBy: models.WSUserRef{
+				Firstname: inviter.Firstname,
+				Lastname:  inviter.Lastname,
 			},
-			"message":    invitation.Message,
-			"invited_at": invitation.InvitedAt,
+			Message:   invitation.Message,
+			InvitedAt: invitation.InvitedAt,
 		},
 	}
 
@@ -79,11 +79,10 @@ func (h *ChatGroupHandler) sendGroupInvitationFCM(group *models.ChatGroup, invit
 		"group_name": group.Name,
 	}
 
-	// Envoyer à tous les tokens de l'utilisateur
-	for _, token := range tokens {
-		if err := h.fcmService.SendToToken(token.Token, title, message, data); err != nil {
-			log.Printf("❌ Erreur envoi FCM: %v", err)
-		}
+	// Envoyer à tous les tokens de l'utilisateur en un seul envoi (sauf sourdine ou heures calmes)
+	fcmTokens := h.fcmService.FilterTokens(tokens, models.NotificationContext{Category: models.NotificationCategoryGeneral})
+	if _, failed, _ := h.fcmService.SendToAll(fcmTokens, title, message, data); failed > 0 {
+		log.Printf("❌ Erreur envoi FCM: %d échec(s)", failed)
 	}
 
 	log.Printf("📱 Notification FCM envoyée: group_invitation à %s", invitedUser.Email)
@@ -91,15 +90,15 @@ func (h *ChatGroupHandler) sendGroupInvitationFCM(group *models.ChatGroup, invit
 
 // notifyInvitationAccepted notifie l'admin que l'invitation a été acceptée
 func (h *ChatGroupHandler) notifyInvitationAccepted(invitation *models.ChatGroupInvitation, user *models.User, group *models.ChatGroup) {
-	payload := map[string]interface{}{
-		"type":     "group_invitation_accepted",
-		"group_id": group.ID.Hex(),
-		"user": map[string]interface{}{
-			"id":        user.Email,
-			"firstname": user.Firstname,
-			"lastname":  user.Lastname,
+	payload := models.WSGroupInvitationAccepted{
+		Type:    models.WSTypeGroupInvitationAccepted,
+		GroupID: group.ID.Hex(),
+		User: models.WSUserRef{
+			ID:        user.Email,
+			Firstname: user.Firstname,
+			Lastname:  user.Lastname,
 		},
-		"accepted_at": invitation.RespondedAt,
+		AcceptedAt: invitation.RespondedAt,
 	}
 
 	h.wsHub.SendToUser(invitation.InvitedBy, payload)
@@ -109,15 +108,15 @@ func (h *ChatGroupHandler) notifyInvitationAccepted(invitation *models.ChatGroup
 
 // notifyInvitationRejected notifie l'admin que l'invitation a été refusée
 func (h *ChatGroupHandler) notifyInvitationRejected(invitation *models.ChatGroupInvitation, user *models.User, group *models.ChatGroup) {
-	payload := map[string]interface{}{
-		"type":     "group_invitation_rejected",
-		"group_id": group.ID.Hex(),
-		"user": map[string]interface{}{
-			"id":        user.Email,
-			"firstname": user.Firstname,
-			"lastname":  user.Lastname,
+	payload := models.WSGroupInvitationRejected{
+		Type:    models.WSTypeGroupInvitationRejected,
+		GroupID: group.ID.Hex(),
+		User: models.WSUserRef{
+			ID:        user.Email,
+			Firstname: user.Firstname,
+			Lastname:  user.Lastname,
 		},
-		"rejected_at": invitation.RespondedAt,
+		RejectedAt: invitation.RespondedAt,
 	}
 
 	h.wsHub.SendToUser(invitation.InvitedBy, payload)
@@ -134,20 +133,20 @@ func (h *ChatGroupHandler) broadcastMemberJoined(group *models.ChatGroup, user *
 		return
 	}
 
-	payload := map[string]interface{}{
-		"type":     "group_member_joined",
-		"group_id": group.ID.Hex(),
-		"user": map[string]interface{}{
-			"id":        user.Email,
-			"firstname": user.Firstname,
-			"lastname":  user.Lastname,
-			"email":     user.Email,
+	payload := models.WSGroupMemberJoined{
+		Type:    models.WSTypeGroupMemberJoined,
+		GroupID: group.ID.Hex(),
+		User: models.WSUserRef{
+			ID:        user.Email,
+			Firstname: user.Firstname,
+			Lastname:  user.Lastname,
+			Email:     user.Email,
 		},
-		"system_message": map[string]interface{}{
-			"id":           systemMessage.ID.Hex(),
-			"content":      systemMessage.Content,
-			"message_type": systemMessage.MessageType,
-			"created_at":   systemMessage.CreatedAt,
+		SystemMessage: models.WSSystemMessage{
+			ID:          systemMessage.ID.Hex(),
+			Content:     systemMessage.Content,
+			MessageType: systemMessage.MessageType,
+			CreatedAt:   systemMessage.CreatedAt,
 		},
 	}
 
@@ -170,10 +169,10 @@ func (h *ChatGroupHandler) broadcastGroupMessage(groupID primitive.ObjectID, mes
 		return
 	}
 
-	payload := map[string]interface{}{
-		"type":     "new_group_message",
-		"group_id": groupID.Hex(),
-		"message":  message,
+	payload := models.WSNewGroupMessage{
+		Type:    models.WSTypeNewGroupMessage,
+		GroupID: groupID.Hex(),
+		Message: message,
 	}
 
 	// Envoyer à TOUS les membres (y compris l'expéditeur) pour affichage correct
@@ -186,8 +185,11 @@ func (h *ChatGroupHandler) broadcastGroupMessage(groupID primitive.ObjectID, mes
 	log.Printf("📨 Message diffusé dans le groupe %s", groupID.Hex())
 }
 
-// sendGroupMessageFCM envoie une notification FCM pour un nouveau message
-func (h *ChatGroupHandler) sendGroupMessageFCM(group *models.ChatGroup, sender *models.User, message *models.ChatGroupMessage) {
+// sendGroupMessageFCM envoie une notification FCM pour un nouveau message.
+// Les membres mentionnés reçoivent une notification prioritaire « vous a mentionné » ;
+// si le message répond à un autre (quoted), l'auteur du message cité reçoit « a répondu à votre message ».
+// Les préférences de chaque membre s'appliquent ; une mention passe outre la sourdine du groupe.
+func (h *ChatGroupHandler) sendGroupMessageFCM(group *models.ChatGroup, sender *models.User, message *models.ChatGroupMessage, quoted *models.ChatGroupMessage) {
 	// Récupérer tous les membres du groupe
 	members, err := h.groupRepo.GetMembers(group.ID)
 	if err != nil {
@@ -195,7 +197,7 @@ func (h *ChatGroupHandler) sendGroupMessageFCM(group *models.ChatGroup, sender *
 	}
 
 	// Collecter les tokens de tous les membres (sauf l'expéditeur)
-	var tokens []string
+	var tokens, replyTokens, mentionTokens []string
 	for _, member := range members {
 		if member.ID == sender.Email {
 			continue
@@ -206,23 +208,30 @@ func (h *ChatGroupHandler) sendGroupMessageFCM(group *models.ChatGroup, sender *
 			continue
 		}
 
+		mentioned := models.IsMentioned(message.Mentions, member.ID)
+		notification := models.NotificationContext{Category: models.NotificationCategoryChat, GroupID: group.ID.Hex(), Mention: mentioned}
+		if !h.fcmService.Allows(member.ID, notification) {
+			continue
+		}
+
 		for _, token := range memberTokens {
-			tokens = append(tokens, token.Token)
+			switch {
+			case mentioned:
+				mentionTokens = append(mentionTokens, token.Token)
+			case quoted != nil && member.ID == quoted.SenderID:
+				replyTokens = append(replyTokens, token.Token)
+			default:
+				tokens = append(tokens, token.Token)
+			}
 		}
 	}
 
-	if len(tokens) == 0 {
+	if len(tokens) == 0 && len(replyTokens) == 0 && len(mentionTokens) == 0 {
 		return
 	}
 
 	// Préparer la notification
 	title := fmt.Sprintf("👥 %s", group.Name)
-	body := fmt.Sprintf("%s: %s", sender.Firstname, message.Content)
-
-	// Limiter le corps du message
-	if len(body) > 100 {
-		body = body[:97] + "..."
-	}
 
 	data := map[string]string{
 		"type":        "group_message",
@@ -230,10 +239,39 @@ func (h *ChatGroupHandler) sendGroupMessageFCM(group *models.ChatGroup, sender *
 		"message_id":  message.ID.Hex(),
 		"sender_name": fmt.Sprintf("%s %s", sender.Firstname, sender.Lastname),
 	}
+	if message.ThreadID != nil {
+		data["reply_to"] = message.ReplyToID.Hex()
+		data["thread_id"] = message.ThreadID.Hex()
+	}
 
 	// Envoyer via FCM
-	success, failed, _ := h.fcmService.SendToAll(tokens, title, body, data)
-	log.Printf("📱 FCM group message: %d succès, %d échecs", success, failed)
+	if len(tokens) > 0 {
+		body := truncateNotificationBody(fmt.Sprintf("%s: %s", sender.Firstname, models.ContentPreview(message.Content, message.Attachments)))
+		success, failed, _ := h.fcmService.SendToAll(tokens, title, body, data)
+		log.Printf("📱 FCM group message: %d succès, %d échecs", success, failed)
+	}
+	if len(replyTokens) > 0 {
+		body := truncateNotificationBody(fmt.Sprintf("%s a répondu à votre message : %s", sender.Firstname, models.ContentPreview(message.Content, message.Attachments)))
+		success, failed, _ := h.fcmService.SendToAll(replyTokens, title, body, data)
+		log.Printf("📱 FCM group reply: %d succès, %d échecs", success, failed)
+	}
+	if len(mentionTokens) > 0 {
+		mentionData := map[string]string{"mention": "true"}
+		for key, value := range data {
+			mentionData[key] = value
+		}
+		body := truncateNotificationBody(fmt.Sprintf("%s vous a mentionné : %s", sender.Firstname, models.ContentPreview(message.Content, message.Attachments)))
+		success, failed, _ := h.fcmService.SendToAllHighPriority(mentionTokens, title, body, mentionData)
+		log.Printf("📱 FCM group mention: %d succès, %d échecs", success, failed)
+	}
+}
+
+// truncateNotificationBody limite le corps d'une notification à 100 octets
+func truncateNotificationBody(body string) string {
+	if len(body) > 100 {
+		body = body[:97] + "..."
+	}
+	return body
 }
 
 // broadcastMessagesRead notifie que des messages ont été lus
@@ -244,11 +282,10 @@ func (h *ChatGroupHandler) broadcastMessagesRead(groupID primitive.ObjectID, use
 		return
 	}
 
-	payload := map[string]interface{}{
-		"type":     "group_messages_read",
-		"group_id": groupID.Hex(),
-		"user_id":  userID,
-		"read_at":  models.FlexibleTime{},
+	payload := models.WSGroupMessagesRead{
+		Type:    models.WSTypeGroupMessagesRead,
+		GroupID: groupID.Hex(),
+		UserID:  userID,
 	}
 
 	// Envoyer à tous les membres sauf celui qui a lu (JSON direct)
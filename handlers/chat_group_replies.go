@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetThread récupère un fil de discussion : le premier message et ses réponses.
+// L'ID peut être celui du premier message ou de n'importe quelle réponse du fil.
+func (h *ChatGroupHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	message, _, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	threadID := message.ID
+	if message.ThreadID != nil {
+		threadID = *message.ThreadID
+	}
+
+	root, err := h.messageRepo.FindWithSenderByID(threadID)
+	if err != nil {
+		log.Printf("❌ Erreur récupération fil: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if root == nil {
+		utils.RespondError(w, http.StatusNotFound, "Fil de discussion non trouvé")
+		return
+	}
+
+	// Paramètres de pagination
+	limit := 50
+	if parsedLimit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+	}
+
+	var before *primitive.ObjectID
+	if beforeID, err := primitive.ObjectIDFromHex(r.URL.Query().Get("before")); err == nil {
+		before = &beforeID
+	}
+
+	replies, err := h.messageRepo.FindThread(threadID, limit, before)
+	if err != nil {
+		log.Printf("❌ Erreur récupération réponses: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	rootList := []models.GroupMessageWithSender{*root}
+	h.enrichSenders(rootList)
+	h.enrichSenders(replies)
+
+	utils.RespondSuccess(w, "Fil récupéré", map[string]interface{}{
+		"root":        rootList[0],
+		"replies":     replies,
+		"reply_count": rootList[0].ReplyCount,
+	})
+}
+
+// loadReplyTarget vérifie le message auquel on répond : même groupe, message utilisateur non supprimé
+func (h *ChatGroupHandler) loadReplyTarget(w http.ResponseWriter, groupID primitive.ObjectID, replyTo string) (*models.ChatGroupMessage, bool) {
+	replyToID, err := primitive.ObjectIDFromHex(replyTo)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID du message cité invalide")
+		return nil, false
+	}
+
+	quoted, err := h.messageRepo.FindByID(replyToID)
+	if err != nil {
+		log.Printf("Erreur récupération message cité: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, false
+	}
+	if quoted == nil || quoted.GroupID != groupID {
+		utils.RespondError(w, http.StatusNotFound, "Message cité non trouvé")
+		return nil, false
+	}
+	if quoted.MessageType == "system" {
+		utils.RespondError(w, http.StatusBadRequest, "Impossible de répondre à un message système")
+		return nil, false
+	}
+	if quoted.DeletedAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Message cité supprimé")
+		return nil, false
+	}
+
+	return quoted, true
+}
+
+// enrichSenders complète les infos des expéditeurs (email, photo de profil)
+func (h *ChatGroupHandler) enrichSenders(messages []models.GroupMessageWithSender) {
+	for i := range messages {
+		sender, err := h.userRepo.FindByEmail(messages[i].SenderID)
+		if err == nil && sender != nil {
+			messages[i].Sender = &models.UserBasicInfo{
+				ID:              sender.Email,
+				Firstname:       sender.Firstname,
+				Lastname:        sender.Lastname,
+				Email:           sender.Email,
+				ProfilePicture:  sender.ProfileImageURL,
+				ProfileImageURL: sender.ProfileImageURL,
+			}
+		}
+	}
+}
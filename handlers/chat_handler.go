@@ -31,17 +31,21 @@ type ChatHandler struct {
 	userRepo     *database.UserRepository
 	fcmTokenRepo *database.FCMTokenRepository
 	fcmService   *services.FCMService
+	outbox       *services.NotificationOutbox
 	wsHub        WebSocketHub
+	storage      services.FileStorage
 }
 
 // NewChatHandler crée un nouveau handler pour le chat
-func NewChatHandler(chatRepo *database.ChatRepository, userRepo *database.UserRepository, fcmTokenRepo *database.FCMTokenRepository, fcmService *services.FCMService, wsHub WebSocketHub) *ChatHandler {
+func NewChatHandler(chatRepo *database.ChatRepository, userRepo *database.UserRepository, fcmTokenRepo *database.FCMTokenRepository, fcmService *services.FCMService, outbox *services.NotificationOutbox, wsHub WebSocketHub, storage services.FileStorage) *ChatHandler {
 	return &ChatHandler{
 		chatRepo:     chatRepo,
 		userRepo:     userRepo,
 		fcmTokenRepo: fcmTokenRepo,
 		fcmService:   fcmService,
+		outbox:       outbox,
 		wsHub:        wsHub,
+		storage:      storage,
 	}
 }
 
@@ -189,6 +193,10 @@ func (h *ChatHandler) GetMessages(w http.ResponseWriter, r *http.Request) {
 
 	// Enrichir les messages avec les données de l'expéditeur
 	for i := range messages {
+		if messages[i].SenderID == models.DeletedUserObjectID {
+			messages[i].Sender = models.DeletedUserInfo()
+			continue
+		}
 		sender, err := h.userRepo.FindByID(messages[i].SenderID)
 		if err == nil && sender != nil {
 			messages[i].Sender = &models.UserInfo{
@@ -286,10 +294,10 @@ func (h *ChatHandler) MarkConversationAsRead(w http.ResponseWriter, r *http.Requ
 	// 🔌 Envoyer via WebSocket UNIQUEMENT aux expéditeurs des messages qui viennent d'être lus
 	if h.wsHub != nil && markedCount > 0 && len(senderIDs) > 0 {
 		readAt := time.Now()
-		payload := map[string]interface{}{
-			"type":            "messages_read",
-			"conversation_id": conversationIDStr,
-			"read_at":         readAt.Format(time.RFC3339),
+		payload := models.WSMessagesRead{
+			Type:           models.WSTypeMessagesRead,
+			ConversationID: conversationIDStr,
+			ReadAt:         readAt.Format(time.RFC3339),
 		}
 
 		// ⚠️ CRITIQUE : Envoyer uniquement aux expéditeurs des messages (pas à tous les participants)
@@ -376,50 +384,73 @@ func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parser le body JSON
+	// Parser le body : JSON, ou multipart/form-data avec des pièces jointes
 	var request models.MessageRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+	var form *messageForm
+	if isMultipartRequest(r) {
+		var formErr *formError
+		if form, formErr = parseMessageForm(w, r); formErr != nil {
+			http.Error(w, formErr.message, formErr.status)
+			return
+		}
+		request.Content = form.Content
+	} else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Body JSON invalide", http.StatusBadRequest)
 		return
 	}
 
 	// Validation
-	if strings.TrimSpace(request.Content) == "" {
+	if strings.TrimSpace(request.Content) == "" && !form.hasFiles() {
 		http.Error(w, "Contenu du message requis", http.StatusBadRequest)
 		return
 	}
 
+	// Enregistrer les pièces jointes
+	var attachments []models.Attachment
+	if form.hasFiles() {
+		attachments, err = uploadAttachments(h.storage, form.files, "chat/conversations/"+conversationIDStr)
+		if err != nil {
+			log.Printf("❌ Erreur envoi pièces jointes: %v", err)
+			http.Error(w, "Erreur lors de l'envoi des pièces jointes", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Créer le message
 	message := &models.Message{
 		ConversationID: conversationID,
 		SenderID:       userID,
 		Content:        strings.TrimSpace(request.Content),
-		Type:           "text",
+		Type:           models.AttachmentsMessageType(attachments),
+		Attachments:    attachments,
 	}
 
 	// Envoyer le message
 	if err := h.chatRepo.SendMessage(r.Context(), message); err != nil {
+		deleteAttachments(h.storage, attachments)
 		http.Error(w, "Erreur serveur", http.StatusInternalServerError)
 		return
 	}
 
-	// Envoyer une notification aux autres participants (FCM)
-	go h.sendMessageNotification(conversation, message, userID)
+	// Envoyer une notification aux autres participants (FCM, via l'outbox)
+	h.sendMessageNotification(conversation, message, userID)
 
 	// 🔌 Envoyer via WebSocket à TOUS les participants (même ceux qui n'ont pas rejoint la room)
 	if h.wsHub != nil {
 
-		payload := map[string]interface{}{
-			"type":            "new_message",
-			"conversation_id": conversationIDStr,
-			"message": map[string]interface{}{
-				"id":              message.ID.Hex(),
-				"conversation_id": conversationIDStr,
-				"sender_id":       userID.Hex(),
-				"content":         message.Content,
-				"timestamp":       message.CreatedAt,
-				"delivered_at":    message.DeliveredAt,
-				"read_at":         message.ReadAt,
+		payload := models.WSNewMessage{
+			Type:           models.WSTypeNewMessage,
+			ConversationID: conversationIDStr,
+			Message: models.WSChatMessage{
+				ID:             message.ID.Hex(),
+				ConversationID: conversationIDStr,
+				SenderID:       userID.Hex(),
+				Content:        message.Content,
+				Timestamp:      message.CreatedAt,
+				DeliveredAt:    message.DeliveredAt,
+				ReadAt:         message.ReadAt,
+				Type:           message.Type,
+				Attachments:    message.Attachments,
 			},
 		}
 
@@ -579,27 +610,27 @@ func (h *ChatHandler) SendInvitation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Envoyer une notification (FCM)
-	go h.sendInvitationNotification(invitation, user)
+	// Envoyer une notification (FCM, via l'outbox)
+	h.sendInvitationNotification(invitation, user)
 
 	// 🔌 Envoyer via WebSocket au destinataire
 	if h.wsHub != nil {
 		h.wsHub.SendToUser(
 			toUserID.Hex(),
-			map[string]interface{}{
-				"type": "new_invitation",
-				"invitation": map[string]interface{}{
-					"id":           invitation.ID.Hex(),
-					"from_user_id": userID.Hex(),
-					"to_user_id":   toUserID.Hex(),
-					"status":       "pending",
-					"message":      invitation.Message,
-					"created_at":   invitation.CreatedAt,
-					"fromUser": map[string]interface{}{
-						"id":        user.ID.Hex(),
-						"firstname": user.Firstname,
-						"lastname":  user.Lastname,
-						"email":     user.Email,
+			models.WSNewInvitation{
+				Type: models.WSTypeNewInvitation,
+				Invitation: models.WSChatInvitation{
+					ID:         invitation.ID.Hex(),
+					FromUserID: userID.Hex(),
+					ToUserID:   toUserID.Hex(),
+					Status:     "pending",
+					Message:    invitation.Message,
+					CreatedAt:  invitation.CreatedAt,
+					FromUser: models.WSUserRef{
+						ID:        user.ID.Hex(),
+						Firstname: user.Firstname,
+						Lastname:  user.Lastname,
+						Email:     user.Email,
 					},
 				},
 			},
@@ -727,7 +758,7 @@ func (h *ChatHandler) RespondToInvitation(w http.ResponseWriter, r *http.Request
 
 	// Si acceptée, envoyer une notification au demandeur
 	if request.Action == "accept" && conversation != nil {
-		go h.sendAcceptedInvitationNotification(&invitation, user)
+		h.sendAcceptedInvitationNotification(&invitation, user)
 
 		// 🔌 Envoyer via WebSocket à l'expéditeur
 		if h.wsHub != nil {
@@ -738,19 +769,19 @@ func (h *ChatHandler) RespondToInvitation(w http.ResponseWriter, r *http.Request
 				// Le WebSocket identifie les utilisateurs par leur email
 				h.wsHub.SendToUser(
 					fromUser.Email,
-					map[string]interface{}{
-						"type":          "invitation_accepted",
-						"invitation_id": invitationID.Hex(),
-						"conversation": map[string]interface{}{
-							"id": conversation.ID.Hex(),
-							"participant": map[string]interface{}{
-								"id":        user.ID.Hex(),
-								"firstname": user.Firstname,
-								"lastname":  user.Lastname,
-								"email":     user.Email,
+					models.WSInvitationAccepted{
+						Type:         models.WSTypeInvitationAccepted,
+						InvitationID: invitationID.Hex(),
+						Conversation: models.WSAcceptedConversation{
+							ID: conversation.ID.Hex(),
+							Participant: models.WSUserRef{
+								ID:        user.ID.Hex(),
+								Firstname: user.Firstname,
+								Lastname:  user.Lastname,
+								Email:     user.Email,
 							},
-							"status":       "accepted",
-							"unread_count": 0,
+							Status:      "accepted",
+							UnreadCount: 0,
 						},
 					},
 				)
@@ -766,9 +797,9 @@ func (h *ChatHandler) RespondToInvitation(w http.ResponseWriter, r *http.Request
 				// Le WebSocket identifie les utilisateurs par leur email
 				h.wsHub.SendToUser(
 					fromUser.Email,
-					map[string]interface{}{
-						"type":          "invitation_rejected",
-						"invitation_id": invitationID.Hex(),
+					models.WSInvitationRejected{
+						Type:         models.WSTypeInvitationRejected,
+						InvitationID: invitationID.Hex(),
 					},
 				)
 			}
@@ -865,14 +896,9 @@ func (h *ChatHandler) SendChatNotification(w http.ResponseWriter, r *http.Reques
 				}
 			}
 
-			// Envoyer à tous les tokens de l'utilisateur
-			for _, token := range fcmTokens {
-				err = h.fcmService.SendToToken(token.Token, request.Title, request.Body, fcmData)
-				if err != nil {
-					// Log l'erreur mais continue avec les autres tokens
-					continue
-				}
-			}
+			// Envoyer à tous les tokens de l'utilisateur en un seul envoi (sauf sourdine ou heures calmes)
+			tokens := h.fcmService.FilterTokens(fcmTokens, models.NotificationContext{Category: models.NotificationCategoryGeneral})
+			h.fcmService.SendToAll(tokens, request.Title, request.Body, fcmData)
 		}
 	}
 
@@ -886,145 +912,117 @@ func (h *ChatHandler) SendChatNotification(w http.ResponseWriter, r *http.Reques
 }
 
 // sendMessageNotification envoie une notification pour un nouveau message
+// (via l'outbox : relancée si FCM échoue, selon les réglages de chaque destinataire pour cette conversation)
 func (h *ChatHandler) sendMessageNotification(conversation *models.Conversation, message *models.Message, senderID primitive.ObjectID) {
-	if h.fcmService == nil {
+	if h.outbox == nil {
 		return
 	}
 
-	// Trouver les autres participants
-	for _, participant := range conversation.Participants {
-		if participant.UserID != senderID {
-
-			// Récupérer les informations de l'expéditeur
-			sender, err := h.userRepo.FindByID(senderID)
-			if err != nil {
-				continue
-			}
-
-			// Format ultra simple : Juste le nom et le message
-			title := sender.Firstname + " " + strings.ToUpper(sender.Lastname)
-			body := message.Content
-			if len(body) > 240 {
-				body = body[:240] // Limiter à 240 caractères
-			}
-			data := map[string]interface{}{
-				"type":           "chat_message",
-				"conversationId": conversation.ID.Hex(),
-				"messageId":      message.ID.Hex(),
-				"senderId":       senderID.Hex(),
-				"senderName":     sender.Firstname + " " + sender.Lastname,
-			}
-
-			// Récupérer l'utilisateur pour obtenir son email
-			participantUser, err := h.userRepo.FindByID(participant.UserID)
-			if err != nil {
-				continue
-			}
-
-			// Récupérer les tokens FCM du participant (par email)
-			fcmTokens, err := h.fcmTokenRepo.FindByUserID(participantUser.Email)
-			if err != nil {
-				continue
-			}
-
-			if len(fcmTokens) > 0 {
-				// Convertir les données en map[string]string pour FCM
-				fcmData := make(map[string]string)
-				for k, v := range data {
-					if str, ok := v.(string); ok {
-						fcmData[k] = str
-					}
-				}
-
-				// 🔍 LOGS CRITIQUES - Vérifier que conversationId est bien présent
+	// Récupérer les informations de l'expéditeur
+	sender, err := h.userRepo.FindByID(senderID)
+	if err != nil || sender == nil {
+		return
+	}
 
-				// Envoyer à tous les tokens du participant
-				for _, token := range fcmTokens {
-					err := h.fcmService.SendToToken(token.Token, title, body, fcmData)
-					if err != nil {
-					} else {
-					}
-				}
-			} else {
-			}
+	// Trouver les autres participants (par email)
+	var recipients []string
+	for _, participant := range conversation.Participants {
+		if participant.UserID == senderID {
+			continue
 		}
+		participantUser, err := h.userRepo.FindByID(participant.UserID)
+		if err != nil || participantUser == nil {
+			continue
+		}
+		recipients = append(recipients, participantUser.Email)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	// Format ultra simple : Juste le nom et le message
+	title := sender.Firstname + " " + strings.ToUpper(sender.Lastname)
+	body := models.ContentPreview(message.Content, message.Attachments)
+	if len(body) > 240 {
+		body = body[:240] // Limiter à 240 caractères
 	}
+
+	h.enqueueNotification(&models.OutboxMessage{
+		IdempotencyKey: "chat_message:" + message.ID.Hex(),
+		Category:       models.NotificationCategoryChat,
+		ConversationID: conversation.ID.Hex(),
+		Recipients:     recipients,
+		Title:          title,
+		Body:           body,
+		Data: map[string]string{
+			"type":           "chat_message",
+			"conversationId": conversation.ID.Hex(),
+			"messageId":      message.ID.Hex(),
+			"senderId":       senderID.Hex(),
+			"senderName":     sender.Firstname + " " + sender.Lastname,
+		},
+	})
 }
 
-// sendInvitationNotification envoie une notification pour une invitation
+// sendInvitationNotification envoie une notification pour une invitation (via l'outbox)
 func (h *ChatHandler) sendInvitationNotification(invitation *models.ChatInvitation, fromUser *models.User) {
-	if h.fcmService == nil {
+	if h.outbox == nil {
 		return
 	}
 
-	// Format ultra simple : Juste le nom et un message court
-	title := fromUser.Firstname + " " + strings.ToUpper(fromUser.Lastname)
-	body := "Vous invite à discuter"
-	data := map[string]interface{}{
-		"type":         "chat_invitation",
-		"invitationId": invitation.ID.Hex(),
-		"fromUserId":   fromUser.ID.Hex(),
-		"fromUserName": fromUser.Firstname + " " + fromUser.Lastname,
-	}
-
 	// Récupérer l'utilisateur destinataire pour obtenir son email
 	toUser, err := h.userRepo.FindByID(invitation.ToUserID)
-	if err != nil {
+	if err != nil || toUser == nil {
 		return
 	}
 
-	// Récupérer les tokens FCM du destinataire (par email)
-	fcmTokens, err := h.fcmTokenRepo.FindByUserID(toUser.Email)
-	if err == nil && len(fcmTokens) > 0 {
-		// Convertir les données en map[string]string pour FCM
-		fcmData := make(map[string]string)
-		for k, v := range data {
-			if str, ok := v.(string); ok {
-				fcmData[k] = str
-			}
-		}
-		// Envoyer à tous les tokens du destinataire
-		for _, token := range fcmTokens {
-			h.fcmService.SendToToken(token.Token, title, body, fcmData)
-		}
-	}
+	// Format ultra simple : Juste le nom et un message court
+	h.enqueueNotification(&models.OutboxMessage{
+		IdempotencyKey: "chat_invitation:" + invitation.ID.Hex(),
+		Category:       models.NotificationCategoryGeneral,
+		Recipients:     []string{toUser.Email},
+		Title:          fromUser.Firstname + " " + strings.ToUpper(fromUser.Lastname),
+		Body:           "Vous invite à discuter",
+		Data: map[string]string{
+			"type":         "chat_invitation",
+			"invitationId": invitation.ID.Hex(),
+			"fromUserId":   fromUser.ID.Hex(),
+			"fromUserName": fromUser.Firstname + " " + fromUser.Lastname,
+		},
+	})
 }
 
-// sendAcceptedInvitationNotification envoie une notification quand une invitation est acceptée
+// sendAcceptedInvitationNotification envoie une notification quand une invitation est acceptée (via l'outbox)
 func (h *ChatHandler) sendAcceptedInvitationNotification(invitation *models.ChatInvitation, acceptedByUser *models.User) {
-	if h.fcmService == nil {
+	if h.outbox == nil {
 		return
 	}
 
-	// Format ultra simple : Juste le nom et un message court
-	title := acceptedByUser.Firstname + " " + strings.ToUpper(acceptedByUser.Lastname)
-	body := "A accepté votre invitation"
-	data := map[string]interface{}{
-		"type":           "chat_invitation_accepted",
-		"invitationId":   invitation.ID.Hex(),
-		"acceptedBy":     acceptedByUser.ID.Hex(),
-		"acceptedByName": acceptedByUser.Firstname + " " + acceptedByUser.Lastname,
-	}
-
 	// Récupérer l'utilisateur demandeur pour obtenir son email
 	fromUser, err := h.userRepo.FindByID(invitation.FromUserID)
-	if err != nil {
+	if err != nil || fromUser == nil {
 		return
 	}
 
-	// Récupérer les tokens FCM du demandeur (par email)
-	fcmTokens, err := h.fcmTokenRepo.FindByUserID(fromUser.Email)
-	if err == nil && len(fcmTokens) > 0 {
-		// Convertir les données en map[string]string pour FCM
-		fcmData := make(map[string]string)
-		for k, v := range data {
-			if str, ok := v.(string); ok {
-				fcmData[k] = str
-			}
-		}
-		// Envoyer à tous les tokens du demandeur
-		for _, token := range fcmTokens {
-			h.fcmService.SendToToken(token.Token, title, body, fcmData)
-		}
+	// Format ultra simple : Juste le nom et un message court
+	h.enqueueNotification(&models.OutboxMessage{
+		IdempotencyKey: "chat_invitation_accepted:" + invitation.ID.Hex(),
+		Category:       models.NotificationCategoryGeneral,
+		Recipients:     []string{fromUser.Email},
+		Title:          acceptedByUser.Firstname + " " + strings.ToUpper(acceptedByUser.Lastname),
+		Body:           "A accepté votre invitation",
+		Data: map[string]string{
+			"type":           "chat_invitation_accepted",
+			"invitationId":   invitation.ID.Hex(),
+			"acceptedBy":     acceptedByUser.ID.Hex(),
+			"acceptedByName": acceptedByUser.Firstname + " " + acceptedByUser.Lastname,
+		},
+	})
+}
+
+// enqueueNotification met une notification en file dans l'outbox
+func (h *ChatHandler) enqueueNotification(message *models.OutboxMessage) {
+	if _, err := h.outbox.Enqueue(message); err != nil {
+		log.Printf("❌ Erreur mise en file de la notification %s: %v", message.IdempotencyKey, err)
 	}
 }
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// editWindowExceeded est le message renvoyé une fois le délai de modification passé
+var editWindowExceeded = fmt.Sprintf("Délai de modification dépassé (%d minutes)", int(models.MessageEditWindow.Minutes()))
+
+// ====================================
+// Conversations privées
+// ====================================
+
+// EditMessage modifie un message de conversation (auteur uniquement, dans le délai autorisé)
+func (h *ChatHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	conversation, message, ok := h.loadOwnMessage(w, r)
+	if !ok {
+		return
+	}
+
+	var request models.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Body JSON invalide", http.StatusBadRequest)
+		return
+	}
+
+	content := strings.TrimSpace(request.Content)
+	if content == "" {
+		http.Error(w, "Contenu du message requis", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.chatRepo.EditMessage(r.Context(), message, content); err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "Message modifié ou supprimé entre-temps", http.StatusConflict)
+			return
+		}
+		log.Printf("❌ Erreur modification message: %v", err)
+		http.Error(w, "Erreur serveur", http.StatusInternalServerError)
+		return
+	}
+
+	// 🔌 Envoyer via WebSocket à tous les participants (y compris les autres appareils de l'auteur)
+	h.notifyParticipants(conversation, models.WSMessageEdited{
+		Type:           models.WSTypeMessageEdited,
+		ConversationID: conversation.ID.Hex(),
+		MessageID:      message.ID.Hex(),
+		Content:        message.Content,
+		EditedAt:       *message.EditedAt,
+	})
+
+	log.Printf("✓ Message %s modifié", message.ID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ChatResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"message": message,
+		},
+	})
+}
+
+// DeleteMessage supprime un message de conversation (auteur uniquement, dans le délai autorisé).
+// Le message reste dans la conversation sous forme de trace (deleted_at, contenu vide).
+func (h *ChatHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	conversation, message, ok := h.loadOwnMessage(w, r)
+	if !ok {
+		return
+	}
+
+	attachments := message.Attachments
+	if err := h.chatRepo.DeleteMessage(r.Context(), message); err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "Message déjà supprimé", http.StatusConflict)
+			return
+		}
+		log.Printf("❌ Erreur suppression message: %v", err)
+		http.Error(w, "Erreur serveur", http.StatusInternalServerError)
+		return
+	}
+	go deleteAttachments(h.storage, attachments)
+
+	// 🔌 Envoyer via WebSocket à tous les participants
+	h.notifyParticipants(conversation, models.WSMessageDeleted{
+		Type:           models.WSTypeMessageDeleted,
+		ConversationID: conversation.ID.Hex(),
+		MessageID:      message.ID.Hex(),
+		DeletedAt:      *message.DeletedAt,
+	})
+
+	log.Printf("✓ Message %s supprimé", message.ID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ChatResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"message": message,
+		},
+	})
+}
+
+// loadMessage charge le message ciblé par l'URL après avoir vérifié que l'utilisateur participe à la conversation
+func (h *ChatHandler) loadMessage(w http.ResponseWriter, r *http.Request) (*models.Conversation, *models.Message, primitive.ObjectID, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		http.Error(w, "Token invalide", http.StatusUnauthorized)
+		return nil, nil, primitive.NilObjectID, false
+	}
+
+	userID, err := h.getUserObjectID(claims.UserID)
+	if err != nil {
+		http.Error(w, "Utilisateur introuvable", http.StatusBadRequest)
+		return nil, nil, primitive.NilObjectID, false
+	}
+
+	vars := mux.Vars(r)
+	conversationID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		http.Error(w, "ID de conversation invalide", http.StatusBadRequest)
+		return nil, nil, primitive.NilObjectID, false
+	}
+	messageID, err := primitive.ObjectIDFromHex(vars["message_id"])
+	if err != nil {
+		http.Error(w, "ID de message invalide", http.StatusBadRequest)
+		return nil, nil, primitive.NilObjectID, false
+	}
+
+	conversation, err := h.chatRepo.GetConversationByID(r.Context(), conversationID)
+	if err != nil {
+		http.Error(w, "Conversation non trouvée", http.StatusNotFound)
+		return nil, nil, primitive.NilObjectID, false
+	}
+
+	isParticipant := false
+	for _, participant := range conversation.Participants {
+		if participant.UserID == userID {
+			isParticipant = true
+			break
+		}
+	}
+	if !isParticipant {
+		http.Error(w, "Accès refusé à cette conversation", http.StatusForbidden)
+		return nil, nil, primitive.NilObjectID, false
+	}
+
+	message, err := h.chatRepo.GetMessageByID(r.Context(), messageID)
+	if err != nil || message.ConversationID != conversationID {
+		http.Error(w, "Message non trouvé", http.StatusNotFound)
+		return nil, nil, primitive.NilObjectID, false
+	}
+
+	return conversation, message, userID, true
+}
+
+// loadOwnMessage charge le message ciblé par l'URL et vérifie que l'utilisateur peut le modifier :
+// participant de la conversation, auteur du message, message non supprimé et encore dans le délai
+func (h *ChatHandler) loadOwnMessage(w http.ResponseWriter, r *http.Request) (*models.Conversation, *models.Message, bool) {
+	conversation, message, userID, ok := h.loadMessage(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if message.SenderID != userID {
+		http.Error(w, "Vous ne pouvez modifier que vos propres messages", http.StatusForbidden)
+		return nil, nil, false
+	}
+	if message.DeletedAt != nil {
+		http.Error(w, "Message supprimé", http.StatusConflict)
+		return nil, nil, false
+	}
+	if !models.WithinEditWindow(message.CreatedAt) {
+		http.Error(w, editWindowExceeded, http.StatusForbidden)
+		return nil, nil, false
+	}
+
+	return conversation, message, true
+}
+
+// notifyParticipants envoie un événement WebSocket à tous les participants d'une conversation
+func (h *ChatHandler) notifyParticipants(conversation *models.Conversation, payload interface{}) {
+	if h.wsHub == nil {
+		return
+	}
+
+	for _, participant := range conversation.Participants {
+		// ⚠️  IMPORTANT: Utiliser EMAIL, pas ObjectID !
+		if participantUser, err := h.userRepo.FindByID(participant.UserID); err == nil && participantUser != nil {
+			h.wsHub.SendToUser(participantUser.Email, payload)
+		}
+	}
+}
+
+// ====================================
+// Groupes
+// ====================================
+
+// EditMessage modifie un message de groupe (auteur uniquement, dans le délai autorisé)
+func (h *ChatGroupHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	message, ok := h.loadOwnMessage(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Le contenu est requis")
+		return
+	}
+
+	// Les mentions suivent le nouveau contenu
+	members, err := h.groupRepo.GetMembers(message.GroupID)
+	if err != nil {
+		log.Printf("Erreur récupération membres: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if err := h.messageRepo.Edit(message, content, models.ParseMentions(content, members)); err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.RespondError(w, http.StatusConflict, "Message modifié ou supprimé entre-temps")
+			return
+		}
+		log.Printf("Erreur modification message: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	h.broadcastToMembers(message.GroupID, models.WSMessageEdited{
+		Type:      models.WSTypeMessageEdited,
+		GroupID:   message.GroupID.Hex(),
+		MessageID: message.ID.Hex(),
+		Content:   message.Content,
+		Mentions:  message.Mentions,
+		EditedAt:  *message.EditedAt,
+	})
+
+	log.Printf("✓ Message %s modifié dans le groupe %s", message.ID.Hex(), message.GroupID.Hex())
+	utils.RespondSuccess(w, "Message modifié", message)
+}
+
+// DeleteMessage supprime un message de groupe (auteur uniquement, dans le délai autorisé).
+// Le message reste dans le groupe sous forme de trace (deleted_at, contenu vide).
+func (h *ChatGroupHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	message, ok := h.loadOwnMessage(w, r)
+	if !ok {
+		return
+	}
+
+	attachments := message.Attachments
+	if err := h.messageRepo.SoftDelete(message); err != nil {
+		if err == mongo.ErrNoDocuments {
+			utils.RespondError(w, http.StatusConflict, "Message déjà supprimé")
+			return
+		}
+		log.Printf("Erreur suppression message: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	go deleteAttachments(h.storage, attachments)
+
+	h.broadcastToMembers(message.GroupID, models.WSMessageDeleted{
+		Type:      models.WSTypeMessageDeleted,
+		GroupID:   message.GroupID.Hex(),
+		MessageID: message.ID.Hex(),
+		DeletedAt: *message.DeletedAt,
+	})
+
+	log.Printf("✓ Message %s supprimé dans le groupe %s", message.ID.Hex(), message.GroupID.Hex())
+	utils.RespondSuccess(w, "Message supprimé", message)
+}
+
+// loadMessage charge le message ciblé par l'URL après avoir vérifié que l'utilisateur est membre du groupe.
+// Retourne aussi l'email normalisé de l'utilisateur.
+func (h *ChatGroupHandler) loadMessage(w http.ResponseWriter, r *http.Request) (*models.ChatGroupMessage, string, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return nil, "", false
+	}
+
+	vars := mux.Vars(r)
+	groupID, err := primitive.ObjectIDFromHex(vars["group_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de groupe invalide")
+		return nil, "", false
+	}
+	messageID, err := primitive.ObjectIDFromHex(vars["message_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de message invalide")
+		return nil, "", false
+	}
+
+	// Vérifier que l'utilisateur est membre (user_id en DB est un email)
+	isMember, err := h.groupRepo.IsMember(groupID, claims.Email)
+	if err != nil || !isMember {
+		utils.RespondError(w, http.StatusForbidden, "Vous n'êtes pas membre de ce groupe")
+		return nil, "", false
+	}
+
+	message, err := h.messageRepo.FindByID(messageID)
+	if err != nil {
+		log.Printf("Erreur récupération message: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, "", false
+	}
+	if message == nil || message.GroupID != groupID {
+		utils.RespondError(w, http.StatusNotFound, "Message non trouvé")
+		return nil, "", false
+	}
+
+	// Les messages sont enregistrés avec l'email normalisé
+	return message, strings.ToLower(strings.TrimSpace(claims.Email)), true
+}
+
+// loadOwnMessage charge le message ciblé par l'URL et vérifie que l'utilisateur peut le modifier :
+// membre du groupe, auteur du message, message non supprimé et encore dans le délai
+func (h *ChatGroupHandler) loadOwnMessage(w http.ResponseWriter, r *http.Request) (*models.ChatGroupMessage, bool) {
+	message, email, ok := h.loadMessage(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	// Les messages système ne sont à personne
+	if message.SenderID != email {
+		utils.RespondError(w, http.StatusForbidden, "Vous ne pouvez modifier que vos propres messages")
+		return nil, false
+	}
+	if message.DeletedAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Message supprimé")
+		return nil, false
+	}
+	if !models.WithinEditWindow(message.CreatedAt) {
+		utils.RespondError(w, http.StatusForbidden, editWindowExceeded)
+		return nil, false
+	}
+
+	return message, true
+}
+
+// broadcastToMembers envoie un événement WebSocket à tous les membres d'un groupe
+func (h *ChatGroupHandler) broadcastToMembers(groupID primitive.ObjectID, payload interface{}) {
+	if h.wsHub == nil {
+		return
+	}
+
+	members, err := h.groupRepo.GetMembers(groupID)
+	if err != nil {
+		log.Printf("Erreur récupération membres: %v", err)
+		return
+	}
+
+	// ✅ member.ID est l'email
+	for _, member := range members {
+		h.wsHub.SendToUser(member.ID, payload)
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// ====================================
+// Conversations privées
+// ====================================
+
+// AddReaction ajoute une réaction à un message de conversation
+func (h *ChatHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	conversation, message, userID, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	var request models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Body JSON invalide", http.StatusBadRequest)
+		return
+	}
+	if !models.ValidReactionEmoji(request.Emoji) {
+		http.Error(w, "Emoji invalide", http.StatusBadRequest)
+		return
+	}
+	if message.DeletedAt != nil {
+		http.Error(w, "Message supprimé", http.StatusConflict)
+		return
+	}
+
+	reaction := models.MessageReaction{
+		Emoji:     request.Emoji,
+		UserID:    userID.Hex(),
+		CreatedAt: time.Now(),
+	}
+	message, added, err := h.chatRepo.AddReaction(r.Context(), message.ID, reaction)
+	if err != nil {
+		log.Printf("❌ Erreur ajout réaction: %v", err)
+		http.Error(w, "Erreur serveur", http.StatusInternalServerError)
+		return
+	}
+	if message.DeletedAt != nil {
+		http.Error(w, "Message supprimé", http.StatusConflict)
+		return
+	}
+
+	// 🔌 Envoyer via WebSocket à tous les participants (rien si la réaction existait déjà)
+	if added {
+		h.notifyParticipants(conversation, models.WSReaction{
+			Type:           models.WSTypeReactionAdded,
+			ConversationID: conversation.ID.Hex(),
+			MessageID:      message.ID.Hex(),
+			Emoji:          reaction.Emoji,
+			UserID:         reaction.UserID,
+			Reactions:      reactionsOrEmpty(message.Summary),
+		})
+	}
+
+	h.respondReactions(w, message)
+}
+
+// RemoveReaction retire une réaction de l'utilisateur sur un message de conversation
+func (h *ChatHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	conversation, message, userID, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	emoji := mux.Vars(r)["emoji"]
+	if !models.ValidReactionEmoji(emoji) {
+		http.Error(w, "Emoji invalide", http.StatusBadRequest)
+		return
+	}
+
+	message, removed, err := h.chatRepo.RemoveReaction(r.Context(), message.ID, userID.Hex(), emoji)
+	if err != nil {
+		log.Printf("❌ Erreur suppression réaction: %v", err)
+		http.Error(w, "Erreur serveur", http.StatusInternalServerError)
+		return
+	}
+
+	// 🔌 Envoyer via WebSocket à tous les participants
+	if removed {
+		h.notifyParticipants(conversation, models.WSReaction{
+			Type:           models.WSTypeReactionRemoved,
+			ConversationID: conversation.ID.Hex(),
+			MessageID:      message.ID.Hex(),
+			Emoji:          emoji,
+			UserID:         userID.Hex(),
+			Reactions:      reactionsOrEmpty(message.Summary),
+		})
+	}
+
+	h.respondReactions(w, message)
+}
+
+// respondReactions renvoie le résumé des réactions d'un message
+func (h *ChatHandler) respondReactions(w http.ResponseWriter, message *models.Message) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ChatResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"message_id": message.ID.Hex(),
+			"reactions":  reactionsOrEmpty(message.Summary),
+		},
+	})
+}
+
+// ====================================
+// Groupes
+// ====================================
+
+// AddReaction ajoute une réaction à un message de groupe
+func (h *ChatGroupHandler) AddReaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	message, email, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+	if !models.ValidReactionEmoji(req.Emoji) {
+		utils.RespondError(w, http.StatusBadRequest, "Emoji invalide")
+		return
+	}
+	if message.DeletedAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Message supprimé")
+		return
+	}
+
+	reaction := models.MessageReaction{
+		Emoji:     req.Emoji,
+		UserID:    email,
+		CreatedAt: time.Now(),
+	}
+	message, added, err := h.messageRepo.AddReaction(message.ID, reaction)
+	if err != nil {
+		log.Printf("Erreur ajout réaction: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if message == nil || message.DeletedAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Message supprimé")
+		return
+	}
+
+	// 🔌 Diffuser via WebSocket à tous les membres du groupe
+	if added {
+		h.broadcastToMembers(message.GroupID, models.WSReaction{
+			Type:      models.WSTypeReactionAdded,
+			GroupID:   message.GroupID.Hex(),
+			MessageID: message.ID.Hex(),
+			Emoji:     reaction.Emoji,
+			UserID:    email,
+			Reactions: reactionsOrEmpty(message.Summary),
+		})
+	}
+
+	utils.RespondSuccess(w, "Réaction ajoutée", map[string]interface{}{
+		"message_id": message.ID.Hex(),
+		"reactions":  reactionsOrEmpty(message.Summary),
+	})
+}
+
+// RemoveReaction retire une réaction de l'utilisateur sur un message de groupe
+func (h *ChatGroupHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	message, email, ok := h.loadMessage(w, r)
+	if !ok {
+		return
+	}
+
+	emoji := mux.Vars(r)["emoji"]
+	if !models.ValidReactionEmoji(emoji) {
+		utils.RespondError(w, http.StatusBadRequest, "Emoji invalide")
+		return
+	}
+
+	message, removed, err := h.messageRepo.RemoveReaction(message.ID, email, emoji)
+	if err != nil {
+		log.Printf("Erreur suppression réaction: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if message == nil {
+		utils.RespondError(w, http.StatusNotFound, "Message non trouvé")
+		return
+	}
+
+	// 🔌 Diffuser via WebSocket à tous les membres du groupe
+	if removed {
+		h.broadcastToMembers(message.GroupID, models.WSReaction{
+			Type:      models.WSTypeReactionRemoved,
+			GroupID:   message.GroupID.Hex(),
+			MessageID: message.ID.Hex(),
+			Emoji:     emoji,
+			UserID:    email,
+			Reactions: reactionsOrEmpty(message.Summary),
+		})
+	}
+
+	utils.RespondSuccess(w, "Réaction retirée", map[string]interface{}{
+		"message_id": message.ID.Hex(),
+		"reactions":  reactionsOrEmpty(message.Summary),
+	})
+}
+
+// reactionsOrEmpty évite de renvoyer null quand un message n'a plus de réaction
+func reactionsOrEmpty(summary []models.ReactionSummary) []models.ReactionSummary {
+	if summary == nil {
+		return []models.ReactionSummary{}
+	}
+	return summary
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"premier-an-backend/database"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ChatSearchHandler gère la recherche dans l'historique du chat (conversations privées et groupes)
+type ChatSearchHandler struct {
+	chatRepo    *database.ChatRepository
+	groupRepo   *database.ChatGroupRepository
+	messageRepo *database.ChatGroupMessageRepository
+	userRepo    *database.UserRepository
+}
+
+// NewChatSearchHandler crée une nouvelle instance
+func NewChatSearchHandler(db *mongo.Database) *ChatSearchHandler {
+	return &ChatSearchHandler{
+		chatRepo:    database.NewChatRepository(db),
+		groupRepo:   database.NewChatGroupRepository(db),
+		messageRepo: database.NewChatGroupMessageRepository(db),
+		userRepo:    database.NewUserRepository(db),
+	}
+}
+
+// SearchMessages recherche des messages dans les conversations et les groupes de l'utilisateur.
+// Filtres : q (requis), scope (all, conversations, groups), conversation_id, group_id, sender (email),
+// from / to (date ou RFC3339), limit et cursor (next_cursor de la page précédente).
+func (h *ChatSearchHandler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.MessageSearchFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+		Limit: models.DefaultSearchLimit,
+	}
+	if utf8.RuneCountInString(filter.Query) < models.MinSearchLength {
+		utils.RespondError(w, http.StatusBadRequest, "Recherche trop courte (2 caractères minimum)")
+		return
+	}
+
+	if parsedLimit, err := strconv.Atoi(query.Get("limit")); err == nil && parsedLimit > 0 {
+		filter.Limit = parsedLimit
+		if filter.Limit > models.MaxSearchLimit {
+			filter.Limit = models.MaxSearchLimit
+		}
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		before, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Curseur invalide")
+			return
+		}
+		filter.Before = &before
+	}
+
+	var err error
+	if filter.From, err = parseSearchDate(query.Get("from"), false); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Date de début invalide (YYYY-MM-DD ou RFC3339)")
+		return
+	}
+	if filter.To, err = parseSearchDate(query.Get("to"), true); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Date de fin invalide (YYYY-MM-DD ou RFC3339)")
+		return
+	}
+
+	// Périmètre : une conversation, un groupe, ou tout l'historique de l'utilisateur
+	scope := query.Get("scope")
+	conversationID, groupID := query.Get("conversation_id"), query.Get("group_id")
+	switch {
+	case conversationID != "":
+		scope = "conversations"
+	case groupID != "":
+		scope = "groups"
+	case scope == "":
+		scope = "all"
+	}
+	if scope != "all" && scope != "conversations" && scope != "groups" {
+		utils.RespondError(w, http.StatusBadRequest, "Périmètre invalide (all, conversations ou groups)")
+		return
+	}
+
+	sender := strings.ToLower(strings.TrimSpace(query.Get("sender")))
+	var results []models.MessageSearchResult
+
+	if scope != "groups" {
+		conversationResults, ok := h.searchConversations(w, r, claims.Email, conversationID, sender, filter)
+		if !ok {
+			return
+		}
+		results = append(results, conversationResults...)
+	}
+
+	if scope != "conversations" {
+		groupResults, ok := h.searchGroups(w, claims.Email, groupID, sender, filter)
+		if !ok {
+			return
+		}
+		results = append(results, groupResults...)
+	}
+
+	// Fusion des deux sources, du plus récent au plus ancien (les ObjectID suivent l'ordre d'envoi)
+	sort.Slice(results, func(i, j int) bool {
+		return bytes.Compare(results[i].ID[:], results[j].ID[:]) > 0
+	})
+
+	response := map[string]interface{}{
+		"results":     results,
+		"has_more":    false,
+		"next_cursor": nil,
+	}
+	if len(results) > filter.Limit {
+		results = results[:filter.Limit]
+		response["results"] = results
+		response["has_more"] = true
+		response["next_cursor"] = results[len(results)-1].ID.Hex()
+	}
+	if results == nil {
+		response["results"] = []models.MessageSearchResult{}
+	}
+
+	utils.RespondSuccess(w, "Résultats de la recherche", response)
+}
+
+// searchConversations recherche dans les conversations privées de l'utilisateur
+func (h *ChatSearchHandler) searchConversations(w http.ResponseWriter, r *http.Request, email, conversationID, sender string, filter models.MessageSearchFilter) ([]models.MessageSearchResult, bool) {
+	user, err := h.userRepo.FindByEmail(email)
+	if err != nil || user == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur introuvable")
+		return nil, false
+	}
+
+	conversationIDs, err := h.chatRepo.GetConversationIDs(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ Erreur récupération conversations: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, false
+	}
+
+	if conversationID != "" {
+		id, err := primitive.ObjectIDFromHex(conversationID)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "ID de conversation invalide")
+			return nil, false
+		}
+		if !containsObjectID(conversationIDs, id) {
+			utils.RespondError(w, http.StatusForbidden, "Accès refusé à cette conversation")
+			return nil, false
+		}
+		conversationIDs = []primitive.ObjectID{id}
+	}
+
+	var senderID *primitive.ObjectID
+	if sender != "" {
+		senderUser, err := h.userRepo.FindByEmail(sender)
+		if err != nil || senderUser == nil {
+			return nil, true // Expéditeur inconnu : aucun résultat
+		}
+		senderID = &senderUser.ID
+	}
+
+	if len(conversationIDs) == 0 {
+		return nil, true
+	}
+
+	messages, err := h.chatRepo.SearchMessages(r.Context(), conversationIDs, senderID, filter)
+	if err != nil {
+		log.Printf("❌ Erreur recherche messages privés: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, false
+	}
+
+	terms := models.SearchTerms(filter.Query)
+	senders := make(map[primitive.ObjectID]*models.UserBasicInfo)
+	results := make([]models.MessageSearchResult, 0, len(messages))
+	for _, message := range messages {
+		info, cached := senders[message.SenderID]
+		if !cached {
+			if senderUser, err := h.userRepo.FindByID(message.SenderID); err == nil && senderUser != nil {
+				info = userBasicInfo(senderUser)
+			}
+			senders[message.SenderID] = info
+		}
+
+		results = append(results, models.MessageSearchResult{
+			ID:             message.ID,
+			Source:         models.SearchSourceConversation,
+			ConversationID: message.ConversationID.Hex(),
+			SenderID:       message.SenderID.Hex(),
+			Sender:         info,
+			Content:        message.Content,
+			Snippet:        models.HighlightSnippet(message.Content, terms),
+			Attachments:    message.Attachments,
+			CreatedAt:      message.CreatedAt,
+		})
+	}
+	return results, true
+}
+
+// searchGroups recherche dans les groupes actifs dont l'utilisateur est membre
+func (h *ChatSearchHandler) searchGroups(w http.ResponseWriter, email, groupID, sender string, filter models.MessageSearchFilter) ([]models.MessageSearchResult, bool) {
+	groups, err := h.groupRepo.FindGroupsByUserID(email)
+	if err != nil {
+		log.Printf("❌ Erreur récupération groupes: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, false
+	}
+
+	groupNames := make(map[primitive.ObjectID]string, len(groups))
+	groupIDs := make([]primitive.ObjectID, 0, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+		groupIDs = append(groupIDs, group.ID)
+	}
+
+	if groupID != "" {
+		id, err := primitive.ObjectIDFromHex(groupID)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "ID de groupe invalide")
+			return nil, false
+		}
+		if _, ok := groupNames[id]; !ok {
+			utils.RespondError(w, http.StatusForbidden, "Vous n'êtes pas membre de ce groupe")
+			return nil, false
+		}
+		groupIDs = []primitive.ObjectID{id}
+	}
+
+	if len(groupIDs) == 0 {
+		return nil, true
+	}
+
+	messages, err := h.messageRepo.Search(groupIDs, sender, filter)
+	if err != nil {
+		log.Printf("❌ Erreur recherche messages de groupe: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, false
+	}
+
+	terms := models.SearchTerms(filter.Query)
+	senders := make(map[string]*models.UserBasicInfo)
+	results := make([]models.MessageSearchResult, 0, len(messages))
+	for _, message := range messages {
+		info, cached := senders[message.SenderID]
+		if !cached {
+			if senderUser, err := h.userRepo.FindByEmail(message.SenderID); err == nil && senderUser != nil {
+				info = userBasicInfo(senderUser)
+			}
+			senders[message.SenderID] = info
+		}
+
+		results = append(results, models.MessageSearchResult{
+			ID:          message.ID,
+			Source:      models.SearchSourceGroup,
+			GroupID:     message.GroupID.Hex(),
+			GroupName:   groupNames[message.GroupID],
+			SenderID:    message.SenderID,
+			Sender:      info,
+			Content:     message.Content,
+			Snippet:     models.HighlightSnippet(message.Content, terms),
+			Attachments: message.Attachments,
+			CreatedAt:   message.CreatedAt,
+		})
+	}
+	return results, true
+}
+
+// parseSearchDate lit une date de filtre (YYYY-MM-DD ou RFC3339). Une date seule utilisée comme
+// borne de fin inclut toute la journée.
+func parseSearchDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return &date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// containsObjectID indique si un ID fait partie de la liste
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// userBasicInfo informations publiques d'un utilisateur (ID = email, comme dans les groupes)
+func userBasicInfo(user *models.User) *models.UserBasicInfo {
+	return &models.UserBasicInfo{
+		ID:              user.Email,
+		Firstname:       user.Firstname,
+		Lastname:        user.Lastname,
+		Email:           user.Email,
+		ProfilePicture:  user.ProfileImageURL,
+		ProfileImageURL: user.ProfileImageURL,
+	}
+}
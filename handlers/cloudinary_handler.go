@@ -1,50 +1,32 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"mime/multipart"
 	"net/http"
 	"premier-an-backend/database"
 	"premier-an-backend/middleware"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
 	"strings"
-	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // CloudinaryHandler gère les uploads vers Cloudinary
 type CloudinaryHandler struct {
-	userRepo      *database.UserRepository
-	cloudName     string
-	uploadPreset  string
-	apiKey        string
-	apiSecret     string
+	userRepo   *database.UserRepository
+	cloudinary *services.CloudinaryClient
 }
 
 // NewCloudinaryHandler crée une nouvelle instance
-func NewCloudinaryHandler(db *mongo.Database, cloudName, uploadPreset, apiKey, apiSecret string) *CloudinaryHandler {
+func NewCloudinaryHandler(db *mongo.Database, cloudinary *services.CloudinaryClient) *CloudinaryHandler {
 	return &CloudinaryHandler{
-		userRepo:     database.NewUserRepository(db),
-		cloudName:    cloudName,
-		uploadPreset: uploadPreset,
-		apiKey:       apiKey,
-		apiSecret:    apiSecret,
+		userRepo:   database.NewUserRepository(db),
+		cloudinary: cloudinary,
 	}
 }
 
-// CloudinaryUploadResponse représente la réponse de Cloudinary
-type CloudinaryUploadResponse struct {
-	PublicID  string `json:"public_id"`
-	SecureURL string `json:"secure_url"`
-	Format    string `json:"format"`
-	Bytes     int    `json:"bytes"`
-}
-
 // UploadProfileImage gère l'upload de la photo de profil
 func (h *CloudinaryHandler) UploadProfileImage(w http.ResponseWriter, r *http.Request) {
 	// Vérifier la méthode HTTP
@@ -121,13 +103,15 @@ func (h *CloudinaryHandler) UploadProfileImage(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Upload vers Cloudinary
-	cloudinaryURL, err := h.uploadToCloudinary(file, userEmail, header.Filename)
+	// Upload vers Cloudinary (un dossier par utilisateur)
+	folder := fmt.Sprintf("profiles/%s", strings.Replace(userEmail, "@", "_", -1))
+	uploaded, err := h.cloudinary.Upload(file, header.Filename, folder)
 	if err != nil {
 		log.Printf("Erreur upload Cloudinary: %v", err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de l'upload de l'image")
 		return
 	}
+	cloudinaryURL := uploaded.SecureURL
 
 	log.Printf("✅ Upload Cloudinary réussi: %s", cloudinaryURL)
 
@@ -169,76 +153,3 @@ func (h *CloudinaryHandler) UploadProfileImage(w http.ResponseWriter, r *http.Re
 		},
 	})
 }
-
-// uploadToCloudinary envoie le fichier vers Cloudinary
-func (h *CloudinaryHandler) uploadToCloudinary(file multipart.File, userEmail, filename string) (string, error) {
-	// Construire l'URL d'upload Cloudinary
-	uploadURL := fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/image/upload", h.cloudName)
-
-	// Créer un buffer pour le formulaire multipart
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Ajouter le fichier
-	part, err := writer.CreateFormFile("file", filename)
-	if err != nil {
-		return "", err
-	}
-
-	if _, err := io.Copy(part, file); err != nil {
-		return "", err
-	}
-
-	// Ajouter l'upload preset
-	if err := writer.WriteField("upload_preset", h.uploadPreset); err != nil {
-		return "", err
-	}
-
-	// Ajouter le dossier (organiser par utilisateur)
-	folder := fmt.Sprintf("profiles/%s", strings.Replace(userEmail, "@", "_", -1))
-	if err := writer.WriteField("folder", folder); err != nil {
-		return "", err
-	}
-
-	// Ajouter un timestamp pour éviter les doublons
-	timestamp := fmt.Sprintf("%d", time.Now().Unix())
-	if err := writer.WriteField("public_id", timestamp); err != nil {
-		return "", err
-	}
-
-	// Note: Les transformations (resize, qualité, etc.) doivent être configurées
-	// dans le Upload Preset sur Cloudinary pour les uploads Unsigned
-
-	writer.Close()
-
-	// Créer la requête HTTP
-	req, err := http.NewRequest("POST", uploadURL, body)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	// Envoyer la requête
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	// Lire la réponse
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("❌ Cloudinary error: %s", string(bodyBytes))
-		return "", fmt.Errorf("cloudinary returned status %d", resp.StatusCode)
-	}
-
-	var cloudinaryResp CloudinaryUploadResponse
-	if err := json.NewDecoder(resp.Body).Decode(&cloudinaryResp); err != nil {
-		return "", err
-	}
-
-	return cloudinaryResp.SecureURL, nil
-}
-
@@ -2,15 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"premier-an-backend/database"
 	"premier-an-backend/middleware"
 	"premier-an-backend/models"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
-	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -21,21 +19,17 @@ import (
 
 // EventTrailerHandler gère les trailers vidéo des événements
 type EventTrailerHandler struct {
-	eventRepo       *database.EventRepository
-	cloudName       string
-	uploadPreset    string
-	apiKey          string
-	apiSecret       string
+	eventRepo  *database.EventRepository
+	auditRepo  *database.AuditLogRepository
+	cloudinary *services.CloudinaryClient
 }
 
 // NewEventTrailerHandler crée une nouvelle instance
-func NewEventTrailerHandler(db *mongo.Database, cloudName, uploadPreset, apiKey, apiSecret string) *EventTrailerHandler {
+func NewEventTrailerHandler(db *mongo.Database, cloudinary *services.CloudinaryClient) *EventTrailerHandler {
 	return &EventTrailerHandler{
-		eventRepo:    database.NewEventRepository(db),
-		cloudName:    cloudName,
-		uploadPreset: uploadPreset,
-		apiKey:       apiKey,
-		apiSecret:    apiSecret,
+		eventRepo:  database.NewEventRepository(db),
+		auditRepo:  database.NewAuditLogRepository(db),
+		cloudinary: cloudinary,
 	}
 }
 
@@ -145,6 +139,10 @@ func (h *EventTrailerHandler) UploadTrailer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditTrailerUpload, "event", eventID,
+		map[string]interface{}{"trailer": event.Trailer},
+		map[string]interface{}{"trailer": trailer})
+
 	log.Printf("✅ Trailer ajouté à l'événement %s", eventID)
 
 	// Réponse
@@ -234,7 +232,7 @@ func (h *EventTrailerHandler) ReplaceTrailer(w http.ResponseWriter, r *http.Requ
 	log.Printf("✅ Nouveau trailer reçu: %s", newTrailer.URL)
 
 	// Supprimer l'ancienne vidéo de Cloudinary
-	if err := h.deleteVideoFromCloudinary(oldPublicID); err != nil {
+	if err := h.cloudinary.Destroy(oldPublicID, "video"); err != nil {
 		log.Printf("⚠️  Erreur suppression ancien trailer: %v (continuons quand même)", err)
 	} else {
 		log.Printf("✅ Ancien trailer supprimé de Cloudinary")
@@ -252,6 +250,10 @@ func (h *EventTrailerHandler) ReplaceTrailer(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditTrailerReplace, "event", eventID,
+		map[string]interface{}{"trailer": event.Trailer},
+		map[string]interface{}{"trailer": newTrailer})
+
 	log.Printf("✅ Trailer remplacé pour l'événement %s", eventID)
 
 	// Réponse
@@ -311,7 +313,7 @@ func (h *EventTrailerHandler) DeleteTrailer(w http.ResponseWriter, r *http.Reque
 	log.Printf("🗑️  Suppression trailer pour événement %s (public_id: %s)", eventID, publicID)
 
 	// Supprimer la vidéo de Cloudinary
-	if err := h.deleteVideoFromCloudinary(publicID); err != nil {
+	if err := h.cloudinary.Destroy(publicID, "video"); err != nil {
 		log.Printf("⚠️  Erreur suppression Cloudinary: %v (continuons quand même)", err)
 	} else {
 		log.Printf("✅ Trailer supprimé de Cloudinary")
@@ -331,6 +333,10 @@ func (h *EventTrailerHandler) DeleteTrailer(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditTrailerDelete, "event", eventID,
+		map[string]interface{}{"trailer": event.Trailer},
+		map[string]interface{}{"trailer": nil})
+
 	log.Printf("✅ Trailer supprimé de l'événement %s", eventID)
 
 	// Réponse
@@ -339,38 +345,3 @@ func (h *EventTrailerHandler) DeleteTrailer(w http.ResponseWriter, r *http.Reque
 		"message": "Trailer supprimé avec succès",
 	})
 }
-
-// deleteVideoFromCloudinary supprime une vidéo de Cloudinary
-func (h *EventTrailerHandler) deleteVideoFromCloudinary(publicID string) error {
-	// Construire l'URL de suppression
-	deleteURL := fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/video/destroy", h.cloudName)
-
-	// Créer les données du formulaire
-	data := fmt.Sprintf("public_id=%s&api_key=%s&timestamp=%d", publicID, h.apiKey, time.Now().Unix())
-
-	// Note: Pour une suppression complète, il faudrait signer la requête avec l'API secret
-	// Pour l'instant, on utilise une approche simplifiée
-
-	req, err := http.NewRequest("POST", deleteURL, strings.NewReader(data))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("⚠️  Cloudinary delete error: %s", string(bodyBytes))
-		return fmt.Errorf("cloudinary delete returned status %d", resp.StatusCode)
-	}
-
-	return nil
-}
-
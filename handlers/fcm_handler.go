@@ -124,11 +124,8 @@ func (h *FCMHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extraire les tokens
-	tokens := make([]string, len(allTokens))
-	for i, t := range allTokens {
-		tokens[i] = t.Token
-	}
+	// Extraire les tokens (sauf utilisateurs en sourdine ou en heures calmes)
+	tokens := h.fcmService.FilterTokens(allTokens, models.NotificationContext{Category: models.NotificationCategoryGeneral})
 
 	// Préparer le message
 	title := req.Title
@@ -195,11 +192,8 @@ func (h *FCMHandler) SendToUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extraire les tokens
-	tokens := make([]string, len(userTokens))
-	for i, t := range userTokens {
-		tokens[i] = t.Token
-	}
+	// Extraire les tokens (sauf utilisateurs en sourdine ou en heures calmes)
+	tokens := h.fcmService.FilterTokens(userTokens, models.NotificationContext{Category: models.NotificationCategoryGeneral})
 
 	// Préparer le message
 	title := req.Title
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"premier-an-backend/database"
 	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
 	"strings"
 
@@ -20,10 +22,7 @@ type GalleryNotificationHandler struct {
 	eventRepo       *database.EventRepository
 	userRepo        *database.UserRepository
 	inscriptionRepo *database.InscriptionRepository
-	fcmTokenRepo    *database.FCMTokenRepository
-	fcmService      interface {
-		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	}
+	outbox          *services.NotificationOutbox
 	cloudName       string
 	previewPreset   string
 }
@@ -31,17 +30,14 @@ type GalleryNotificationHandler struct {
 // NewGalleryNotificationHandler crée une nouvelle instance
 func NewGalleryNotificationHandler(
 	db *mongo.Database,
-	fcmService interface {
-		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	},
+	outbox *services.NotificationOutbox,
 	cloudName, previewPreset string,
 ) *GalleryNotificationHandler {
 	return &GalleryNotificationHandler{
 		eventRepo:       database.NewEventRepository(db),
 		userRepo:        database.NewUserRepository(db),
 		inscriptionRepo: database.NewInscriptionRepository(db),
-		fcmTokenRepo:    database.NewFCMTokenRepository(db),
-		fcmService:      fcmService,
+		outbox:          outbox,
 		cloudName:       cloudName,
 		previewPreset:   previewPreset,
 	}
@@ -143,27 +139,36 @@ func (h *GalleryNotificationHandler) SendGalleryNotification(w http.ResponseWrit
 		"action_url":  fmt.Sprintf("/galerie-event/%s", eventID),
 	}
 
-	// 6. Envoyer les notifications
-	successCount, failedCount, failedTokens := h.fcmService.SendToAll(participants, title, body, notificationData)
-
-	// 7. Nettoyer les tokens invalides
-	if len(failedTokens) > 0 {
-		go h.cleanupInvalidTokens(failedTokens)
+	// 6. Mettre les notifications en file (envoi et relances par l'outbox, préférences évaluées à l'envoi)
+	if h.outbox != nil {
+		_, err = h.outbox.Enqueue(&models.OutboxMessage{
+			IdempotencyKey: fmt.Sprintf("gallery_update:%s:%s", eventID, primitive.NewObjectID().Hex()),
+			Category:       models.NotificationCategoryGalleryUpload,
+			Recipients:     participants,
+			Title:          title,
+			Body:           body,
+			Data:           notificationData,
+		})
+		if err != nil {
+			log.Printf("❌ Erreur mise en file de la notification galerie: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de l'envoi des notifications")
+			return
+		}
 	}
 
-	log.Printf("📊 Notifications envoyées: %d succès, %d échecs", successCount, failedCount)
+	log.Printf("📊 Notification galerie mise en file pour %d participant(s)", len(participants))
 
-	// 8. Réponse
+	// 7. Réponse
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success":            true,
-		"notifications_sent": successCount,
-		"failed_count":       failedCount,
-		"message":            "Notifications envoyées avec succès",
+		"notifications_sent": len(participants),
+		"message":            "Notifications mises en file",
 		"preview_url":        previewURL,
 	})
 }
 
-// getEventParticipants récupère les participants d'un événement (exclut l'utilisateur qui a ajouté)
+// getEventParticipants récupère les emails des participants d'un événement (exclut l'utilisateur qui a ajouté).
+// Les préférences de galerie sont évaluées par l'outbox à chaque essai d'envoi.
 func (h *GalleryNotificationHandler) getEventParticipants(eventID primitive.ObjectID, excludeUserEmail string) ([]string, error) {
 	// Récupérer les inscriptions de l'événement
 	inscriptions, err := h.inscriptionRepo.FindByEventID(eventID)
@@ -171,29 +176,18 @@ func (h *GalleryNotificationHandler) getEventParticipants(eventID primitive.Obje
 		return nil, err
 	}
 
+	seen := make(map[string]bool)
 	var participants []string
 	for _, inscription := range inscriptions {
 		// Exclure l'utilisateur qui a ajouté les médias
-		if inscription.UserEmail == excludeUserEmail {
-			continue
-		}
-
-		// Récupérer les tokens FCM de l'utilisateur depuis la collection fcm_tokens
-		tokens, err := h.fcmTokenRepo.FindByUserID(inscription.UserEmail)
-		if err != nil {
-			log.Printf("⚠️  Erreur récupération tokens FCM pour %s: %v", inscription.UserEmail, err)
+		if inscription.UserEmail == excludeUserEmail || seen[inscription.UserEmail] {
 			continue
 		}
-
-		// Ajouter tous les tokens valides de cet utilisateur
-		for _, token := range tokens {
-			if token.Token != "" {
-				participants = append(participants, token.Token)
-			}
-		}
+		seen[inscription.UserEmail] = true
+		participants = append(participants, inscription.UserEmail)
 	}
 
-	log.Printf("📱 Participants trouvés: %d tokens pour l'événement %s", len(participants), eventID.Hex())
+	log.Printf("📱 Participants trouvés: %d pour l'événement %s", len(participants), eventID.Hex())
 	return participants, nil
 }
 
@@ -233,15 +227,6 @@ func (h *GalleryNotificationHandler) buildNotificationMessage(userName string, m
 	return fmt.Sprintf("%s a ajouté %d médias dans la galerie %s", userName, mediaCount, eventTitle)
 }
 
-// cleanupInvalidTokens nettoie les tokens FCM invalides
-func (h *GalleryNotificationHandler) cleanupInvalidTokens(failedTokens []string) {
-	for _, token := range failedTokens {
-		log.Printf("🧹 Nettoyage token invalide: %s", token)
-		// Ici on pourrait supprimer le token de la base de données
-		// Pour l'instant on log juste
-	}
-}
-
 // TestGalleryNotification endpoint de test pour les notifications
 func (h *GalleryNotificationHandler) TestGalleryNotification(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTicket retourne le billet (token à afficher en QR code) de l'utilisateur connecté
+func (h *InscriptionHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventID, err := primitive.ObjectIDFromHex(vars["event_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	userEmail := getUserEmailFromContext(r)
+	if userEmail == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	inscription, err := h.inscriptionRepo.FindByEventAndUser(eventID, userEmail)
+	if err != nil {
+		log.Printf("Erreur recherche inscription: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if inscription == nil {
+		utils.RespondError(w, http.StatusNotFound, "Aucune inscription pour cet événement")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":          true,
+		"inscription_id":   inscription.ID.Hex(),
+		"ticket_token":     utils.GenerateTicketToken(inscription.ID.Hex(), eventID.Hex(), h.jwtSecret),
+		"nombre_personnes": inscription.NombrePersonnes,
+		"checked_in_at":    inscription.CheckedInAt,
+	})
+}
+
+// Checkin valide un billet scanné à l'entrée (admin uniquement)
+func (h *InscriptionHandler) Checkin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventID, err := primitive.ObjectIDFromHex(vars["event_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	var req models.CheckinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		utils.RespondError(w, http.StatusBadRequest, "Token du billet requis")
+		return
+	}
+
+	// Vérifier la signature du billet
+	inscriptionHex, eventHex, err := utils.ValidateTicketToken(req.Token, h.jwtSecret)
+	if err != nil {
+		log.Printf("❌ Billet refusé: %v", err)
+		utils.RespondError(w, http.StatusUnauthorized, "Billet invalide")
+		return
+	}
+
+	if eventHex != eventID.Hex() {
+		utils.RespondError(w, http.StatusBadRequest, "Ce billet n'est pas valable pour cet événement")
+		return
+	}
+
+	inscriptionID, err := primitive.ObjectIDFromHex(inscriptionHex)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Billet invalide")
+		return
+	}
+
+	// Le billet d'une inscription supprimée n'est plus valable
+	inscription, err := h.inscriptionRepo.FindByID(inscriptionID)
+	if err != nil {
+		log.Printf("Erreur recherche inscription: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if inscription == nil || inscription.EventID != eventID {
+		utils.RespondError(w, http.StatusNotFound, "Inscription non trouvée")
+		return
+	}
+
+	// Marquer la présence (refusé si le billet a déjà été scanné)
+	checkedIn, err := h.inscriptionRepo.CheckIn(inscriptionID)
+	if err != nil {
+		log.Printf("Erreur check-in: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if !checkedIn {
+		inscription, _ = h.inscriptionRepo.FindByID(inscriptionID)
+		var checkedInAt interface{}
+		if inscription != nil {
+			checkedInAt = inscription.CheckedInAt
+		}
+		utils.RespondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":         "Billet déjà scanné",
+			"checked_in_at": checkedInAt,
+		})
+		return
+	}
+
+	inscription, err = h.inscriptionRepo.FindByID(inscriptionID)
+	if err != nil || inscription == nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	userName := ""
+	user, err := h.userRepo.FindByEmail(inscription.UserEmail)
+	if err == nil && user != nil {
+		userName = fmt.Sprintf("%s %s", user.Firstname, user.Lastname)
+	}
+
+	log.Printf("✓ Check-in: %s (%d personnes)", inscription.UserEmail, inscription.NombrePersonnes)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":          true,
+		"message":          "Entrée validée",
+		"inscription_id":   inscription.ID.Hex(),
+		"user_email":       inscription.UserEmail,
+		"user_name":        userName,
+		"nombre_personnes": inscription.NombrePersonnes,
+		"accompagnants":    inscription.Accompagnants,
+		"checked_in_at":    inscription.CheckedInAt,
+	})
+}
+
+// GetCheckinStats retourne le nombre de personnes présentes (admin uniquement)
+func (h *InscriptionHandler) GetCheckinStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventID, err := primitive.ObjectIDFromHex(vars["event_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	event, err := h.eventRepo.FindByID(eventID)
+	if err != nil || event == nil {
+		utils.RespondError(w, http.StatusNotFound, "Événement non trouvé")
+		return
+	}
+
+	inscriptions, err := h.inscriptionRepo.FindByEvent(eventID)
+	if err != nil {
+		log.Printf("Erreur récupération inscriptions: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	totalPersonnes := 0
+	presents := 0
+	billetsScannes := 0
+	for _, insc := range inscriptions {
+		totalPersonnes += insc.NombrePersonnes
+		if insc.CheckedInAt != nil {
+			billetsScannes++
+			presents++
+		}
+		for _, acc := range insc.Accompagnants {
+			if acc.CheckedInAt != nil {
+				presents++
+			}
+		}
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"event_id":        event.ID.Hex(),
+		"titre":           event.Titre,
+		"total_billets":   len(inscriptions),
+		"billets_scannes": billetsScannes,
+		"total_personnes": totalPersonnes,
+		"presents":        presents,
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"premier-an-backend/database"
 	"premier-an-backend/middleware"
 	"premier-an-backend/models"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,10 +23,12 @@ type InscriptionHandler struct {
 	eventRepo       *database.EventRepository
 	userRepo        *database.UserRepository
 	codeRepo        *database.CodeSoireeRepository
-	fcmService      interface {
-		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	}
-	fcmTokenRepo *database.FCMTokenRepository
+	outbox          *services.NotificationOutbox
+	waitlistRepo    *database.WaitlistRepository
+	waitlistService *services.WaitlistService
+	siteSettingRepo *database.SiteSettingRepository
+	auditRepo       *database.AuditLogRepository
+	jwtSecret       string
 }
 
 // EventWithInscription représente un événement avec les détails de l'inscription de l'utilisateur
@@ -41,16 +45,18 @@ type InscriptionDetails struct {
 }
 
 // NewInscriptionHandler crée une nouvelle instance
-func NewInscriptionHandler(db *mongo.Database, fcmService interface {
-	SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-}) *InscriptionHandler {
+func NewInscriptionHandler(db *mongo.Database, jwtSecret string, outbox *services.NotificationOutbox) *InscriptionHandler {
 	return &InscriptionHandler{
 		inscriptionRepo: database.NewInscriptionRepository(db),
 		eventRepo:       database.NewEventRepository(db),
 		userRepo:        database.NewUserRepository(db),
 		codeRepo:        database.NewCodeSoireeRepository(db),
-		fcmService:      fcmService,
-		fcmTokenRepo:    database.NewFCMTokenRepository(db),
+		outbox:          outbox,
+		waitlistRepo:    database.NewWaitlistRepository(db),
+		waitlistService: services.NewWaitlistService(db, outbox),
+		siteSettingRepo: database.NewSiteSettingRepository(db),
+		auditRepo:       database.NewAuditLogRepository(db),
+		jwtSecret:       jwtSecret,
 	}
 }
 
@@ -108,8 +114,8 @@ func (h *InscriptionHandler) CreateInscription(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Vérifier que l'événement est ouvert
-	if event.Statut != "ouvert" {
+	// Vérifier que l'événement est ouvert (un événement complet accepte la liste d'attente)
+	if event.Statut != "ouvert" && !(event.Statut == "complet" && req.ListeAttente) {
 		utils.RespondJSON(w, http.StatusBadRequest, map[string]interface{}{
 			"error":  "Les inscriptions sont fermées pour cet événement",
 			"statut": event.Statut,
@@ -117,6 +123,23 @@ func (h *InscriptionHandler) CreateInscription(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Vérifier l'adresse e-mail si les admins l'exigent
+	if !h.canRegisterUnverified(r) {
+		user, err := h.userRepo.FindByEmail(req.UserEmail)
+		if err != nil {
+			log.Printf("Erreur recherche utilisateur: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+			return
+		}
+		if user == nil || !user.EmailVerified {
+			utils.RespondJSON(w, http.StatusForbidden, map[string]interface{}{
+				"error":                       "Veuillez vérifier votre adresse e-mail avant de vous inscrire",
+				"email_verification_required": true,
+			})
+			return
+		}
+	}
+
 	// Vérifier que l'utilisateur n'est pas déjà inscrit
 	existingInscription, err := h.inscriptionRepo.FindByEventAndUser(eventID, req.UserEmail)
 	if err != nil {
@@ -132,11 +155,38 @@ func (h *InscriptionHandler) CreateInscription(w http.ResponseWriter, r *http.Re
 
 	// Vérifier les places disponibles
 	placesRestantes := event.Capacite - event.Inscrits
-	if req.NombrePersonnes > placesRestantes {
+	if req.NombrePersonnes > placesRestantes || event.Statut == "complet" {
+		if req.ListeAttente {
+			h.joinWaitlist(w, event, &req)
+			return
+		}
+		utils.RespondJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":                    "Plus assez de places disponibles",
+			"places_restantes":         placesRestantes,
+			"demande":                  req.NombrePersonnes,
+			"liste_attente_disponible": true,
+		})
+		return
+	}
+
+	// Réserver les places (contrôle de capacité atomique)
+	reserved, err := h.eventRepo.ReservePlaces(eventID, req.NombrePersonnes)
+	if err != nil {
+		log.Printf("Erreur réservation places: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if !reserved {
+		// Les places ont été prises entre-temps
+		if req.ListeAttente {
+			h.joinWaitlist(w, event, &req)
+			return
+		}
 		utils.RespondJSON(w, http.StatusBadRequest, map[string]interface{}{
-			"error":            "Plus assez de places disponibles",
-			"places_restantes": placesRestantes,
-			"demande":          req.NombrePersonnes,
+			"error":                    "Plus assez de places disponibles",
+			"demande":                  req.NombrePersonnes,
+			"liste_attente_disponible": true,
 		})
 		return
 	}
@@ -151,31 +201,28 @@ func (h *InscriptionHandler) CreateInscription(w http.ResponseWriter, r *http.Re
 
 	if err := h.inscriptionRepo.Create(inscription); err != nil {
 		log.Printf("Erreur création inscription: %v", err)
+		// Rendre les places réservées
+		if err := h.eventRepo.ReleasePlaces(eventID, req.NombrePersonnes); err != nil {
+			log.Printf("Erreur libération places: %v", err)
+		}
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la création de l'inscription")
 		return
 	}
 
-	// Mettre à jour le compteur d'inscrits
-	newInscrits := event.Inscrits + req.NombrePersonnes
-	if err := h.eventRepo.Update(eventID, map[string]interface{}{
-		"inscrits": newInscrits,
-	}); err != nil {
-		log.Printf("Erreur mise à jour compteur: %v", err)
-	}
-
 	// Recharger l'événement pour avoir les données à jour
 	event, _ = h.eventRepo.FindByID(eventID)
 
 	log.Printf("✓ Nouvelle inscription: %s à l'événement %s (%d personnes)", req.UserEmail, event.Titre, req.NombrePersonnes)
 
-	// Notifier les admins
-	go h.notifyAdminsNewInscription(req.UserEmail, event, req.NombrePersonnes)
+	// Notifier les admins (via l'outbox : envoi garanti avec relances)
+	h.notifyAdminsNewInscription(inscription.ID, req.UserEmail, event, req.NombrePersonnes)
 
 	// Réponse conforme à la spécification (pas de wrapper "data")
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success":        true,
 		"message":        "Inscription confirmée",
 		"inscription_id": inscription.ID.Hex(),
+		"ticket_token":   utils.GenerateTicketToken(inscription.ID.Hex(), eventID.Hex(), h.jwtSecret),
 	})
 }
 
@@ -289,6 +336,12 @@ func (h *InscriptionHandler) UpdateInscription(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Billet déjà scanné : la liste des accompagnants (et leur présence) ne peut plus changer
+	if inscription.CheckedInAt != nil {
+		utils.RespondError(w, http.StatusConflict, "Billet déjà scanné à l'entrée : l'inscription ne peut plus être modifiée")
+		return
+	}
+
 	// Récupérer l'événement
 	event, err := h.eventRepo.FindByID(eventID)
 	if err != nil || event == nil {
@@ -310,13 +363,18 @@ func (h *InscriptionHandler) UpdateInscription(w http.ResponseWriter, r *http.Re
 	nouveauNombre := req.NombrePersonnes
 	difference := nouveauNombre - ancienNombre
 
-	// Si augmentation, vérifier les places disponibles
+	// Si augmentation, réserver les places supplémentaires (contrôle atomique)
 	if difference > 0 {
-		placesRestantes := event.Capacite - event.Inscrits
-		if difference > placesRestantes {
+		reserved, err := h.eventRepo.ReservePlaces(eventID, difference)
+		if err != nil {
+			log.Printf("Erreur réservation places: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+			return
+		}
+		if !reserved {
 			utils.RespondJSON(w, http.StatusBadRequest, map[string]interface{}{
 				"error":                 "Plus assez de places pour cette modification",
-				"places_restantes":      placesRestantes,
+				"places_restantes":      event.Capacite - event.Inscrits,
 				"augmentation_demandee": difference,
 			})
 			return
@@ -329,16 +387,21 @@ func (h *InscriptionHandler) UpdateInscription(w http.ResponseWriter, r *http.Re
 
 	if err := h.inscriptionRepo.Update(inscription); err != nil {
 		log.Printf("Erreur mise à jour inscription: %v", err)
+		if difference > 0 {
+			if err := h.eventRepo.ReleasePlaces(eventID, difference); err != nil {
+				log.Printf("Erreur libération places: %v", err)
+			}
+		}
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la modification")
 		return
 	}
 
-	// Mettre à jour le compteur d'inscrits
-	newInscrits := event.Inscrits + difference
-	if err := h.eventRepo.Update(eventID, map[string]interface{}{
-		"inscrits": newInscrits,
-	}); err != nil {
-		log.Printf("Erreur mise à jour compteur: %v", err)
+	// Libérer les places en cas de diminution
+	if difference < 0 {
+		if err := h.eventRepo.ReleasePlaces(eventID, -difference); err != nil {
+			log.Printf("Erreur mise à jour compteur: %v", err)
+		}
+		go h.waitlistService.PromoteFromWaitlist(eventID)
 	}
 
 	// Recharger l'événement
@@ -407,22 +470,18 @@ func (h *InscriptionHandler) DeleteInscription(w http.ResponseWriter, r *http.Re
 	}
 
 	// Mettre à jour le compteur d'inscrits
-	event, _ := h.eventRepo.FindByID(eventID)
-	if event != nil {
-		newInscrits := event.Inscrits - nombrePersonnes
-		if newInscrits < 0 {
-			newInscrits = 0
-		}
-		h.eventRepo.Update(eventID, map[string]interface{}{
-			"inscrits": newInscrits,
-		})
-
-		// Recharger l'événement
-		event, _ = h.eventRepo.FindByID(eventID)
+	if err := h.eventRepo.ReleasePlaces(eventID, nombrePersonnes); err != nil {
+		log.Printf("Erreur mise à jour compteur: %v", err)
 	}
 
+	// Recharger l'événement
+	event, _ := h.eventRepo.FindByID(eventID)
+
 	log.Printf("✓ Désinscription: %s (%d personnes libérées)", req.UserEmail, nombrePersonnes)
 
+	// Promouvoir les personnes en liste d'attente
+	go h.waitlistService.PromoteFromWaitlist(eventID)
+
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"message":                  "Désinscription réussie",
 		"nombre_personnes_liberes": nombrePersonnes,
@@ -502,6 +561,7 @@ func (h *InscriptionHandler) GetInscrits(w http.ResponseWriter, r *http.Request)
 			UserPhone:       userPhone,
 			NombrePersonnes: insc.NombrePersonnes,
 			Accompagnants:   insc.Accompagnants,
+			CheckedInAt:     insc.CheckedInAt,
 			CreatedAt:       insc.CreatedAt,
 			UpdatedAt:       insc.UpdatedAt,
 		})
@@ -518,6 +578,17 @@ func (h *InscriptionHandler) GetInscrits(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// canRegisterUnverified indique si les comptes à l'e-mail non vérifié peuvent s'inscrire
+func (h *InscriptionHandler) canRegisterUnverified(r *http.Request) bool {
+	value, err := h.siteSettingRepo.GetSetting(r.Context(), models.SettingAllowUnverifiedInscriptions, "true")
+	if err != nil {
+		log.Printf("Erreur lecture paramètre: %v", err)
+		return true
+	}
+	allowed, err := strconv.ParseBool(value)
+	return err != nil || allowed
+}
+
 // Helper pour vérifier si l'utilisateur est authentifié
 func getUserEmailFromContext(r *http.Request) string {
 	claims := middleware.GetUserFromContext(r.Context())
@@ -577,22 +648,20 @@ func (h *InscriptionHandler) DeleteInscriptionAdmin(w http.ResponseWriter, r *ht
 	}
 
 	// Mettre à jour le compteur d'inscrits
-	event, _ := h.eventRepo.FindByID(eventID)
-	if event != nil {
-		newInscrits := event.Inscrits - nombrePersonnes
-		if newInscrits < 0 {
-			newInscrits = 0
-		}
-		h.eventRepo.Update(eventID, map[string]interface{}{
-			"inscrits": newInscrits,
-		})
-
-		// Recharger l'événement
-		event, _ = h.eventRepo.FindByID(eventID)
+	if err := h.eventRepo.ReleasePlaces(eventID, nombrePersonnes); err != nil {
+		log.Printf("Erreur mise à jour compteur: %v", err)
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditInscriptionDelete, "inscription", inscriptionID.Hex(), inscription, nil)
+
+	// Recharger l'événement
+	event, _ := h.eventRepo.FindByID(eventID)
+
 	log.Printf("✓ Inscription supprimée par admin: %s (%d personnes)", inscription.UserEmail, nombrePersonnes)
 
+	// Promouvoir les personnes en liste d'attente
+	go h.waitlistService.PromoteFromWaitlist(eventID)
+
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"message":                  "Inscription supprimée avec succès",
 		"inscription_id":           inscriptionID.Hex(),
@@ -659,6 +728,9 @@ func (h *InscriptionHandler) DeleteAccompagnant(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// Instantané avant modification pour le journal d'audit
+	before := auditSnapshot(inscription)
+
 	// Récupérer le nom de l'accompagnant avant suppression
 	accompagnantName := fmt.Sprintf("%s %s", inscription.Accompagnants[index].Firstname, inscription.Accompagnants[index].Lastname)
 
@@ -679,22 +751,20 @@ func (h *InscriptionHandler) DeleteAccompagnant(w http.ResponseWriter, r *http.R
 	}
 
 	// Mettre à jour le compteur de l'événement
-	event, _ := h.eventRepo.FindByID(eventID)
-	if event != nil {
-		newInscrits := event.Inscrits - 1
-		if newInscrits < 0 {
-			newInscrits = 0
-		}
-		h.eventRepo.Update(eventID, map[string]interface{}{
-			"inscrits": newInscrits,
-		})
-
-		// Recharger l'événement
-		event, _ = h.eventRepo.FindByID(eventID)
+	if err := h.eventRepo.ReleasePlaces(eventID, 1); err != nil {
+		log.Printf("Erreur mise à jour compteur: %v", err)
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditAccompagnantDelete, "inscription", inscriptionID.Hex(), before, inscription)
+
+	// Recharger l'événement
+	event, _ := h.eventRepo.FindByID(eventID)
+
 	log.Printf("✓ Accompagnant supprimé par admin: %s de l'inscription %s", accompagnantName, inscription.UserEmail)
 
+	// Promouvoir les personnes en liste d'attente
+	go h.waitlistService.PromoteFromWaitlist(eventID)
+
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"message":     "Accompagnant supprimé avec succès",
 		"inscription": inscription,
@@ -705,9 +775,9 @@ func (h *InscriptionHandler) DeleteAccompagnant(w http.ResponseWriter, r *http.R
 	})
 }
 
-// notifyAdminsNewInscription envoie une notification aux admins lors d'une nouvelle inscription
-func (h *InscriptionHandler) notifyAdminsNewInscription(userEmail string, event *models.Event, nombrePersonnes int) {
-	if h.fcmService == nil {
+// notifyAdminsNewInscription place dans l'outbox la notification des admins pour une nouvelle inscription
+func (h *InscriptionHandler) notifyAdminsNewInscription(inscriptionID primitive.ObjectID, userEmail string, event *models.Event, nombrePersonnes int) {
+	if h.outbox == nil {
 		return
 	}
 
@@ -730,21 +800,10 @@ func (h *InscriptionHandler) notifyAdminsNewInscription(userEmail string, event
 		return
 	}
 
-	// Récupérer les tokens FCM des admins
-	var adminTokens []string
+	// Les tokens (et les préférences d'inscription) sont résolus à chaque essai d'envoi
+	recipients := make([]string, 0, len(admins))
 	for _, admin := range admins {
-		tokens, err := h.fcmTokenRepo.FindByUserID(admin.Email)
-		if err != nil {
-			continue
-		}
-		for _, t := range tokens {
-			adminTokens = append(adminTokens, t.Token)
-		}
-	}
-
-	if len(adminTokens) == 0 {
-		log.Println("⚠️  Aucun token FCM pour les admins")
-		return
+		recipients = append(recipients, admin.Email)
 	}
 
 	// Préparer la notification
@@ -769,9 +828,19 @@ func (h *InscriptionHandler) notifyAdminsNewInscription(userEmail string, event
 		"capacite":         fmt.Sprintf("%d", event.Capacite),
 	}
 
-	// Envoyer aux admins
-	success, failed, _ := h.fcmService.SendToAll(adminTokens, title, message, data)
-	log.Printf("📧 Notification inscription envoyée aux admins: %d succès, %d échecs", success, failed)
+	_, err = h.outbox.Enqueue(&models.OutboxMessage{
+		IdempotencyKey: "new_inscription:" + inscriptionID.Hex(),
+		Category:       models.NotificationCategoryNewInscription,
+		Recipients:     recipients,
+		Title:          title,
+		Body:           message,
+		Data:           data,
+	})
+	if err != nil {
+		log.Printf("❌ Erreur mise en file de la notification inscription: %v", err)
+		return
+	}
+	log.Printf("📧 Notification inscription mise en file pour %d admin(s)", len(recipients))
 }
 
 // GetMesEvenements retourne la liste des événements auxquels l'utilisateur est inscrit
@@ -870,24 +939,24 @@ func (h *InscriptionHandler) VerifyCode(w http.ResponseWriter, r *http.Request)
 	log.Printf("🔍 Vérification du code: %s", req.CodeSoiree)
 
 	// Vérifier si le code existe et est valide
-	isValid, err := h.codeRepo.IsCodeValid(req.CodeSoiree)
+	_, reason, err := h.codeRepo.Validate(req.CodeSoiree)
 	if err != nil {
 		log.Printf("❌ Erreur vérification code: %v", err)
 		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la vérification du code")
 		return
 	}
 
-	if isValid {
+	if reason == "" {
 		log.Printf("✅ Code valide: %s", req.CodeSoiree)
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"valid":   true,
 			"message": "Code d'accès valide",
 		})
 	} else {
-		log.Printf("❌ Code invalide: %s", req.CodeSoiree)
+		log.Printf("❌ Code invalide: %s (%s)", req.CodeSoiree, reason)
 		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 			"valid":   false,
-			"message": "Code d'accès invalide ou inexistant",
+			"message": reason,
 		})
 	}
 }
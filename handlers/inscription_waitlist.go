@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// joinWaitlist ajoute la demande en liste d'attente quand l'événement est complet
+func (h *InscriptionHandler) joinWaitlist(w http.ResponseWriter, event *models.Event, req *models.CreateInscriptionRequest) {
+	// Vérifier que l'utilisateur n'est pas déjà en liste d'attente
+	existing, err := h.waitlistRepo.FindPendingByEventAndUser(event.ID, req.UserEmail)
+	if err != nil {
+		log.Printf("Erreur vérification liste d'attente: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if existing != nil {
+		utils.RespondError(w, http.StatusConflict, "Vous êtes déjà sur la liste d'attente de cet événement")
+		return
+	}
+
+	// La demande ne pourra jamais être satisfaite
+	if req.NombrePersonnes > event.Capacite {
+		utils.RespondJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":    "La demande dépasse la capacité de l'événement",
+			"capacite": event.Capacite,
+			"demande":  req.NombrePersonnes,
+		})
+		return
+	}
+
+	entry := &models.WaitlistEntry{
+		EventID:         event.ID,
+		UserEmail:       req.UserEmail,
+		NombrePersonnes: req.NombrePersonnes,
+		Accompagnants:   req.Accompagnants,
+	}
+
+	if err := h.waitlistRepo.Create(entry); err != nil {
+		log.Printf("Erreur ajout liste d'attente: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de l'ajout en liste d'attente")
+		return
+	}
+
+	position, err := h.waitlistRepo.GetPosition(entry)
+	if err != nil {
+		log.Printf("Erreur calcul position: %v", err)
+	}
+
+	log.Printf("✓ Liste d'attente: %s pour l'événement %s (%d personnes, position %d)", req.UserEmail, event.Titre, req.NombrePersonnes, position)
+
+	utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"success":          true,
+		"message":          "Ajouté à la liste d'attente",
+		"liste_attente_id": entry.ID.Hex(),
+		"position":         position,
+	})
+}
+
+// GetMaListeAttente retourne la position de l'utilisateur dans la liste d'attente
+func (h *InscriptionHandler) GetMaListeAttente(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventID, err := primitive.ObjectIDFromHex(vars["event_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	userEmail := getUserEmailFromContext(r)
+	if userEmail == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	entry, err := h.waitlistRepo.FindPendingByEventAndUser(eventID, userEmail)
+	if err != nil {
+		log.Printf("Erreur recherche liste d'attente: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if entry == nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success":       true,
+			"liste_attente": nil,
+		})
+		return
+	}
+
+	position, err := h.waitlistRepo.GetPosition(entry)
+	if err != nil {
+		log.Printf("Erreur calcul position: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"liste_attente": map[string]interface{}{
+			"id":               entry.ID.Hex(),
+			"position":         position,
+			"nombre_personnes": entry.NombrePersonnes,
+			"created_at":       entry.CreatedAt,
+		},
+	})
+}
+
+// LeaveListeAttente retire l'utilisateur de la liste d'attente
+func (h *InscriptionHandler) LeaveListeAttente(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventID, err := primitive.ObjectIDFromHex(vars["event_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	userEmail := getUserEmailFromContext(r)
+	if userEmail == "" {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	entry, err := h.waitlistRepo.FindPendingByEventAndUser(eventID, userEmail)
+	if err != nil {
+		log.Printf("Erreur recherche liste d'attente: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	if entry == nil {
+		utils.RespondError(w, http.StatusNotFound, "Vous n'êtes pas sur la liste d'attente")
+		return
+	}
+
+	if err := h.waitlistRepo.DeleteByID(entry.ID); err != nil {
+		log.Printf("Erreur suppression liste d'attente: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors du retrait de la liste d'attente")
+		return
+	}
+
+	log.Printf("✓ Retrait liste d'attente: %s", userEmail)
+
+	// Une demande plus petite placée derrière peut maintenant passer
+	go h.waitlistService.PromoteFromWaitlist(eventID)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Retiré de la liste d'attente",
+	})
+}
+
+// GetListeAttente retourne la liste d'attente complète d'un événement (admin uniquement)
+func (h *InscriptionHandler) GetListeAttente(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	vars := mux.Vars(r)
+	eventID, err := primitive.ObjectIDFromHex(vars["event_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+		return
+	}
+
+	event, err := h.eventRepo.FindByID(eventID)
+	if err != nil || event == nil {
+		utils.RespondError(w, http.StatusNotFound, "Événement non trouvé")
+		return
+	}
+
+	entries, err := h.waitlistRepo.FindPendingByEvent(eventID)
+	if err != nil {
+		log.Printf("Erreur récupération liste d'attente: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	// Enrichir avec les infos utilisateur
+	entriesWithInfo := []models.WaitlistEntryWithUserInfo{}
+	totalPersonnes := 0
+
+	for i, entry := range entries {
+		user, err := h.userRepo.FindByEmail(entry.UserEmail)
+		userName := ""
+		userPhone := ""
+		if err == nil && user != nil {
+			userName = fmt.Sprintf("%s %s", user.Firstname, user.Lastname)
+			userPhone = user.Phone
+		}
+
+		totalPersonnes += entry.NombrePersonnes
+
+		entriesWithInfo = append(entriesWithInfo, models.WaitlistEntryWithUserInfo{
+			ID:              entry.ID.Hex(),
+			Position:        i + 1,
+			UserEmail:       entry.UserEmail,
+			UserName:        userName,
+			UserPhone:       userPhone,
+			NombrePersonnes: entry.NombrePersonnes,
+			Accompagnants:   entry.Accompagnants,
+			CreatedAt:       entry.CreatedAt,
+		})
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"event_id":        event.ID.Hex(),
+		"titre":           event.Titre,
+		"total_demandes":  len(entries),
+		"total_personnes": totalPersonnes,
+		"liste_attente":   entriesWithInfo,
+	})
+}
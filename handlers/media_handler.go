@@ -8,6 +8,7 @@ import (
 	"premier-an-backend/database"
 	"premier-an-backend/middleware"
 	"premier-an-backend/models"
+	"premier-an-backend/services"
 	"premier-an-backend/utils"
 	"strings"
 
@@ -22,20 +23,15 @@ type MediaHandler struct {
 	eventRepo       *database.EventRepository
 	userRepo        *database.UserRepository
 	inscriptionRepo *database.InscriptionRepository
-	fcmTokenRepo    *database.FCMTokenRepository
-	fcmService      interface {
-		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	}
-	cloudName     string
-	previewPreset string
+	outbox          *services.NotificationOutbox
+	cloudName       string
+	previewPreset   string
 }
 
 // NewMediaHandler crée une nouvelle instance
 func NewMediaHandler(
 	db *mongo.Database,
-	fcmService interface {
-		SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string)
-	},
+	outbox *services.NotificationOutbox,
 	cloudName, previewPreset string,
 ) *MediaHandler {
 	return &MediaHandler{
@@ -43,8 +39,7 @@ func NewMediaHandler(
 		eventRepo:       database.NewEventRepository(db),
 		userRepo:        database.NewUserRepository(db),
 		inscriptionRepo: database.NewInscriptionRepository(db),
-		fcmTokenRepo:    database.NewFCMTokenRepository(db),
-		fcmService:      fcmService,
+		outbox:          outbox,
 		cloudName:       cloudName,
 		previewPreset:   previewPreset,
 	}
@@ -196,8 +191,8 @@ func (h *MediaHandler) CreateMedia(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("✓ Média ajouté: %s (%s) par %s", req.Filename, req.Type, req.UserEmail)
 
-	// NOUVEAU: Envoyer notification de galerie
-	go h.sendGalleryNotification(eventID, req.UserEmail, userName, req.URL)
+	// Notifier les participants (via l'outbox : envoi garanti avec relances)
+	h.notifyGalleryUpload(media, event, userName)
 
 	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
 		"message": "Média ajouté avec succès",
@@ -252,9 +247,14 @@ func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Les modérateurs de la galerie peuvent supprimer les médias des autres
 	if media.UserEmail != claims.Email {
-		utils.RespondError(w, http.StatusForbidden, "Vous ne pouvez supprimer que vos propres médias")
-		return
+		user, err := h.userRepo.FindByEmail(claims.Email)
+		if err != nil || !user.HasPermission(models.PermissionGalleryModerate, &eventID) {
+			utils.RespondError(w, http.StatusForbidden, "Vous ne pouvez supprimer que vos propres médias")
+			return
+		}
+		log.Printf("🛡️  Média %s supprimé par le modérateur %s", mediaID.Hex(), claims.Email)
 	}
 
 	// Supprimer le média
@@ -278,29 +278,26 @@ func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// sendGalleryNotification envoie une notification de galerie
-func (h *MediaHandler) sendGalleryNotification(eventID primitive.ObjectID, userEmail, userName, mediaURL string) {
-	// Récupérer l'événement
-	event, err := h.eventRepo.FindByID(eventID)
-	if err != nil || event == nil {
-		log.Printf("❌ Erreur récupération événement pour notification: %v", err)
+// notifyGalleryUpload place dans l'outbox la notification de galerie pour les participants de l'événement
+func (h *MediaHandler) notifyGalleryUpload(media *models.Media, event *models.Event, userName string) {
+	if h.outbox == nil {
 		return
 	}
 
 	// Récupérer les participants de l'événement (exclure l'utilisateur qui a ajouté)
-	participants, err := h.getEventParticipants(eventID, userEmail)
+	recipients, err := h.getEventRecipients(event.ID, media.UserEmail)
 	if err != nil {
 		log.Printf("❌ Erreur récupération participants: %v", err)
 		return
 	}
 
-	if len(participants) == 0 {
-		log.Printf("ℹ️  Aucun participant trouvé pour l'événement %s", eventID.Hex())
+	if len(recipients) == 0 {
+		log.Printf("ℹ️  Aucun participant trouvé pour l'événement %s", event.ID.Hex())
 		return
 	}
 
 	// Générer l'URL de preview avec flou
-	previewURL := h.generatePreviewURL(mediaURL)
+	previewURL := h.generatePreviewURL(media.URL)
 	log.Printf("🖼️  URL preview générée: %s", previewURL)
 
 	// Construire le message de notification
@@ -310,56 +307,45 @@ func (h *MediaHandler) sendGalleryNotification(eventID primitive.ObjectID, userE
 	// Préparer les données de la notification
 	notificationData := map[string]string{
 		"type":        "gallery_update",
-		"event_id":    eventID.Hex(),
+		"event_id":    event.ID.Hex(),
 		"user_name":   userName,
 		"media_count": "1",
 		"event_title": event.Titre,
-		"action_url":  fmt.Sprintf("/galerie-event/%s", eventID.Hex()),
+		"action_url":  fmt.Sprintf("/galerie-event/%s", event.ID.Hex()),
 	}
 
-	// Envoyer les notifications
-	successCount, failedCount, failedTokens := h.fcmService.SendToAll(participants, title, body, notificationData)
-
-	// Nettoyer les tokens invalides
-	if len(failedTokens) > 0 {
-		go h.cleanupInvalidTokens(failedTokens)
+	_, err = h.outbox.Enqueue(&models.OutboxMessage{
+		IdempotencyKey: "gallery_upload:" + media.ID.Hex(),
+		Category:       models.NotificationCategoryGalleryUpload,
+		Recipients:     recipients,
+		Title:          title,
+		Body:           body,
+		Data:           notificationData,
+	})
+	if err != nil {
+		log.Printf("❌ Erreur mise en file de la notification galerie: %v", err)
+		return
 	}
 
-	log.Printf("📱 Notification galerie envoyée: %s - %s - %d succès, %d échecs", userName, event.Titre, successCount, failedCount)
+	log.Printf("📱 Notification galerie mise en file: %s - %s - %d participant(s)", userName, event.Titre, len(recipients))
 }
 
-// getEventParticipants récupère les participants d'un événement (exclut l'utilisateur qui a ajouté)
-func (h *MediaHandler) getEventParticipants(eventID primitive.ObjectID, excludeUserEmail string) ([]string, error) {
-	// Récupérer les inscriptions de l'événement
+// getEventRecipients récupère les emails des participants d'un événement (exclut l'utilisateur qui a ajouté).
+// Les tokens et les préférences de galerie sont appliqués par l'outbox à chaque essai d'envoi.
+func (h *MediaHandler) getEventRecipients(eventID primitive.ObjectID, excludeUserEmail string) ([]string, error) {
 	inscriptions, err := h.inscriptionRepo.FindByEventID(eventID)
 	if err != nil {
 		return nil, err
 	}
 
-	var participants []string
+	recipients := make([]string, 0, len(inscriptions))
 	for _, inscription := range inscriptions {
-		// Exclure l'utilisateur qui a ajouté les médias
 		if inscription.UserEmail == excludeUserEmail {
 			continue
 		}
-
-		// Récupérer les tokens FCM de l'utilisateur depuis la collection fcm_tokens
-		tokens, err := h.fcmTokenRepo.FindByUserID(inscription.UserEmail)
-		if err != nil {
-			log.Printf("⚠️  Erreur récupération tokens FCM pour %s: %v", inscription.UserEmail, err)
-			continue
-		}
-
-		// Ajouter tous les tokens valides de cet utilisateur
-		for _, token := range tokens {
-			if token.Token != "" {
-				participants = append(participants, token.Token)
-			}
-		}
+		recipients = append(recipients, inscription.UserEmail)
 	}
-
-	log.Printf("📱 Participants trouvés: %d tokens pour l'événement %s", len(participants), eventID.Hex())
-	return participants, nil
+	return recipients, nil
 }
 
 // generatePreviewURL génère une URL de preview avec flou
@@ -389,13 +375,3 @@ func (h *MediaHandler) generatePreviewURL(originalURL string) string {
 
 	return newURL
 }
-
-// cleanupInvalidTokens nettoie les tokens FCM invalides
-func (h *MediaHandler) cleanupInvalidTokens(failedTokens []string) {
-	for _, token := range failedTokens {
-		log.Printf("🧹 Nettoyage token invalide: %s", token)
-		// Ici on pourrait supprimer le token de la base de données
-		// Pour l'instant on log juste
-	}
-}
-
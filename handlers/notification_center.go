@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"premier-an-backend/database"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotificationCenterHandler gère le centre de notifications (historique des push de l'utilisateur)
+type NotificationCenterHandler struct {
+	notificationRepo *database.NotificationRepository
+	wsHub            WebSocketHub
+}
+
+// NewNotificationCenterHandler crée une nouvelle instance
+func NewNotificationCenterHandler(db *mongo.Database, wsHub WebSocketHub) *NotificationCenterHandler {
+	return &NotificationCenterHandler{
+		notificationRepo: database.NewNotificationRepository(db),
+		wsHub:            wsHub,
+	}
+}
+
+// GetNotifications liste les notifications de l'utilisateur, de la plus récente à la plus ancienne.
+// Query params : limit, cursor (next_cursor de la page précédente), unread=true pour les non lues uniquement.
+func (h *NotificationCenterHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := models.DefaultNotificationLimit
+	if parsedLimit, err := strconv.Atoi(query.Get("limit")); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+		if limit > models.MaxNotificationLimit {
+			limit = models.MaxNotificationLimit
+		}
+	}
+
+	var before *primitive.ObjectID
+	if cursor := query.Get("cursor"); cursor != "" {
+		id, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Curseur invalide")
+			return
+		}
+		before = &id
+	}
+
+	notifications, err := h.notificationRepo.FindByUser(claims.Email, before, query.Get("unread") == "true", limit)
+	if err != nil {
+		log.Printf("❌ Erreur récupération notifications: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	unread, err := h.notificationRepo.CountUnread(claims.Email)
+	if err != nil {
+		log.Printf("❌ Erreur comptage notifications: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	response := map[string]interface{}{
+		"notifications": notifications,
+		"unread_count":  unread,
+		"has_more":      false,
+		"next_cursor":   nil,
+	}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		response["notifications"] = notifications
+		response["has_more"] = true
+		response["next_cursor"] = notifications[len(notifications)-1].ID.Hex()
+	}
+
+	utils.RespondSuccess(w, "Notifications", response)
+}
+
+// GetUnreadCount retourne le nombre de notifications non lues (badge)
+func (h *NotificationCenterHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	unread, err := h.notificationRepo.CountUnread(claims.Email)
+	if err != nil {
+		log.Printf("❌ Erreur comptage notifications: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	utils.RespondSuccess(w, "Notifications non lues", map[string]interface{}{
+		"unread_count": unread,
+	})
+}
+
+// MarkAsRead marque une notification comme lue
+func (h *NotificationCenterHandler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	notificationID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de notification invalide")
+		return
+	}
+
+	found, err := h.notificationRepo.MarkAsRead(claims.Email, notificationID)
+	if err != nil {
+		log.Printf("❌ Erreur mise à jour notification: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if !found {
+		utils.RespondError(w, http.StatusNotFound, "Notification introuvable")
+		return
+	}
+
+	unread := h.broadcastRead(claims.Email, notificationID.Hex())
+	utils.RespondSuccess(w, "Notification lue", map[string]interface{}{
+		"unread_count": unread,
+	})
+}
+
+// MarkAllAsRead marque toutes les notifications de l'utilisateur comme lues
+func (h *NotificationCenterHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	updated, err := h.notificationRepo.MarkAllAsRead(claims.Email)
+	if err != nil {
+		log.Printf("❌ Erreur mise à jour notifications: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	unread := h.broadcastRead(claims.Email, "")
+	utils.RespondSuccess(w, "Notifications lues", map[string]interface{}{
+		"marked_count": updated,
+		"unread_count": unread,
+	})
+}
+
+// broadcastRead met à jour le badge sur les autres appareils de l'utilisateur et retourne le nombre de non lues
+func (h *NotificationCenterHandler) broadcastRead(email, notificationID string) int64 {
+	unread, err := h.notificationRepo.CountUnread(email)
+	if err != nil {
+		log.Printf("⚠️  Erreur comptage notifications: %v", err)
+		return 0
+	}
+
+	if h.wsHub != nil {
+		h.wsHub.SendToUser(email, models.WSNotificationsRead{
+			Type:           models.WSTypeNotificationsRead,
+			NotificationID: notificationID,
+			UnreadCount:    unread,
+		})
+	}
+	return unread
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"premier-an-backend/database"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/services"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotificationOutboxHandler expose aux admins l'état de livraison des notifications de l'outbox
+type NotificationOutboxHandler struct {
+	outboxRepo *database.NotificationOutboxRepository
+	outbox     *services.NotificationOutbox
+}
+
+// NewNotificationOutboxHandler crée une nouvelle instance
+func NewNotificationOutboxHandler(db *mongo.Database, outbox *services.NotificationOutbox) *NotificationOutboxHandler {
+	return &NotificationOutboxHandler{
+		outboxRepo: database.NewNotificationOutboxRepository(db),
+		outbox:     outbox,
+	}
+}
+
+// GetOutbox liste les messages de l'outbox, du plus récent au plus ancien, avec les compteurs par statut.
+// Query params : status (pending, processing, sent, dead), limit, cursor (next_cursor de la page précédente).
+func (h *NotificationOutboxHandler) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status != "" && !models.IsValidOutboxStatus(status) {
+		utils.RespondError(w, http.StatusBadRequest, "Statut invalide (pending, processing, sent ou dead)")
+		return
+	}
+
+	limit := models.DefaultOutboxLimit
+	if parsedLimit, err := strconv.Atoi(query.Get("limit")); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+		if limit > models.MaxOutboxLimit {
+			limit = models.MaxOutboxLimit
+		}
+	}
+
+	var before *primitive.ObjectID
+	if cursor := query.Get("cursor"); cursor != "" {
+		id, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Curseur invalide")
+			return
+		}
+		before = &id
+	}
+
+	messages, err := h.outboxRepo.FindByStatus(status, before, limit)
+	if err != nil {
+		log.Printf("❌ Erreur récupération outbox: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	counts, err := h.outboxRepo.CountByStatus()
+	if err != nil {
+		log.Printf("❌ Erreur comptage outbox: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	response := map[string]interface{}{
+		"messages":    messages,
+		"counts":      counts,
+		"has_more":    false,
+		"next_cursor": nil,
+	}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		response["messages"] = messages
+		response["has_more"] = true
+		response["next_cursor"] = messages[len(messages)-1].ID.Hex()
+	}
+
+	utils.RespondSuccess(w, "Outbox des notifications", response)
+}
+
+// GetOutboxMessage retourne l'état de livraison d'un message de l'outbox
+func (h *NotificationOutboxHandler) GetOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de message invalide")
+		return
+	}
+
+	message, err := h.outboxRepo.FindByID(messageID)
+	if err != nil {
+		log.Printf("❌ Erreur récupération message outbox: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if message == nil {
+		utils.RespondError(w, http.StatusNotFound, "Message introuvable")
+		return
+	}
+
+	utils.RespondSuccess(w, "Message outbox", message)
+}
+
+// RetryOutboxMessage relance un message abandonné (statut dead) pour un nouveau cycle d'essais
+func (h *NotificationOutboxHandler) RetryOutboxMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de message invalide")
+		return
+	}
+
+	message, err := h.outboxRepo.FindByID(messageID)
+	if err != nil {
+		log.Printf("❌ Erreur récupération message outbox: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if message == nil {
+		utils.RespondError(w, http.StatusNotFound, "Message introuvable")
+		return
+	}
+
+	requeued, err := h.outbox.Retry(messageID)
+	if err != nil {
+		log.Printf("❌ Erreur relance message outbox: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+	if !requeued {
+		utils.RespondError(w, http.StatusConflict, "Seuls les messages abandonnés peuvent être relancés")
+		return
+	}
+
+	if claims := middleware.GetUserFromContext(r.Context()); claims != nil {
+		log.Printf("🔁 Message outbox %s relancé par %s", message.IdempotencyKey, claims.Email)
+	}
+
+	utils.RespondSuccess(w, "Message relancé", map[string]interface{}{
+		"id":     messageID.Hex(),
+		"status": models.OutboxStatusPending,
+	})
+}
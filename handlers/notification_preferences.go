@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"premier-an-backend/database"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// NotificationPreferencesHandler gère les préférences de notifications push (sourdine, heures calmes, catégories)
+type NotificationPreferencesHandler struct {
+	preferenceRepo *database.NotificationPreferenceRepository
+	chatRepo       *database.ChatRepository
+	groupRepo      *database.ChatGroupRepository
+	userRepo       *database.UserRepository
+}
+
+// NewNotificationPreferencesHandler crée une nouvelle instance
+func NewNotificationPreferencesHandler(db *mongo.Database) *NotificationPreferencesHandler {
+	return &NotificationPreferencesHandler{
+		preferenceRepo: database.NewNotificationPreferenceRepository(db),
+		chatRepo:       database.NewChatRepository(db),
+		groupRepo:      database.NewChatGroupRepository(db),
+		userRepo:       database.NewUserRepository(db),
+	}
+}
+
+// GetPreferences retourne les préférences de l'utilisateur connecté (valeurs par défaut s'il n'a rien configuré)
+func (h *NotificationPreferencesHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	preferences, ok := h.loadPreferences(w, claims.Email)
+	if !ok {
+		return
+	}
+
+	utils.RespondSuccess(w, "Préférences de notifications", preferences)
+}
+
+// UpdatePreferences modifie la sourdine globale, les heures calmes et les catégories
+func (h *NotificationPreferencesHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	preferences, ok := h.loadPreferences(w, claims.Email)
+	if !ok {
+		return
+	}
+
+	// Sourdine : {"muted": true} jusqu'à réactivation, {"muted_until": date} jusqu'à une date, {"muted": false} pour réactiver
+	if req.Muted != nil || req.MutedUntil != nil {
+		preferences.Muted = req.Muted != nil && *req.Muted
+		preferences.MutedUntil = req.MutedUntil
+	}
+	if req.QuietHours != nil {
+		if req.QuietHours.Start == "" && req.QuietHours.End == "" {
+			preferences.QuietHours = nil
+		} else {
+			if err := req.QuietHours.Validate(); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, "Heures calmes : "+err.Error())
+				return
+			}
+			preferences.QuietHours = req.QuietHours
+		}
+	}
+	if req.Categories != nil {
+		preferences.Categories = *req.Categories
+	}
+
+	if err := h.preferenceRepo.UpdateGlobal(preferences); err != nil {
+		log.Printf("❌ Erreur mise à jour préférences: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la mise à jour des préférences")
+		return
+	}
+	preferences.UpdatedAt = time.Now()
+
+	log.Printf("🔔 Préférences de notifications mises à jour par %s", claims.Email)
+	utils.RespondSuccess(w, "Préférences mises à jour", preferences)
+}
+
+// UpdateConversationPreference règle les notifications d'une conversation privée (sourdine)
+func (h *NotificationPreferencesHandler) UpdateConversationPreference(w http.ResponseWriter, r *http.Request) {
+	h.updateTargetPreference(w, r, database.NotificationTargetConversations)
+}
+
+// ResetConversationPreference rétablit les notifications par défaut d'une conversation privée
+func (h *NotificationPreferencesHandler) ResetConversationPreference(w http.ResponseWriter, r *http.Request) {
+	h.resetTargetPreference(w, r, database.NotificationTargetConversations)
+}
+
+// UpdateGroupPreference règle les notifications d'un groupe (sourdine, mentions uniquement)
+func (h *NotificationPreferencesHandler) UpdateGroupPreference(w http.ResponseWriter, r *http.Request) {
+	h.updateTargetPreference(w, r, database.NotificationTargetGroups)
+}
+
+// ResetGroupPreference rétablit les notifications par défaut d'un groupe
+func (h *NotificationPreferencesHandler) ResetGroupPreference(w http.ResponseWriter, r *http.Request) {
+	h.resetTargetPreference(w, r, database.NotificationTargetGroups)
+}
+
+// updateTargetPreference enregistre le réglage d'une conversation ou d'un groupe dont l'utilisateur fait partie
+func (h *NotificationPreferencesHandler) updateTargetPreference(w http.ResponseWriter, r *http.Request, target string) {
+	if r.Method != http.MethodPut {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	email, id, ok := h.authorizeTarget(w, r, target)
+	if !ok {
+		return
+	}
+
+	var setting models.ChatNotificationSetting
+	if err := json.NewDecoder(r.Body).Decode(&setting); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+	if setting.MentionsOnly && target == database.NotificationTargetConversations {
+		utils.RespondError(w, http.StatusBadRequest, "Le mode « mentions uniquement » est réservé aux groupes")
+		return
+	}
+	if setting.MutedUntil != nil && !setting.MutedUntil.After(time.Now()) {
+		setting.MutedUntil = nil
+	}
+
+	if err := h.preferenceRepo.SetTargetSetting(email, target, id, setting); err != nil {
+		log.Printf("❌ Erreur mise à jour préférences: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la mise à jour des préférences")
+		return
+	}
+
+	log.Printf("🔕 Notifications %s/%s réglées par %s", target, id, email)
+	utils.RespondSuccess(w, "Notifications mises à jour", setting)
+}
+
+// resetTargetPreference supprime le réglage d'une conversation ou d'un groupe
+func (h *NotificationPreferencesHandler) resetTargetPreference(w http.ResponseWriter, r *http.Request, target string) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	email, id, ok := h.authorizeTarget(w, r, target)
+	if !ok {
+		return
+	}
+
+	if err := h.preferenceRepo.RemoveTargetSetting(email, target, id); err != nil {
+		log.Printf("❌ Erreur mise à jour préférences: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de la mise à jour des préférences")
+		return
+	}
+
+	utils.RespondSuccess(w, "Notifications rétablies", nil)
+}
+
+// authorizeTarget vérifie que l'utilisateur connecté participe à la conversation ou au groupe de l'URL
+func (h *NotificationPreferencesHandler) authorizeTarget(w http.ResponseWriter, r *http.Request, target string) (string, string, bool) {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return "", "", false
+	}
+
+	if target == database.NotificationTargetGroups {
+		groupID, err := primitive.ObjectIDFromHex(mux.Vars(r)["group_id"])
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "ID de groupe invalide")
+			return "", "", false
+		}
+		isMember, err := h.groupRepo.IsMember(groupID, claims.Email)
+		if err != nil || !isMember {
+			utils.RespondError(w, http.StatusForbidden, "Vous n'êtes pas membre de ce groupe")
+			return "", "", false
+		}
+		return claims.Email, groupID.Hex(), true
+	}
+
+	conversationID, err := primitive.ObjectIDFromHex(mux.Vars(r)["conversation_id"])
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "ID de conversation invalide")
+		return "", "", false
+	}
+	user, err := h.userRepo.FindByEmail(claims.Email)
+	if err != nil || user == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur introuvable")
+		return "", "", false
+	}
+	conversationIDs, err := h.chatRepo.GetConversationIDs(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("❌ Erreur récupération conversations: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return "", "", false
+	}
+	if !containsObjectID(conversationIDs, conversationID) {
+		utils.RespondError(w, http.StatusForbidden, "Accès refusé à cette conversation")
+		return "", "", false
+	}
+	return claims.Email, conversationID.Hex(), true
+}
+
+// loadPreferences récupère les préférences de l'utilisateur, ou les valeurs par défaut
+func (h *NotificationPreferencesHandler) loadPreferences(w http.ResponseWriter, email string) (*models.NotificationPreferences, bool) {
+	preferences, err := h.preferenceRepo.FindByUserID(email)
+	if err != nil {
+		log.Printf("❌ Erreur récupération préférences: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil, false
+	}
+	if preferences == nil {
+		return models.DefaultNotificationPreferences(email), true
+	}
+	if preferences.Conversations == nil {
+		preferences.Conversations = map[string]models.ChatNotificationSetting{}
+	}
+	if preferences.Groups == nil {
+		preferences.Groups = map[string]models.ChatNotificationSetting{}
+	}
+	return preferences, true
+}
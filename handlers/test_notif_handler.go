@@ -73,7 +73,7 @@ func (h *TestNotifHandler) SendSimpleTest(w http.ResponseWriter, r *http.Request
 	log.Printf("   Message: %s", message)
 	log.Printf("   Token: %s...", tokenString[:30])
 	
-	// Envoyer
+	// Envoyer (test demandé par l'utilisateur : ses préférences de notifications ne s'appliquent pas)
 	err = h.fcmService.SendToToken(tokenString, title, message, nil)
 	if err != nil {
 		log.Printf("❌ ERREUR ENVOI: %v", err)
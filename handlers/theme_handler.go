@@ -14,13 +14,15 @@ import (
 type ThemeHandler struct {
 	siteSettingRepo *database.SiteSettingRepository
 	userCollection  *database.UserRepository
+	auditRepo       *database.AuditLogRepository
 }
 
 // NewThemeHandler crée un nouveau handler pour les thèmes
-func NewThemeHandler(siteSettingRepo *database.SiteSettingRepository, userCollection *database.UserRepository) *ThemeHandler {
+func NewThemeHandler(siteSettingRepo *database.SiteSettingRepository, userCollection *database.UserRepository, auditRepo *database.AuditLogRepository) *ThemeHandler {
 	return &ThemeHandler{
 		siteSettingRepo: siteSettingRepo,
 		userCollection:  userCollection,
+		auditRepo:       auditRepo,
 	}
 }
 
@@ -81,6 +83,12 @@ func (h *ThemeHandler) SetGlobalTheme(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	previousTheme, err := h.siteSettingRepo.GetGlobalTheme(r.Context())
+	if err != nil {
+		http.Error(w, "Erreur serveur", http.StatusInternalServerError)
+		return
+	}
+
 	// Mise à jour du thème global
 	err = h.siteSettingRepo.SetGlobalTheme(r.Context(), theme, &user.ID)
 	if err != nil {
@@ -88,6 +96,10 @@ func (h *ThemeHandler) SetGlobalTheme(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordAudit(h.auditRepo, r, models.AuditThemeUpdate, "settings", "global_theme",
+		map[string]interface{}{"theme": previousTheme},
+		map[string]interface{}{"theme": theme})
+
 	// Réponse JSON
 	response := models.ThemeResponse{
 		Success: true,
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"premier-an-backend/database"
+	"premier-an-backend/middleware"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UserDataHandler gère l'export et la suppression de ses propres données (RGPD)
+type UserDataHandler struct {
+	userRepo         *database.UserRepository
+	inscriptionRepo  *database.InscriptionRepository
+	waitlistRepo     *database.WaitlistRepository
+	mediaRepo        *database.MediaRepository
+	chatRepo         *database.ChatRepository
+	groupRepo        *database.ChatGroupRepository
+	groupMessageRepo *database.ChatGroupMessageRepository
+	fcmTokenRepo     *database.FCMTokenRepository
+	subscriptionRepo *database.SubscriptionRepository
+	preferenceRepo   *database.NotificationPreferenceRepository
+	notificationRepo *database.NotificationRepository
+}
+
+// NewUserDataHandler crée une nouvelle instance de UserDataHandler
+func NewUserDataHandler(db *mongo.Database) *UserDataHandler {
+	return &UserDataHandler{
+		userRepo:         database.NewUserRepository(db),
+		inscriptionRepo:  database.NewInscriptionRepository(db),
+		waitlistRepo:     database.NewWaitlistRepository(db),
+		mediaRepo:        database.NewMediaRepository(db),
+		chatRepo:         database.NewChatRepository(db),
+		groupRepo:        database.NewChatGroupRepository(db),
+		groupMessageRepo: database.NewChatGroupMessageRepository(db),
+		fcmTokenRepo:     database.NewFCMTokenRepository(db),
+		subscriptionRepo: database.NewSubscriptionRepository(db),
+		preferenceRepo:   database.NewNotificationPreferenceRepository(db),
+		notificationRepo: database.NewNotificationRepository(db),
+	}
+}
+
+// currentUser charge l'utilisateur authentifié
+func (h *UserDataHandler) currentUser(w http.ResponseWriter, r *http.Request) *models.User {
+	claims := middleware.GetUserFromContext(r.Context())
+	if claims == nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return nil
+	}
+
+	user, err := h.userRepo.FindByEmail(claims.Email)
+	if err != nil {
+		log.Printf("Erreur récupération utilisateur: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return nil
+	}
+	if user == nil {
+		utils.RespondError(w, http.StatusNotFound, "Utilisateur non trouvé")
+		return nil
+	}
+
+	return user
+}
+
+// ExportData retourne toutes les données personnelles de l'utilisateur (JSON, ou ZIP avec ?format=zip)
+func (h *UserDataHandler) ExportData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+
+	export, err := h.buildExport(user)
+	if err != nil {
+		log.Printf("Erreur export des données de %s: %v", user.Email, err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de l'export des données")
+		return
+	}
+
+	filename := fmt.Sprintf("mes-donnees-%s", export.ExportedAt.Format("2006-01-02"))
+
+	if r.URL.Query().Get("format") == "zip" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.zip\"", filename))
+		w.WriteHeader(http.StatusOK)
+
+		if err := writeExportZip(w, export); err != nil {
+			log.Printf("Erreur écriture ZIP export: %v", err)
+		}
+	} else {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.json\"", filename))
+		utils.RespondJSON(w, http.StatusOK, export)
+	}
+
+	log.Printf("✓ Export des données personnelles: %s", user.Email)
+}
+
+// buildExport rassemble les données de l'utilisateur dans toutes les collections
+func (h *UserDataHandler) buildExport(user *models.User) (*models.UserDataExport, error) {
+	export := &models.UserDataExport{
+		ExportedAt: time.Now(),
+		Profile:    *user,
+	}
+
+	var err error
+	if export.Inscriptions, err = h.inscriptionRepo.FindByUser(user.Email); err != nil {
+		return nil, err
+	}
+	if export.Waitlist, err = h.waitlistRepo.FindByUser(user.Email); err != nil {
+		return nil, err
+	}
+	if export.Medias, err = h.mediaRepo.FindByUser(user.Email); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if export.PrivateMessages, err = h.chatRepo.FindMessagesBySender(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("erreur lors de la récupération des messages privés: %w", err)
+	}
+
+	if export.GroupMemberships, err = h.groupRepo.FindMembershipsByUser(user.Email); err != nil {
+		return nil, err
+	}
+	if export.GroupMessages, err = h.groupMessageRepo.FindBySender(user.Email); err != nil {
+		return nil, err
+	}
+	if export.FCMDevices, err = h.fcmTokenRepo.FindByUserID(user.Email); err != nil {
+		return nil, err
+	}
+	if export.PushSubscriptions, err = h.subscriptionRepo.FindByUserID(user.Email); err != nil {
+		return nil, err
+	}
+	if export.NotificationPreferences, err = h.preferenceRepo.FindByUserID(user.Email); err != nil {
+		return nil, err
+	}
+	if export.Notifications, err = h.notificationRepo.FindAllByUser(user.Email); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// writeExportZip écrit l'export sous forme d'archive, un fichier JSON par catégorie
+func writeExportZip(w http.ResponseWriter, export *models.UserDataExport) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profil.json", export.Profile},
+		{"inscriptions.json", export.Inscriptions},
+		{"liste_attente.json", export.Waitlist},
+		{"medias.json", export.Medias},
+		{"messages_prives.json", export.PrivateMessages},
+		{"groupes.json", export.GroupMemberships},
+		{"messages_groupes.json", export.GroupMessages},
+		{"appareils.json", export.FCMDevices},
+		{"abonnements_push.json", export.PushSubscriptions},
+		{"preferences_notifications.json", export.NotificationPreferences},
+		{"notifications.json", export.Notifications},
+	}
+
+	for _, file := range files {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// RequestAccountDeletion programme la suppression du compte après le délai de grâce
+func (h *UserDataHandler) RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "Données invalides")
+		return
+	}
+
+	// Confirmation par mot de passe
+	if req.Password == "" || !utils.CheckPassword(user.Password, req.Password) {
+		utils.RespondError(w, http.StatusUnauthorized, "Mot de passe incorrect")
+		return
+	}
+
+	// Le site doit toujours garder au moins un super-admin
+	if user.IsSuperAdmin() {
+		admins, err := h.userRepo.FindAdmins()
+		if err != nil {
+			log.Printf("Erreur récupération admins: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+			return
+		}
+		if len(admins) <= 1 {
+			utils.RespondError(w, http.StatusBadRequest, "Impossible de supprimer le compte du dernier super-administrateur")
+			return
+		}
+	}
+
+	if user.DeletionScheduledAt != nil {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success":               true,
+			"message":               "La suppression de votre compte est déjà programmée",
+			"deletion_scheduled_at": user.DeletionScheduledAt,
+		})
+		return
+	}
+
+	scheduledAt := time.Now().Add(models.AccountDeletionGracePeriod)
+	if err := h.userRepo.UpdateByEmail(user.Email, map[string]interface{}{
+		"deletion_scheduled_at": scheduledAt,
+	}); err != nil {
+		log.Printf("Erreur programmation suppression compte: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	log.Printf("✓ Suppression du compte %s programmée le %s", user.Email, scheduledAt.Format(time.RFC3339))
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":               true,
+		"message":               "Votre compte sera supprimé à la fin du délai de grâce. Vous pouvez annuler d'ici là.",
+		"deletion_scheduled_at": scheduledAt,
+	})
+}
+
+// CancelAccountDeletion annule une suppression de compte programmée
+func (h *UserDataHandler) CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+
+	if user.DeletionScheduledAt == nil {
+		utils.RespondError(w, http.StatusBadRequest, "Aucune suppression de compte programmée")
+		return
+	}
+
+	if err := h.userRepo.UpdateByEmail(user.Email, map[string]interface{}{
+		"deletion_scheduled_at": nil,
+	}); err != nil {
+		log.Printf("Erreur annulation suppression compte: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+		return
+	}
+
+	log.Printf("✓ Suppression du compte %s annulée", user.Email)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "La suppression de votre compte a été annulée",
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"premier-an-backend/database"
 	"premier-an-backend/handlers"
 	"premier-an-backend/middleware"
+	"premier-an-backend/models"
 	"premier-an-backend/services"
 	"premier-an-backend/utils"
 	"premier-an-backend/websocket"
@@ -33,6 +34,7 @@ func main() {
 
 	// Initialiser Firebase Cloud Messaging
 	fcmService, err := services.NewFCMService(cfg.FirebaseCredentialsFile)
+	fcmEnabled := err == nil
 	if err != nil {
 		log.Printf("⚠️  Erreur d'initialisation Firebase: %v", err)
 		log.Println("⚠️  Le serveur démarre SANS notifications push")
@@ -40,12 +42,11 @@ func main() {
 		fcmService = services.NewDisabledFCMService()
 	} else {
 		log.Println("✓ Firebase Cloud Messaging initialisé")
-
-		// Initialiser et démarrer le cron job pour les notifications automatiques
-		notificationCron := services.NewNotificationCron(database.DB, fcmService)
-		notificationCron.Start()
 	}
 
+	// Filtrer les destinataires selon leurs préférences (sourdine, heures calmes, catégories)
+	fcmService.UsePreferences(database.NewNotificationPreferenceRepository(database.DB))
+
 	// Initialiser le service Slack pour les notifications d'erreurs
 	slackService := services.NewSlackService(cfg.SlackWebhookURL)
 	if cfg.SlackWebhookURL != "" {
@@ -70,8 +71,14 @@ func main() {
 	chatRepo := database.NewChatRepository(database.DB)
 	fcmTokenRepo := database.NewFCMTokenRepository(database.DB)
 
+	// Service d'envoi d'e-mails (SMTP, ou fichiers/logs en local)
+	mailer := services.NewMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom, cfg.MailOutboxDir)
+
+	// Outbox des notifications : envois durables avec relances (démarrée après le centre de notifications)
+	notificationOutbox := services.NewNotificationOutbox(database.DB, fcmService, cfg.OutboxMaxAttempts)
+
 	// Créer les handlers
-	authHandler := handlers.NewAuthHandler(database.DB, cfg.JWTSecret, fcmService)
+	authHandler := handlers.NewAuthHandler(database.DB, cfg.JWTSecret, fcmService, mailer, cfg.FrontendURL)
 	notificationHandler := handlers.NewNotificationHandler(
 		database.DB,
 		cfg.VAPIDPublicKey,
@@ -80,52 +87,70 @@ func main() {
 	)
 	fcmHandler := handlers.NewFCMHandler(database.DB, fcmService)
 	eventHandler := handlers.NewEventHandler(database.DB)
-	inscriptionHandler := handlers.NewInscriptionHandler(database.DB, fcmService)
+	inscriptionHandler := handlers.NewInscriptionHandler(database.DB, cfg.JWTSecret, notificationOutbox)
 	mediaHandler := handlers.NewMediaHandler(
 		database.DB,
-		fcmService,
+		notificationOutbox,
 		cfg.CloudinaryCloudName,
 		cfg.CloudinaryPreviewPreset,
 	)
 	alertHandler := handlers.NewAlertHandler(database.DB, fcmService)
-	themeHandler := handlers.NewThemeHandler(siteSettingRepo, userRepo)
-	cloudinaryHandler := handlers.NewCloudinaryHandler(
-		database.DB,
-		cfg.CloudinaryCloudName,
-		cfg.CloudinaryUploadPreset,
-		cfg.CloudinaryAPIKey,
-		cfg.CloudinaryAPISecret,
-	)
-	eventTrailerHandler := handlers.NewEventTrailerHandler(
-		database.DB,
-		cfg.CloudinaryCloudName,
-		cfg.CloudinaryVideoPreset,
-		cfg.CloudinaryAPIKey,
-		cfg.CloudinaryAPISecret,
-	)
+	themeHandler := handlers.NewThemeHandler(siteSettingRepo, userRepo, database.NewAuditLogRepository(database.DB))
+	cloudinaryClient := services.NewCloudinaryClient(cfg.CloudinaryCloudName, cfg.CloudinaryAPIKey, cfg.CloudinaryAPISecret)
+	cloudinaryHandler := handlers.NewCloudinaryHandler(database.DB, cloudinaryClient)
+	eventTrailerHandler := handlers.NewEventTrailerHandler(database.DB, cloudinaryClient)
 
 	// Initialiser le handler de notifications galerie
 	galleryNotificationHandler := handlers.NewGalleryNotificationHandler(
 		database.DB,
-		fcmService,
+		notificationOutbox,
 		cfg.CloudinaryCloudName,
 		cfg.CloudinaryPreviewPreset,
 	)
 
 	// Initialiser le hub WebSocket pour le chat (avec repositories pour la présence)
-	wsHub := websocket.NewHub(userRepo, chatRepo)
+	// Backplane WebSocket : "mongo" pour relayer les envois entre plusieurs instances
+	var broadcaster websocket.Broadcaster
+	if cfg.WSBroadcaster == "mongo" {
+		broadcaster = websocket.NewMongoBroadcaster(database.DB)
+		log.Println("✓ Backplane WebSocket MongoDB activé (change stream ws_events)")
+	}
+	wsHub := websocket.NewHub(userRepo, chatRepo, broadcaster, database.NewWSEventLogRepository(database.DB), database.NewWSPresenceRepository(database.DB))
 	go wsHub.Run()
 
+	// Centre de notifications : chaque push est enregistré pour son destinataire et relayé sur le WebSocket
+	fcmService.UseNotificationCenter(database.NewNotificationRepository(database.DB), database.NewFCMTokenRepository(database.DB), wsHub)
+	notificationOutbox.Start()
+
+	// Initialiser et démarrer le cron job pour les notifications automatiques
+	if fcmEnabled {
+		notificationCron := services.NewNotificationCron(database.DB, fcmService)
+		notificationCron.Start()
+	}
+
 	// Créer adminHandler après wsHub car il en a besoin pour les notifications WebSocket
-	adminHandler := handlers.NewAdminHandler(database.DB, fcmService, wsHub)
+	deletionService := services.NewDeletionService(database.DB, cloudinaryClient, services.NewWaitlistService(database.DB, notificationOutbox))
+	deletionService.StartScheduledPurge()
+	adminHandler := handlers.NewAdminHandler(database.DB, fcmService, notificationOutbox, wsHub, deletionService)
+
+	// Stockage des pièces jointes du chat : Cloudinary, ou disque local si non configuré
+	fileStorage := services.NewFileStorage(cloudinaryClient, cfg.StorageLocalDir, cfg.StorageLocalURL)
+	if localStorage, ok := fileStorage.(*services.LocalStorage); ok {
+		router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", localStorage.Handler()))
+	}
 
-	chatHandler := handlers.NewChatHandler(chatRepo, userRepo, fcmTokenRepo, fcmService, wsHub)
+	chatHandler := handlers.NewChatHandler(chatRepo, userRepo, fcmTokenRepo, fcmService, notificationOutbox, wsHub, fileStorage)
 	testNotifHandler := handlers.NewTestNotifHandler(fcmTokenRepo, fcmService)
-	wsHandler := websocket.NewHandler(wsHub, cfg.JWTSecret)
-	chatGroupHandler := handlers.NewChatGroupHandler(database.DB, fcmService, wsHub)
+	wsHandler := websocket.NewHandler(wsHub, cfg.JWTSecret, database.NewSessionRepository(database.DB))
+	chatGroupHandler := handlers.NewChatGroupHandler(database.DB, fcmService, wsHub, fileStorage)
+	chatSearchHandler := handlers.NewChatSearchHandler(database.DB)
+	notificationPreferencesHandler := handlers.NewNotificationPreferencesHandler(database.DB)
+	notificationCenterHandler := handlers.NewNotificationCenterHandler(database.DB, wsHub)
+	notificationOutboxHandler := handlers.NewNotificationOutboxHandler(database.DB, notificationOutbox)
+	userDataHandler := handlers.NewUserDataHandler(database.DB)
 
 	// Middleware Guest pour empêcher l'accès si déjà connecté
-	guestMiddleware := middleware.Guest(cfg.JWTSecret)
+	guestMiddleware := middleware.Guest(cfg.JWTSecret, database.DB)
 
 	// Routes publiques - Compatible avec votre front
 	// Ces routes sont protégées par le middleware Guest (refusent les utilisateurs déjà connectés)
@@ -135,6 +160,10 @@ func main() {
 	// Routes alternatives (pour compatibilité)
 	router.Handle("/api/auth/register", guestMiddleware(http.HandlerFunc(authHandler.Register))).Methods("POST", "OPTIONS")
 	router.Handle("/api/auth/login", guestMiddleware(http.HandlerFunc(authHandler.Login))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/auth/refresh", authHandler.Refresh).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/forgot-password", guestMiddleware(http.HandlerFunc(authHandler.ForgotPassword))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/reset-password", guestMiddleware(http.HandlerFunc(authHandler.ResetPassword))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/auth/verify-email", authHandler.VerifyEmail).Methods("POST", "OPTIONS")
 
 	// Route de santé (health check)
 	router.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
@@ -176,11 +205,25 @@ func main() {
 
 	// Routes protégées
 	protected := router.PathPrefix("/api").Subrouter()
-	protected.Use(middleware.Auth(cfg.JWTSecret))
+	protected.Use(middleware.Auth(cfg.JWTSecret, database.DB))
 
 	// Routes de notifications (VAPID - ancienne méthode, garde pour compatibilité)
 	protected.HandleFunc("/notification/test", notificationHandler.SendTestNotification).Methods("POST", "OPTIONS")
 
+	// Centre de notifications (historique des push, compteur de non lues)
+	protected.HandleFunc("/notifications", notificationCenterHandler.GetNotifications).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/notifications/unread-count", notificationCenterHandler.GetUnreadCount).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/notifications/read-all", notificationCenterHandler.MarkAllAsRead).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/notifications/{id}/read", notificationCenterHandler.MarkAsRead).Methods("POST", "OPTIONS")
+
+	// Préférences de notifications push (sourdine, heures calmes, catégories, réglages par conversation / groupe)
+	protected.HandleFunc("/notifications/preferences", notificationPreferencesHandler.GetPreferences).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/notifications/preferences", notificationPreferencesHandler.UpdatePreferences).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/notifications/preferences/conversations/{conversation_id}", notificationPreferencesHandler.UpdateConversationPreference).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/notifications/preferences/conversations/{conversation_id}", notificationPreferencesHandler.ResetConversationPreference).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/notifications/preferences/groups/{group_id}", notificationPreferencesHandler.UpdateGroupPreference).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/notifications/preferences/groups/{group_id}", notificationPreferencesHandler.ResetGroupPreference).Methods("DELETE", "OPTIONS")
+
 	// Routes FCM (Firebase Cloud Messaging) - RECOMMANDÉ
 	protected.HandleFunc("/fcm/send", fcmHandler.SendNotification).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/fcm/send-to-user", fcmHandler.SendToUser).Methods("POST", "OPTIONS")
@@ -193,64 +236,98 @@ func main() {
 	// La route WebSocket doit être sur rawRouter pour éviter le wrapping du ResponseWriter
 	rawRouter.HandleFunc("/ws/chat", wsHandler.ServeWS).Methods("GET")
 
-	// Routes Admin (protégées par Auth + RequireAdmin)
+	// Routes Admin (protégées par Auth + une permission par route, voir models/role.go)
 	adminRouter := protected.PathPrefix("/admin").Subrouter()
-	adminRouter.Use(middleware.RequireAdmin(database.DB))
+	perm := func(permission string, handler http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(database.DB, permission)(handler)
+	}
 
 	// Gestion des utilisateurs
-	adminRouter.HandleFunc("/utilisateurs", adminHandler.GetUsers).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/utilisateurs/{id}", adminHandler.UpdateUser).Methods("PUT", "OPTIONS")
-	adminRouter.HandleFunc("/utilisateurs/{id}", adminHandler.DeleteUser).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/utilisateurs", perm(models.PermissionUsersRead, adminHandler.GetUsers)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/utilisateurs/{id}", perm(models.PermissionUsersWrite, adminHandler.UpdateUser)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/utilisateurs/{id}", perm(models.PermissionUsersDelete, adminHandler.DeleteUser)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/utilisateurs/{id}/roles", perm(models.PermissionRolesManage, adminHandler.UpdateUserRoles)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/roles", perm(models.PermissionRolesManage, adminHandler.GetRoles)).Methods("GET", "OPTIONS")
+
+	// Journal d'audit
+	adminRouter.Handle("/audit", perm(models.PermissionAuditRead, adminHandler.GetAuditLog)).Methods("GET", "OPTIONS")
 
 	// Gestion des événements
-	adminRouter.HandleFunc("/evenements", adminHandler.GetEvents).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/evenements", adminHandler.CreateEvent).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{event_id}", adminHandler.GetEvent).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{id}", adminHandler.UpdateEvent).Methods("PUT", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{id}", adminHandler.DeleteEvent).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/evenements", perm(models.PermissionEventsRead, adminHandler.GetEvents)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/evenements", perm(models.PermissionEventsCreate, adminHandler.CreateEvent)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}", perm(models.PermissionEventsRead, adminHandler.GetEvent)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/evenements/{id}", perm(models.PermissionEventsWrite, adminHandler.UpdateEvent)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/evenements/{id}", perm(models.PermissionEventsDelete, adminHandler.DeleteEvent)).Methods("DELETE", "OPTIONS")
 
 	// Routes de gestion des trailers vidéo (admin uniquement)
-	adminRouter.HandleFunc("/evenements/{event_id}/trailer", eventTrailerHandler.UploadTrailer).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{event_id}/trailer", eventTrailerHandler.ReplaceTrailer).Methods("PUT", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{event_id}/trailer", eventTrailerHandler.DeleteTrailer).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/trailer", perm(models.PermissionEventsWrite, eventTrailerHandler.UploadTrailer)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/trailer", perm(models.PermissionEventsWrite, eventTrailerHandler.ReplaceTrailer)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/trailer", perm(models.PermissionEventsWrite, eventTrailerHandler.DeleteTrailer)).Methods("DELETE", "OPTIONS")
 
 	// Statistiques
-	adminRouter.HandleFunc("/stats", adminHandler.GetStats).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/stats", perm(models.PermissionStatsRead, adminHandler.GetStats)).Methods("GET", "OPTIONS")
 
 	// Notifications admin
-	adminRouter.HandleFunc("/notifications/send", adminHandler.SendAdminNotification).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/notifications/send", perm(models.PermissionNotificationsSend, adminHandler.SendAdminNotification)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/notifications/outbox", perm(models.PermissionNotificationsSend, notificationOutboxHandler.GetOutbox)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/notifications/outbox/{id}", perm(models.PermissionNotificationsSend, notificationOutboxHandler.GetOutboxMessage)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/notifications/outbox/{id}/retry", perm(models.PermissionNotificationsSend, notificationOutboxHandler.RetryOutboxMessage)).Methods("POST", "OPTIONS")
+
+	// Paramètres d'inscription
+	adminRouter.Handle("/settings/inscriptions", perm(models.PermissionSettingsManage, adminHandler.GetInscriptionSettings)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/settings/inscriptions", perm(models.PermissionSettingsManage, adminHandler.UpdateInscriptionSettings)).Methods("PUT", "OPTIONS")
 
 	// Codes soirée
-	adminRouter.HandleFunc("/codes-soiree", adminHandler.GetAllCodesSoiree).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/code-soiree/generate", adminHandler.GenerateCodeSoiree).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/code-soiree/current", adminHandler.GetCurrentCodeSoiree).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/codes-soiree", perm(models.PermissionCodesManage, adminHandler.GetAllCodesSoiree)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/codes-soiree", perm(models.PermissionCodesManage, adminHandler.CreateCodeSoiree)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/codes-soiree/{id}", perm(models.PermissionCodesManage, adminHandler.GetCodeSoiree)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/codes-soiree/{id}", perm(models.PermissionCodesManage, adminHandler.UpdateCodeSoiree)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/code-soiree/generate", perm(models.PermissionCodesManage, adminHandler.GenerateCodeSoiree)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/code-soiree/current", perm(models.PermissionCodesManage, adminHandler.GetCurrentCodeSoiree)).Methods("GET", "OPTIONS")
 
 	// Chat admin
-	adminRouter.HandleFunc("/chat/conversations", chatHandler.GetConversations).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/conversations/{id}/messages", chatHandler.GetMessages).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/conversations/{id}/messages", chatHandler.SendMessage).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/conversations/{id}/mark-read", chatHandler.MarkConversationAsRead).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/admins/search", chatHandler.SearchAdmins).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/invitations", chatHandler.SendInvitation).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/invitations", chatHandler.GetInvitations).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/invitations/{id}/respond", chatHandler.RespondToInvitation).Methods("PUT", "OPTIONS")
-	adminRouter.HandleFunc("/chat/notifications/send", chatHandler.SendChatNotification).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/conversations", perm(models.PermissionChatAdmin, chatHandler.GetConversations)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/messages", perm(models.PermissionChatAdmin, chatHandler.GetMessages)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/messages", perm(models.PermissionChatAdmin, chatHandler.SendMessage)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/messages/{message_id}", perm(models.PermissionChatAdmin, chatHandler.EditMessage)).Methods("PATCH", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/messages/{message_id}", perm(models.PermissionChatAdmin, chatHandler.DeleteMessage)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/messages/{message_id}/reactions", perm(models.PermissionChatAdmin, chatHandler.AddReaction)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/messages/{message_id}/reactions/{emoji}", perm(models.PermissionChatAdmin, chatHandler.RemoveReaction)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/conversations/{id}/mark-read", perm(models.PermissionChatAdmin, chatHandler.MarkConversationAsRead)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/admins/search", perm(models.PermissionChatAdmin, chatHandler.SearchAdmins)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/invitations", perm(models.PermissionChatAdmin, chatHandler.SendInvitation)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/invitations", perm(models.PermissionChatAdmin, chatHandler.GetInvitations)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/invitations/{id}/respond", perm(models.PermissionChatAdmin, chatHandler.RespondToInvitation)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/chat/notifications/send", perm(models.PermissionChatAdmin, chatHandler.SendChatNotification)).Methods("POST", "OPTIONS")
 
 	// 👥 Routes Groupes de chat (admin)
-	adminRouter.HandleFunc("/chat/groups", chatGroupHandler.CreateGroup).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups", chatGroupHandler.GetGroups).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/invite", chatGroupHandler.InviteToGroup).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/members", chatGroupHandler.GetGroupMembers).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/leave", chatGroupHandler.LeaveGroup).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/pending-invitations", chatGroupHandler.GetGroupPendingInvitations).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/invitations/pending", chatGroupHandler.GetGroupPendingInvitations).Methods("GET", "OPTIONS") // Alias pour frontend
-	adminRouter.HandleFunc("/chat/groups/{group_id}/messages", chatGroupHandler.SendMessage).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/messages", chatGroupHandler.GetMessages).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/groups/{group_id}/mark-read", chatGroupHandler.MarkAsRead).Methods("POST", "OPTIONS")
-	adminRouter.HandleFunc("/chat/group-invitations/pending", chatGroupHandler.GetPendingInvitations).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/chat/group-invitations/{invitation_id}/respond", chatGroupHandler.RespondToInvitation).Methods("PUT", "OPTIONS")
-	adminRouter.HandleFunc("/chat/group-invitations/{invitation_id}/cancel", chatGroupHandler.CancelInvitation).Methods("DELETE", "OPTIONS")
-	adminRouter.HandleFunc("/chat/users/search", chatGroupHandler.SearchUsers).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/groups", perm(models.PermissionChatAdmin, chatGroupHandler.CreateGroup)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups", perm(models.PermissionChatAdmin, chatGroupHandler.GetGroups)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/invite", perm(models.PermissionChatAdmin, chatGroupHandler.InviteToGroup)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/members", perm(models.PermissionChatAdmin, chatGroupHandler.GetGroupMembers)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/leave", perm(models.PermissionChatAdmin, chatGroupHandler.LeaveGroup)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}", perm(models.PermissionChatAdmin, chatGroupHandler.UpdateGroup)).Methods("PATCH", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}", perm(models.PermissionChatAdmin, chatGroupHandler.DissolveGroup)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/members/{user_id}/role", perm(models.PermissionChatAdmin, chatGroupHandler.UpdateMemberRole)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/members/{user_id}", perm(models.PermissionChatAdmin, chatGroupHandler.RemoveGroupMember)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/transfer", perm(models.PermissionChatAdmin, chatGroupHandler.TransferOwnership)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/archive", perm(models.PermissionChatAdmin, chatGroupHandler.ArchiveGroup)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/archive", perm(models.PermissionChatAdmin, chatGroupHandler.UnarchiveGroup)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/pending-invitations", perm(models.PermissionChatAdmin, chatGroupHandler.GetGroupPendingInvitations)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/invitations/pending", perm(models.PermissionChatAdmin, chatGroupHandler.GetGroupPendingInvitations)).Methods("GET", "OPTIONS") // Alias pour frontend
+	adminRouter.Handle("/chat/groups/{group_id}/messages", perm(models.PermissionChatAdmin, chatGroupHandler.SendMessage)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/messages", perm(models.PermissionChatAdmin, chatGroupHandler.GetMessages)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/messages/{message_id}", perm(models.PermissionChatAdmin, chatGroupHandler.EditMessage)).Methods("PATCH", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/messages/{message_id}", perm(models.PermissionChatAdmin, chatGroupHandler.DeleteMessage)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/messages/{message_id}/thread", perm(models.PermissionChatAdmin, chatGroupHandler.GetThread)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/messages/{message_id}/reactions", perm(models.PermissionChatAdmin, chatGroupHandler.AddReaction)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/messages/{message_id}/reactions/{emoji}", perm(models.PermissionChatAdmin, chatGroupHandler.RemoveReaction)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/groups/{group_id}/mark-read", perm(models.PermissionChatAdmin, chatGroupHandler.MarkAsRead)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/chat/group-invitations/pending", perm(models.PermissionChatAdmin, chatGroupHandler.GetPendingInvitations)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/group-invitations/{invitation_id}/respond", perm(models.PermissionChatAdmin, chatGroupHandler.RespondToInvitation)).Methods("PUT", "OPTIONS")
+	adminRouter.Handle("/chat/group-invitations/{invitation_id}/cancel", perm(models.PermissionChatAdmin, chatGroupHandler.CancelInvitation)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/chat/users/search", perm(models.PermissionChatAdmin, chatGroupHandler.SearchUsers)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/chat/messages/search", perm(models.PermissionChatAdmin, chatSearchHandler.SearchMessages)).Methods("GET", "OPTIONS")
 
 	// Route protégée exemple
 	protected.HandleFunc("/protected/profile", func(w http.ResponseWriter, r *http.Request) {
@@ -267,11 +344,19 @@ func main() {
 	}).Methods("GET")
 
 	// Route de mise à jour du profil utilisateur
+	protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/logout-all", authHandler.LogoutAll).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/resend-verification", authHandler.ResendVerification).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/user/profile", authHandler.UpdateProfile).Methods("PUT", "PATCH", "OPTIONS")
 
 	// Route d'upload de photo de profil (protégée)
 	protected.HandleFunc("/user/profile/image", cloudinaryHandler.UploadProfileImage).Methods("POST", "OPTIONS")
 
+	// Données personnelles (RGPD) : export et suppression du compte
+	protected.HandleFunc("/user/export", userDataHandler.ExportData).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/user/delete", userDataHandler.RequestAccountDeletion).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/user/delete", userDataHandler.CancelAccountDeletion).Methods("DELETE", "OPTIONS")
+
 	// Routes d'inscription aux événements (protégées - authentification requise)
 	protected.HandleFunc("/evenements/{event_id}/inscription", inscriptionHandler.CreateInscription).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/evenements/{event_id}/inscription", inscriptionHandler.GetInscription).Methods("GET", "OPTIONS")
@@ -279,11 +364,14 @@ func main() {
 	protected.HandleFunc("/evenements/{event_id}/inscription", inscriptionHandler.UpdateInscription).Methods("PUT", "OPTIONS")
 	protected.HandleFunc("/evenements/{event_id}/inscription", inscriptionHandler.DeleteInscription).Methods("DELETE", "OPTIONS")    // Alias REST
 	protected.HandleFunc("/evenements/{event_id}/desinscription", inscriptionHandler.DeleteInscription).Methods("DELETE", "OPTIONS") // Legacy
+	protected.HandleFunc("/evenements/{event_id}/inscription/ticket", inscriptionHandler.GetTicket).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/evenements/{event_id}/liste-attente", inscriptionHandler.GetMaListeAttente).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/evenements/{event_id}/liste-attente", inscriptionHandler.LeaveListeAttente).Methods("DELETE", "OPTIONS")
 
 	// Routes de gestion des trailers vidéo (protégées - authentification requise)
-	protected.HandleFunc("/evenements/{event_id}/trailer", eventTrailerHandler.UploadTrailer).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/evenements/{event_id}/trailer", eventTrailerHandler.ReplaceTrailer).Methods("PUT", "OPTIONS")
-	protected.HandleFunc("/evenements/{event_id}/trailer", eventTrailerHandler.DeleteTrailer).Methods("DELETE", "OPTIONS")
+	protected.Handle("/evenements/{event_id}/trailer", perm(models.PermissionEventsWrite, eventTrailerHandler.UploadTrailer)).Methods("POST", "OPTIONS")
+	protected.Handle("/evenements/{event_id}/trailer", perm(models.PermissionEventsWrite, eventTrailerHandler.ReplaceTrailer)).Methods("PUT", "OPTIONS")
+	protected.Handle("/evenements/{event_id}/trailer", perm(models.PermissionEventsWrite, eventTrailerHandler.DeleteTrailer)).Methods("DELETE", "OPTIONS")
 
 	// Route pour récupérer les événements auxquels l'utilisateur est inscrit
 	protected.HandleFunc("/mes-evenements", inscriptionHandler.GetMesEvenements).Methods("GET", "OPTIONS")
@@ -296,6 +384,10 @@ func main() {
 	protected.HandleFunc("/chat/conversations", chatHandler.GetConversations).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/chat/conversations/{id}/messages", chatHandler.GetMessages).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/chat/conversations/{id}/messages", chatHandler.SendMessage).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/chat/conversations/{id}/messages/{message_id}", chatHandler.EditMessage).Methods("PATCH", "OPTIONS")
+	protected.HandleFunc("/chat/conversations/{id}/messages/{message_id}", chatHandler.DeleteMessage).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/chat/conversations/{id}/messages/{message_id}/reactions", chatHandler.AddReaction).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/chat/conversations/{id}/messages/{message_id}/reactions/{emoji}", chatHandler.RemoveReaction).Methods("DELETE", "OPTIONS")
 	protected.HandleFunc("/chat/conversations/{id}/mark-read", chatHandler.MarkConversationAsRead).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/chat/admins/search", chatHandler.SearchAdmins).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/chat/invitations", chatHandler.SendInvitation).Methods("POST", "OPTIONS")
@@ -308,13 +400,26 @@ func main() {
 	protected.HandleFunc("/chat/groups/{group_id}/invite", chatGroupHandler.InviteToGroup).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/chat/groups/{group_id}/members", chatGroupHandler.GetGroupMembers).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/chat/groups/{group_id}/leave", chatGroupHandler.LeaveGroup).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}", chatGroupHandler.UpdateGroup).Methods("PATCH", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}", chatGroupHandler.DissolveGroup).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/members/{user_id}/role", chatGroupHandler.UpdateMemberRole).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/members/{user_id}", chatGroupHandler.RemoveGroupMember).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/transfer", chatGroupHandler.TransferOwnership).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/archive", chatGroupHandler.ArchiveGroup).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/archive", chatGroupHandler.UnarchiveGroup).Methods("DELETE", "OPTIONS")
 	protected.HandleFunc("/chat/groups/{group_id}/pending-invitations", chatGroupHandler.GetGroupPendingInvitations).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/chat/groups/{group_id}/invitations/pending", chatGroupHandler.GetGroupPendingInvitations).Methods("GET", "OPTIONS") // Alias
 	protected.HandleFunc("/chat/groups/{group_id}/messages", chatGroupHandler.SendMessage).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/chat/groups/{group_id}/messages", chatGroupHandler.GetMessages).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/messages/{message_id}", chatGroupHandler.EditMessage).Methods("PATCH", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/messages/{message_id}", chatGroupHandler.DeleteMessage).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/messages/{message_id}/thread", chatGroupHandler.GetThread).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/messages/{message_id}/reactions", chatGroupHandler.AddReaction).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/chat/groups/{group_id}/messages/{message_id}/reactions/{emoji}", chatGroupHandler.RemoveReaction).Methods("DELETE", "OPTIONS")
 	protected.HandleFunc("/chat/groups/{group_id}/mark-read", chatGroupHandler.MarkAsRead).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/chat/group-invitations/pending", chatGroupHandler.GetPendingInvitations).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/chat/group-invitations/{invitation_id}/respond", chatGroupHandler.RespondToInvitation).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/chat/messages/search", chatSearchHandler.SearchMessages).Methods("GET", "OPTIONS")
 
 	// Routes médias (protégées - authentification requise)
 	protected.HandleFunc("/evenements/{event_id}/medias", mediaHandler.CreateMedia).Methods("POST", "OPTIONS")
@@ -325,9 +430,12 @@ func main() {
 	protected.HandleFunc("/evenements/{eventId}/medias/test", galleryNotificationHandler.TestGalleryNotification).Methods("POST", "OPTIONS")
 
 	// Routes admin inscriptions
-	adminRouter.HandleFunc("/evenements/{event_id}/inscrits", inscriptionHandler.GetInscrits).Methods("GET", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{event_id}/inscrits/{inscription_id}", inscriptionHandler.DeleteInscriptionAdmin).Methods("DELETE", "OPTIONS")
-	adminRouter.HandleFunc("/evenements/{event_id}/inscrits/{inscription_id}/accompagnant/{index}", inscriptionHandler.DeleteAccompagnant).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/inscrits", perm(models.PermissionInscriptionsRead, inscriptionHandler.GetInscrits)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/inscrits/{inscription_id}", perm(models.PermissionInscriptionsWrite, inscriptionHandler.DeleteInscriptionAdmin)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/inscrits/{inscription_id}/accompagnant/{index}", perm(models.PermissionInscriptionsWrite, inscriptionHandler.DeleteAccompagnant)).Methods("DELETE", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/liste-attente", perm(models.PermissionInscriptionsRead, inscriptionHandler.GetListeAttente)).Methods("GET", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/checkin", perm(models.PermissionCheckin, inscriptionHandler.Checkin)).Methods("POST", "OPTIONS")
+	adminRouter.Handle("/evenements/{event_id}/checkin", perm(models.PermissionCheckin, inscriptionHandler.GetCheckinStats)).Methods("GET", "OPTIONS")
 
 	// Créer un multiplexeur qui combine les deux routers
 	mainHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -356,6 +464,10 @@ func main() {
 		log.Println("   POST   /api/inscription                    - Inscription")
 		log.Println("   POST   /api/inscription/verify-code        - Vérifier code d'accès (public)")
 		log.Println("   POST   /api/connexion                      - Connexion")
+		log.Println("   POST   /api/auth/refresh                   - Renouveler le token d'accès")
+		log.Println("   POST   /api/auth/forgot-password           - Demander un lien de réinitialisation")
+		log.Println("   POST   /api/auth/reset-password            - Réinitialiser le mot de passe")
+		log.Println("   POST   /api/auth/verify-email              - Vérifier l'adresse e-mail")
 		log.Println("   GET    /api/health                         - Health check")
 		log.Println("   GET    /api/evenements/public              - Liste événements (public)")
 		log.Println("   GET    /api/evenements/{id}                - Détails événement (public)")
@@ -370,16 +482,28 @@ func main() {
 		log.Println("   POST   /api/fcm/subscribe                  - S'abonner (FCM)")
 		log.Println("")
 		log.Println("   🔒 Routes protégées:")
+		log.Println("   POST   /api/auth/logout                    - Déconnexion (session courante)")
+		log.Println("   POST   /api/auth/logout-all                - Déconnexion de tous les appareils")
+		log.Println("   POST   /api/auth/resend-verification       - Renvoyer l'e-mail de vérification")
 		log.Println("   POST   /api/fcm/send                       - Envoyer à TOUS (FCM)")
 		log.Println("   POST   /api/fcm/send-to-user               - Envoyer à un user (FCM)")
+		log.Println("   GET    /api/notifications                  - Centre de notifications")
+		log.Println("   GET    /api/notifications/preferences      - Mes préférences de notifications")
+		log.Println("   PUT    /api/notifications/preferences      - Sourdine, heures calmes, catégories")
 		log.Println("   GET    /api/protected/profile              - Profil utilisateur")
 		log.Println("   PUT    /api/user/profile                   - Mettre à jour profil")
 		log.Println("   POST   /api/user/profile/image             - Upload photo de profil")
+		log.Println("   GET    /api/user/export                    - Exporter mes données (RGPD)")
+		log.Println("   POST   /api/user/delete                    - Programmer la suppression de mon compte")
+		log.Println("   DELETE /api/user/delete                    - Annuler la suppression de mon compte")
 		log.Println("")
 		log.Println("   👑 Routes Admin (admin=1 requis):")
 		log.Println("   GET    /api/admin/utilisateurs             - Liste utilisateurs")
 		log.Println("   PUT    /api/admin/utilisateurs/{id}        - Modifier utilisateur")
 		log.Println("   DELETE /api/admin/utilisateurs/{id}        - Supprimer utilisateur")
+		log.Println("   PUT    /api/admin/utilisateurs/{id}/roles  - Attribuer les rôles")
+		log.Println("   GET    /api/admin/roles                    - Rôles et permissions")
+		log.Println("   GET    /api/admin/audit                    - Journal d'audit (filtres + pagination)")
 		log.Println("   GET    /api/admin/evenements               - Liste événements")
 		log.Println("   GET    /api/admin/evenements/{id}          - Détails événement")
 		log.Println("   POST   /api/admin/evenements               - Créer événement")
@@ -391,9 +515,19 @@ func main() {
 		log.Println("   GET    /api/admin/evenements/{id}/inscrits - Liste des inscrits")
 		log.Println("   DELETE /api/admin/evenements/{id}/inscrits/{insc_id} - Supprimer inscription")
 		log.Println("   DELETE /api/admin/evenements/{id}/inscrits/{insc_id}/accompagnant/{index} - Supprimer accompagnant")
+		log.Println("   GET    /api/admin/evenements/{id}/liste-attente - Liste d'attente")
+		log.Println("   POST   /api/admin/evenements/{id}/checkin  - Scanner un billet à l'entrée")
+		log.Println("   GET    /api/admin/evenements/{id}/checkin  - Compteur de présents")
 		log.Println("   GET    /api/admin/stats                    - Statistiques globales")
 		log.Println("   POST   /api/admin/notifications/send       - Envoyer notification admin")
+		log.Println("   GET    /api/admin/notifications/outbox     - État de livraison des notifications")
+		log.Println("   POST   /api/admin/notifications/outbox/{id}/retry - Relancer une notification abandonnée")
+		log.Println("   GET    /api/admin/settings/inscriptions    - Paramètres d'inscription")
+		log.Println("   PUT    /api/admin/settings/inscriptions    - Modifier les paramètres d'inscription")
 		log.Println("   GET    /api/admin/codes-soiree             - Liste tous les codes")
+		log.Println("   POST   /api/admin/codes-soiree             - Créer un code (quota, expiration)")
+		log.Println("   GET    /api/admin/codes-soiree/{id}        - Détail et historique d'un code")
+		log.Println("   PUT    /api/admin/codes-soiree/{id}        - Modifier un code")
 		log.Println("   POST   /api/admin/code-soiree/generate     - Générer code soirée")
 		log.Println("   GET    /api/admin/code-soiree/current      - Code soirée actuel")
 		log.Println("")
@@ -403,6 +537,9 @@ func main() {
 		log.Println("   PUT    /api/evenements/{id}/inscription       - Modifier inscription")
 		log.Println("   DELETE /api/evenements/{id}/inscription       - Se désinscrire (REST)")
 		log.Println("   DELETE /api/evenements/{id}/desinscription    - Se désinscrire (legacy)")
+		log.Println("   GET    /api/evenements/{id}/inscription/ticket - Mon billet (QR code)")
+		log.Println("   GET    /api/evenements/{id}/liste-attente     - Ma position en liste d'attente")
+		log.Println("   DELETE /api/evenements/{id}/liste-attente     - Quitter la liste d'attente")
 		log.Println("   GET    /api/mes-evenements                 - Mes événements inscrits")
 		log.Println("")
 		log.Println("   🎬 Trailers vidéo (authentifié):")
@@ -432,6 +569,9 @@ func main() {
 
 	log.Println("\n🛑 Arrêt du serveur...")
 
+	// Arrêter le worker de l'outbox (les messages en attente seront repris au redémarrage)
+	notificationOutbox.Stop()
+
 	// Arrêter le hub WebSocket proprement
 	if wsHub != nil {
 		wsHub.Shutdown()
@@ -30,7 +30,7 @@ func RequireAdmin(db *mongo.Database) func(http.Handler) http.Handler {
 			}
 
 			// Vérifier si l'utilisateur est admin
-			if user.Admin != 1 {
+			if !user.IsSuperAdmin() {
 				log.Printf("⚠️  Accès admin refusé pour: %s (admin=%d)", user.Email, user.Admin)
 				utils.RespondError(w, http.StatusForbidden, "Accès refusé - Admin uniquement")
 				return
@@ -2,17 +2,23 @@ package middleware
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"premier-an-backend/database"
 	"premier-an-backend/utils"
 	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type contextKey string
 
 const UserContextKey contextKey = "user"
 
-// Auth vérifie le token JWT
-func Auth(jwtSecret string) func(http.Handler) http.Handler {
+// Auth vérifie le token JWT et que sa session n'a pas été révoquée
+func Auth(jwtSecret string, db *mongo.Database) func(http.Handler) http.Handler {
+	sessionRepo := database.NewSessionRepository(db)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Récupérer le token depuis l'en-tête Authorization
@@ -38,6 +44,18 @@ func Auth(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Vérifier que la session est toujours active (déconnexion, suppression, changement de rôle)
+			active, err := sessionRepo.IsActive(claims.SessionID)
+			if err != nil {
+				log.Printf("Erreur vérification session: %v", err)
+				utils.RespondError(w, http.StatusInternalServerError, "Erreur serveur")
+				return
+			}
+			if !active {
+				utils.RespondError(w, http.StatusUnauthorized, "Session révoquée ou expirée")
+				return
+			}
+
 			// Ajouter les informations de l'utilisateur au contexte
 			ctx := context.WithValue(r.Context(), UserContextKey, claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
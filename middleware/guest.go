@@ -2,13 +2,18 @@ package middleware
 
 import (
 	"net/http"
+	"premier-an-backend/database"
 	"premier-an-backend/utils"
 	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Guest vérifie que l'utilisateur n'est PAS connecté
 // Si un token valide est présent, refuse l'accès
-func Guest(jwtSecret string) func(http.Handler) http.Handler {
+func Guest(jwtSecret string, db *mongo.Database) func(http.Handler) http.Handler {
+	sessionRepo := database.NewSessionRepository(db)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Récupérer le token depuis l'en-tête Authorization
@@ -31,8 +36,13 @@ func Guest(jwtSecret string) func(http.Handler) http.Handler {
 			tokenString := parts[1]
 
 			// Valider le token
-			_, err := utils.ValidateToken(tokenString, jwtSecret)
+			claims, err := utils.ValidateToken(tokenString, jwtSecret)
 			if err == nil {
+				// Une session révoquée (déconnexion) ne compte pas comme connectée
+				if active, err := sessionRepo.IsActive(claims.SessionID); err != nil || !active {
+					next.ServeHTTP(w, r)
+					return
+				}
 				// Token valide = utilisateur déjà connecté
 				utils.RespondError(w, http.StatusForbidden, "Vous êtes déjà connecté")
 				return
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"premier-an-backend/database"
+	"premier-an-backend/models"
+	"premier-an-backend/utils"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const currentUserContextKey contextKey = "current_user"
+
+// RequirePermission vérifie que l'utilisateur possède la permission demandée.
+// Pour une permission liée à un événement, l'événement est lu dans la route ({event_id} ou {id}).
+func RequirePermission(db *mongo.Database, permission string) func(http.Handler) http.Handler {
+	userRepo := database.NewUserRepository(db)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserFromContext(r.Context())
+			if claims == nil {
+				utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+				return
+			}
+
+			user, err := userRepo.FindByEmail(claims.UserID)
+			if err != nil || user == nil {
+				log.Printf("Utilisateur non trouvé: %v", err)
+				utils.RespondError(w, http.StatusUnauthorized, "Utilisateur non trouvé")
+				return
+			}
+
+			var eventID *primitive.ObjectID
+			if models.IsEventScopedPermission(permission) {
+				vars := mux.Vars(r)
+				rawID := vars["event_id"]
+				if rawID == "" {
+					rawID = vars["id"]
+				}
+				if rawID != "" {
+					id, err := primitive.ObjectIDFromHex(rawID)
+					if err != nil {
+						utils.RespondError(w, http.StatusBadRequest, "ID événement invalide")
+						return
+					}
+					eventID = &id
+				}
+			}
+
+			if !user.HasPermission(permission, eventID) {
+				log.Printf("⚠️  Permission %s refusée pour: %s", permission, user.Email)
+				utils.RespondError(w, http.StatusForbidden, "Accès refusé - permission insuffisante")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), currentUserContextKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetCurrentUser récupère l'utilisateur chargé par RequirePermission
+func GetCurrentUser(ctx context.Context) *models.User {
+	user, ok := ctx.Value(currentUserContextKey).(*models.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
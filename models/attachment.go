@@ -0,0 +1,73 @@
+package models
+
+import "strings"
+
+// Limites des pièces jointes du chat
+const (
+	MaxAttachmentsPerMessage = 5
+	MaxImageAttachmentSize   = 10 << 20 // 10 MB
+	MaxFileAttachmentSize    = 20 << 20 // 20 MB
+)
+
+// AttachmentMimeTypes types MIME acceptés, détectés à partir du contenu du fichier
+var AttachmentMimeTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"application/zip": true, // Archives et documents Office (docx, xlsx...)
+	"text/plain":      true,
+	"audio/mpeg":      true,
+	"video/mp4":       true,
+}
+
+// Attachment est un fichier joint à un message
+type Attachment struct {
+	URL          string `json:"url" bson:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" bson:"thumbnail_url,omitempty"` // Images uniquement
+	Filename     string `json:"filename" bson:"filename"`
+	MimeType     string `json:"mime_type" bson:"mime_type"`
+	Size         int64  `json:"size" bson:"size"`
+	Width        int    `json:"width,omitempty" bson:"width,omitempty"`
+	Height       int    `json:"height,omitempty" bson:"height,omitempty"`
+	StorageID    string `json:"-" bson:"storage_id"`    // Identifiant dans le stockage (suppression)
+	ResourceType string `json:"-" bson:"resource_type"` // "image" ou "raw"
+}
+
+// IsImageMimeType indique si un type MIME est une image
+func IsImageMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "image/")
+}
+
+// MaxAttachmentSize retourne la taille maximale autorisée pour un type MIME
+func MaxAttachmentSize(mimeType string) int64 {
+	if IsImageMimeType(mimeType) {
+		return MaxImageAttachmentSize
+	}
+	return MaxFileAttachmentSize
+}
+
+// AttachmentsMessageType retourne le type d'un message privé selon ses pièces jointes ("text", "image" ou "file")
+func AttachmentsMessageType(attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return "text"
+	}
+	for _, attachment := range attachments {
+		if !IsImageMimeType(attachment.MimeType) {
+			return "file"
+		}
+	}
+	return "image"
+}
+
+// ContentPreview retourne le texte d'un message pour les notifications, ou un libellé s'il ne contient que des pièces jointes
+func ContentPreview(content string, attachments []Attachment) string {
+	if content != "" || len(attachments) == 0 {
+		return content
+	}
+	if AttachmentsMessageType(attachments) == "image" {
+		return "📷 Photo"
+	}
+	return "📎 Fichier"
+}
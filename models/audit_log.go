@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions enregistrées dans le journal d'audit
+const (
+	AuditUserUpdate         = "user.update"
+	AuditUserDelete         = "user.delete"
+	AuditUserRolesUpdate    = "user.roles_update"
+	AuditEventCreate        = "event.create"
+	AuditEventUpdate        = "event.update"
+	AuditEventDelete        = "event.delete"
+	AuditInscriptionDelete  = "inscription.delete"
+	AuditAccompagnantDelete = "inscription.accompagnant_delete"
+	AuditThemeUpdate        = "settings.theme_update"
+	AuditSettingsUpdate     = "settings.update"
+	AuditTrailerUpload      = "event.trailer_upload"
+	AuditTrailerReplace     = "event.trailer_replace"
+	AuditTrailerDelete      = "event.trailer_delete"
+	AuditNotificationSend   = "notification.send"
+	AuditCodeSoireeCreate   = "code_soiree.create"
+	AuditCodeSoireeUpdate   = "code_soiree.update"
+)
+
+// AuditLog représente une action d'administration (collection "audit_log")
+type AuditLog struct {
+	ID         primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	ActorEmail string                 `json:"actor_email" bson:"actor_email"`
+	Action     string                 `json:"action" bson:"action"`
+	TargetType string                 `json:"target_type" bson:"target_type"` // "user", "event", "inscription", ...
+	TargetID   string                 `json:"target_id,omitempty" bson:"target_id,omitempty"`
+	Before     map[string]interface{} `json:"before,omitempty" bson:"before,omitempty"`
+	After      map[string]interface{} `json:"after,omitempty" bson:"after,omitempty"`
+	Changes    map[string]AuditChange `json:"changes,omitempty" bson:"changes,omitempty"` // Champs modifiés uniquement
+	IP         string                 `json:"ip" bson:"ip"`
+	UserAgent  string                 `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	CreatedAt  time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// AuditChange représente la valeur d'un champ avant et après l'action
+type AuditChange struct {
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// AuditLogFilter représente les filtres de recherche dans le journal d'audit
+type AuditLogFilter struct {
+	ActorEmail string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
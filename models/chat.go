@@ -36,6 +36,12 @@ type Message struct {
 	ReadBy         []ReadReceipt      `json:"read_by" bson:"read_by"`
 	DeliveredAt    *time.Time         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"` // Quand le message a été distribué
 	ReadAt         *time.Time         `json:"read_at,omitempty" bson:"read_at,omitempty"`           // Quand le message a été lu
+	EditedAt       *time.Time         `json:"edited_at,omitempty" bson:"edited_at,omitempty"`       // Dernière modification
+	EditHistory    []MessageEdit      `json:"edit_history,omitempty" bson:"edit_history,omitempty"` // Versions précédentes
+	DeletedAt      *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`     // Supprimé : contenu effacé
+	Reactions      []MessageReaction  `json:"-" bson:"reactions,omitempty"`                          // Une réaction par utilisateur et par emoji
+	Summary        []ReactionSummary  `json:"reactions,omitempty" bson:"-"`                         // Réactions regroupées par emoji (enrichi)
+	Attachments    []Attachment       `json:"attachments,omitempty" bson:"attachments,omitempty"`   // Pièces jointes
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
 }
 
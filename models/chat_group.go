@@ -8,12 +8,15 @@ import (
 
 // ChatGroup représente un groupe de chat
 type ChatGroup struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Name      string             `json:"name" bson:"name"`
-	CreatedBy string             `json:"created_by" bson:"created_by"` // User ID (email)
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
-	IsActive  bool               `json:"is_active" bson:"is_active"`
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	AvatarURL   string             `json:"avatar_url,omitempty" bson:"avatar_url,omitempty"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"` // User ID (email)
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ArchivedAt  *time.Time         `json:"archived_at,omitempty" bson:"archived_at,omitempty"` // Archivé : lecture seule
+	IsActive    bool               `json:"is_active" bson:"is_active"`                         // false : groupe dissous
 }
 
 // ChatGroupMember représente un membre d'un groupe
@@ -48,6 +51,16 @@ type ChatGroupMessage struct {
 	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
 	DeliveredAt *time.Time         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
 	ReadBy      []string           `json:"read_by" bson:"read_by"` // Liste d'emails qui ont lu
+	EditedAt    *time.Time         `json:"edited_at,omitempty" bson:"edited_at,omitempty"`       // Dernière modification
+	EditHistory []MessageEdit      `json:"edit_history,omitempty" bson:"edit_history,omitempty"` // Versions précédentes
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`     // Supprimé : contenu effacé
+	Reactions   []MessageReaction  `json:"-" bson:"reactions,omitempty"`                          // Une réaction par utilisateur et par emoji
+	Summary     []ReactionSummary  `json:"reactions,omitempty" bson:"-"`                         // Réactions regroupées par emoji (enrichi)
+	ReplyToID   *primitive.ObjectID `json:"reply_to,omitempty" bson:"reply_to,omitempty"`       // Message cité
+	ThreadID    *primitive.ObjectID `json:"thread_id,omitempty" bson:"thread_id,omitempty"`     // Premier message du fil
+	ReplyCount  int                 `json:"reply_count,omitempty" bson:"reply_count,omitempty"` // Nombre de réponses (premier message du fil)
+	Mentions    []Mention           `json:"mentions,omitempty" bson:"mentions,omitempty"`       // Membres mentionnés (@prénom, @email)
+	Attachments []Attachment        `json:"attachments,omitempty" bson:"attachments,omitempty"` // Pièces jointes
 }
 
 // ChatGroupReadReceipt représente le statut de lecture d'un utilisateur dans un groupe
@@ -83,6 +96,7 @@ type RespondToGroupInvitationRequest struct {
 // SendGroupMessageRequest pour envoyer un message
 type SendGroupMessageRequest struct {
 	Content string `json:"content"`
+	ReplyTo string `json:"reply_to,omitempty"` // ID du message auquel on répond
 }
 
 // UserBasicInfo informations de base d'un utilisateur
@@ -121,13 +135,17 @@ type GroupBasicInfo struct {
 
 // GroupWithDetails groupe avec tous les détails pour la liste
 type GroupWithDetails struct {
-	ID           string                 `json:"id"`
-	Name         string                 `json:"name"`
-	CreatedBy    GroupCreatorInfo       `json:"created_by"`
-	MemberCount  int                    `json:"member_count"`
-	UnreadCount  int                    `json:"unread_count"`
-	LastMessage  *GroupLastMessageInfo  `json:"last_message"`
-	CreatedAt    time.Time              `json:"created_at"`
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description,omitempty"`
+	AvatarURL      string                `json:"avatar_url,omitempty"`
+	ArchivedAt     *time.Time            `json:"archived_at,omitempty"`
+	CreatedBy      GroupCreatorInfo      `json:"created_by"`
+	MemberCount    int                   `json:"member_count"`
+	UnreadCount    int                   `json:"unread_count"`
+	UnreadMentions int                   `json:"unread_mentions"` // Messages non lus qui mentionnent l'utilisateur
+	LastMessage    *GroupLastMessageInfo `json:"last_message"`
+	CreatedAt      time.Time             `json:"created_at"`
 }
 
 // GroupCreatorInfo informations du créateur du groupe
@@ -171,6 +189,16 @@ type GroupMessageWithSender struct {
 	CreatedAt   time.Time          `json:"created_at"`
 	DeliveredAt *time.Time         `json:"delivered_at,omitempty"`
 	ReadBy      []string           `json:"read_by"`
+	EditedAt    *time.Time         `json:"edited_at,omitempty"`
+	EditHistory []MessageEdit      `json:"edit_history,omitempty"`
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty"`
+	Reactions   []ReactionSummary  `json:"reactions,omitempty"`
+	ReplyToID   *primitive.ObjectID `json:"reply_to,omitempty"`
+	ThreadID    *primitive.ObjectID `json:"thread_id,omitempty"`
+	ReplyCount  int                 `json:"reply_count,omitempty"`
+	Quoted      *MessageQuote       `json:"quoted_message,omitempty"` // Aperçu du message cité
+	Mentions    []Mention           `json:"mentions,omitempty"`
+	Attachments []Attachment        `json:"attachments,omitempty"`
 }
 
 // PendingInvitationWithUser invitation en attente avec infos utilisateur
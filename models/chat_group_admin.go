@@ -0,0 +1,41 @@
+package models
+
+// Rôles des membres d'un groupe
+const (
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
+// Limites des informations d'un groupe
+const (
+	MaxGroupNameLength        = 100
+	MaxGroupDescriptionLength = 500
+)
+
+// Actions d'administration diffusées dans l'événement WebSocket group_updated
+const (
+	GroupActionUpdated              = "updated"
+	GroupActionMemberRoleChanged    = "member_role_changed"
+	GroupActionMemberRemoved        = "member_removed"
+	GroupActionOwnershipTransferred = "ownership_transferred"
+	GroupActionArchived             = "archived"
+	GroupActionUnarchived           = "unarchived"
+	GroupActionDissolved            = "dissolved"
+)
+
+// UpdateGroupRequest pour renommer un groupe ou changer sa photo et sa description (champs absents inchangés)
+type UpdateGroupRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"` // "" pour retirer la photo
+}
+
+// UpdateMemberRoleRequest pour promouvoir ou rétrograder un membre
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role"` // "admin" ou "member"
+}
+
+// TransferOwnershipRequest pour transférer la propriété d'un groupe à un autre membre
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id"` // Email du nouveau propriétaire
+}
@@ -6,12 +6,41 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// CodeSoiree représente un code de soirée
+// CodeSoiree représente un code d'invitation (collection "codes_soiree")
 type CodeSoiree struct {
-	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Code         string             `json:"code" bson:"code"`
-	Utilisations int                `json:"utilisations" bson:"utilisations"`
-	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
-	Active       bool               `json:"active" bson:"active"`
+	ID              primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Code            string               `json:"code" bson:"code"`
+	Label           string               `json:"label,omitempty" bson:"label,omitempty"`   // Groupe d'invités, ex: "Amis de X"
+	EventIDs        []primitive.ObjectID `json:"event_ids" bson:"event_ids"`               // Événements auxquels le code donne accès
+	MaxUtilisations int                  `json:"max_utilisations" bson:"max_utilisations"` // 0 = illimité
+	Utilisations    int                  `json:"utilisations" bson:"utilisations"`
+	ExpiresAt       *time.Time           `json:"expires_at,omitempty" bson:"expires_at,omitempty"` // nil = sans expiration
+	Historique      []CodeSoireeUsage    `json:"historique,omitempty" bson:"historique"`
+	CreatedBy       string               `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt       time.Time            `json:"created_at" bson:"created_at"`
+	Active          bool                 `json:"active" bson:"active"`
 }
 
+// CodeSoireeUsage représente une inscription effectuée avec un code
+type CodeSoireeUsage struct {
+	UserEmail string    `json:"user_email" bson:"user_email"`
+	UsedAt    time.Time `json:"used_at" bson:"used_at"`
+}
+
+// CreateCodeSoireeRequest représente la création d'un code par un admin
+type CreateCodeSoireeRequest struct {
+	Code            string        `json:"code"` // Généré si vide
+	Label           string        `json:"label"`
+	EventIDs        []string      `json:"event_ids"`
+	MaxUtilisations int           `json:"max_utilisations"`
+	ExpiresAt       *FlexibleTime `json:"expires_at"`
+}
+
+// UpdateCodeSoireeRequest représente la modification d'un code par un admin
+type UpdateCodeSoireeRequest struct {
+	Label           *string       `json:"label"`
+	EventIDs        []string      `json:"event_ids"`
+	MaxUtilisations *int          `json:"max_utilisations"`
+	ExpiresAt       *FlexibleTime `json:"expires_at"`
+	Active          *bool         `json:"active"`
+}
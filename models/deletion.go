@@ -0,0 +1,42 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// DeletedUserID remplace l'identifiant d'un utilisateur supprimé dans les données conservées
+// (messages de groupe, historique des codes de soirée)
+const DeletedUserID = "utilisateur-supprime"
+
+// DeletedUserObjectID remplace l'ObjectID d'un utilisateur supprimé dans les conversations privées conservées
+var DeletedUserObjectID = primitive.NilObjectID
+
+// DeletedUserInfo retourne l'utilisateur affiché à la place d'un compte supprimé
+func DeletedUserInfo() *UserInfo {
+	return &UserInfo{
+		ID:        DeletedUserID,
+		Firstname: "Utilisateur",
+		Lastname:  "supprimé",
+	}
+}
+
+// DeletionReport décrit ce qu'a fait une suppression en cascade
+type DeletionReport struct {
+	TargetType         string           `json:"target_type"` // "user" ou "event"
+	TargetID           string           `json:"target_id"`
+	Deleted            map[string]int64 `json:"deleted"`             // Documents supprimés, par collection
+	Anonymized         map[string]int64 `json:"anonymized"`          // Documents anonymisés, par collection
+	CloudinaryDeleted  int              `json:"cloudinary_deleted"`  // Fichiers supprimés de Cloudinary
+	EventsRecalculated []string         `json:"events_recalculated"` // Événements dont le compteur d'inscrits a été recalculé
+	Warnings           []string         `json:"warnings"`            // Étapes non bloquantes en échec
+}
+
+// NewDeletionReport crée un rapport vide
+func NewDeletionReport(targetType, targetID string) *DeletionReport {
+	return &DeletionReport{
+		TargetType:         targetType,
+		TargetID:           targetID,
+		Deleted:            map[string]int64{},
+		Anonymized:         map[string]int64{},
+		EventsRecalculated: []string{},
+		Warnings:           []string{},
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EmailVerificationToken représente un lien de vérification d'adresse e-mail.
+// Seule l'empreinte du token est stockée ; le token en clair n'existe que dans l'e-mail.
+type EmailVerificationToken struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserEmail string             `json:"user_email" bson:"user_email"`
+	TokenHash string             `json:"-" bson:"token_hash"`
+	ExpiresAt time.Time          `json:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time         `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// VerifyEmailRequest représente la confirmation d'adresse avec le token reçu par e-mail
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
@@ -8,9 +8,10 @@ import (
 
 // Accompagnant représente une personne accompagnant l'utilisateur principal
 type Accompagnant struct {
-	Firstname string `json:"firstname" bson:"firstname"`
-	Lastname  string `json:"lastname" bson:"lastname"`
-	IsAdult   bool   `json:"is_adult" bson:"is_adult"`
+	Firstname   string     `json:"firstname" bson:"firstname"`
+	Lastname    string     `json:"lastname" bson:"lastname"`
+	IsAdult     bool       `json:"is_adult" bson:"is_adult"`
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty" bson:"checked_in_at,omitempty"` // Présence à l'entrée
 }
 
 // Inscription représente l'inscription d'un utilisateur à un événement
@@ -20,6 +21,7 @@ type Inscription struct {
 	UserEmail       string             `json:"user_email" bson:"user_email"`
 	NombrePersonnes int                `json:"nombre_personnes" bson:"nombre_personnes"`
 	Accompagnants   []Accompagnant     `json:"accompagnants" bson:"accompagnants"`
+	CheckedInAt     *time.Time         `json:"checked_in_at,omitempty" bson:"checked_in_at,omitempty"` // Présence à l'entrée
 	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
 }
@@ -29,6 +31,7 @@ type CreateInscriptionRequest struct {
 	UserEmail       string         `json:"user_email"`
 	NombrePersonnes int            `json:"nombre_personnes"`
 	Accompagnants   []Accompagnant `json:"accompagnants"`
+	ListeAttente    bool           `json:"liste_attente"` // Rejoindre la liste d'attente si l'événement est complet
 }
 
 // UpdateInscriptionRequest représente la requête de modification d'inscription
@@ -51,7 +54,13 @@ type InscriptionWithUserInfo struct {
 	UserPhone       string         `json:"user_phone"`
 	NombrePersonnes int            `json:"nombre_personnes"`
 	Accompagnants   []Accompagnant `json:"accompagnants"`
+	CheckedInAt     *time.Time     `json:"checked_in_at,omitempty"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 }
 
+// CheckinRequest représente le scan d'un billet à l'entrée
+type CheckinRequest struct {
+	Token string `json:"token"`
+}
+
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Mention est la mention d'un membre dans un message de groupe
+type Mention struct {
+	UserID string `json:"user_id" bson:"user_id"` // Email du membre mentionné
+	Text   string `json:"text" bson:"text"`       // Tel qu'écrit dans le message (ex. "@Alice")
+}
+
+// mentionPattern reconnaît @prénom ou @email, en début de texte ou après un caractère qui ne fait pas partie d'un mot
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.+\-]+(?:@[\p{L}\p{N}\-]+(?:\.[\p{L}\p{N}\-]+)+)?)`)
+
+// ParseMentions extrait les mentions d'un message et les valide contre les membres du groupe.
+// @email désigne le membre ayant cet email ; @prénom n'est retenu que si un seul membre porte ce prénom.
+// Les mentions inconnues ou ambiguës sont ignorées, chaque membre n'est mentionné qu'une fois.
+func ParseMentions(content string, members []GroupMemberWithDetails) []Mention {
+	var mentions []Mention
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		token := strings.TrimRight(match[1], ".-")
+		if token == "" {
+			continue
+		}
+
+		userID := resolveMention(token, members)
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		mentions = append(mentions, Mention{UserID: userID, Text: "@" + token})
+	}
+
+	return mentions
+}
+
+// resolveMention retrouve le membre désigné par une mention (email ou prénom)
+func resolveMention(token string, members []GroupMemberWithDetails) string {
+	if strings.Contains(token, "@") {
+		for _, member := range members {
+			if strings.EqualFold(member.Email, token) {
+				return member.ID
+			}
+		}
+		return ""
+	}
+
+	userID := ""
+	for _, member := range members {
+		if member.Firstname != "" && strings.EqualFold(member.Firstname, token) {
+			if userID != "" {
+				return "" // Prénom porté par plusieurs membres
+			}
+			userID = member.ID
+		}
+	}
+	return userID
+}
+
+// IsMentioned indique si un utilisateur est mentionné
+func IsMentioned(mentions []Mention, userID string) bool {
+	for _, mention := range mentions {
+		if mention.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MessageEditWindow est le délai pendant lequel l'auteur peut modifier ou supprimer son message
+const MessageEditWindow = 15 * time.Minute
+
+// MessageEdit est une version précédente d'un message modifié
+type MessageEdit struct {
+	Content  string    `json:"content" bson:"content"`
+	EditedAt time.Time `json:"edited_at" bson:"edited_at"` // Date à laquelle cette version a été remplacée
+}
+
+// EditMessageRequest représente la modification d'un message (conversation ou groupe)
+type EditMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// WithinEditWindow indique si un message envoyé à createdAt peut encore être modifié ou supprimé
+func WithinEditWindow(createdAt time.Time) bool {
+	return time.Since(createdAt) <= MessageEditWindow
+}
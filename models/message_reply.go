@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuotePreviewLength nombre maximal de caractères du message cité repris dans l'aperçu
+const QuotePreviewLength = 120
+
+// MessageQuote aperçu du message auquel un message de groupe répond
+type MessageQuote struct {
+	ID          primitive.ObjectID `json:"id"`
+	SenderID    string             `json:"sender_id"`
+	Sender      *UserBasicInfo     `json:"sender,omitempty"`
+	Content     string             `json:"content"` // Tronqué ("📷 Photo" si seulement des pièces jointes), vide si le message cité a été supprimé
+	MessageType string             `json:"message_type"`
+	CreatedAt   time.Time          `json:"created_at"`
+	DeletedAt   *time.Time         `json:"deleted_at,omitempty"`
+}
+
+// NewMessageQuote construit l'aperçu d'un message cité (sender peut être nil : compte supprimé, message système)
+func NewMessageQuote(message *ChatGroupMessage, sender *User) *MessageQuote {
+	quote := &MessageQuote{
+		ID:          message.ID,
+		SenderID:    message.SenderID,
+		Content:     QuotePreview(ContentPreview(message.Content, message.Attachments)),
+		MessageType: message.MessageType,
+		CreatedAt:   message.CreatedAt,
+		DeletedAt:   message.DeletedAt,
+	}
+	if sender != nil {
+		quote.Sender = &UserBasicInfo{
+			ID:        sender.Email,
+			Firstname: sender.Firstname,
+			Lastname:  sender.Lastname,
+		}
+	}
+	return quote
+}
+
+// QuotePreview tronque un contenu à QuotePreviewLength caractères
+func QuotePreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= QuotePreviewLength {
+		return content
+	}
+	return string(runes[:QuotePreviewLength-1]) + "…"
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limites de la recherche dans l'historique du chat
+const (
+	DefaultSearchLimit  = 20
+	MaxSearchLimit      = 50
+	MinSearchLength     = 2
+	SearchSnippetLength = 160 // Caractères de contenu repris dans l'extrait
+	searchSnippetLead   = 40  // Caractères conservés avant le premier terme trouvé
+)
+
+// Sources des résultats de recherche
+const (
+	SearchSourceConversation = "conversation"
+	SearchSourceGroup        = "group"
+)
+
+// MessageSearchFilter critères communs de recherche de messages
+type MessageSearchFilter struct {
+	Query  string              // Syntaxe MongoDB $text : mots, "phrase exacte", -exclusion
+	From   *time.Time          // Messages envoyés à partir de cette date
+	To     *time.Time          // Messages envoyés avant cette date
+	Before *primitive.ObjectID // Curseur : messages plus anciens que cet ID
+	Limit  int
+}
+
+// MessageSearchResult message trouvé par la recherche, avec un extrait surligné
+type MessageSearchResult struct {
+	ID             primitive.ObjectID `json:"id"`
+	Source         string             `json:"source"` // "conversation" ou "group"
+	ConversationID string             `json:"conversation_id,omitempty"`
+	GroupID        string             `json:"group_id,omitempty"`
+	GroupName      string             `json:"group_name,omitempty"`
+	SenderID       string             `json:"sender_id"` // ID (conversation) ou email (groupe), comme dans les messages
+	Sender         *UserBasicInfo     `json:"sender,omitempty"`
+	Content        string             `json:"content"`
+	Snippet        string             `json:"snippet"` // HTML échappé, termes trouvés entre <mark></mark>
+	Attachments    []Attachment       `json:"attachments,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+// searchTermPattern reconnaît une phrase entre guillemets ou un mot
+var searchTermPattern = regexp.MustCompile(`"([^"]+)"|(\S+)`)
+
+// SearchTerms extrait les termes à surligner d'une requête (les exclusions "-mot" sont ignorées).
+// Le pluriel simple est retiré pour surligner aussi les variantes d'un mot (décision, décisions).
+func SearchTerms(query string) []string {
+	var terms []string
+	for _, match := range searchTermPattern.FindAllStringSubmatch(query, -1) {
+		term := match[1]
+		if term == "" {
+			term = match[2]
+			if strings.HasPrefix(term, "-") {
+				continue
+			}
+			if len([]rune(term)) > 3 {
+				term = strings.TrimRight(term, "sx")
+			}
+		}
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// HighlightSnippet retourne un extrait du contenu centré sur le premier terme trouvé.
+// L'extrait est échappé pour l'HTML et les mots commençant par un terme sont entourés de <mark></mark>.
+func HighlightSnippet(content string, terms []string) string {
+	runes := []rune(content)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// Repérer les mots qui commencent par un des termes
+	type span struct{ start, end int }
+	var spans []span
+	for i := 0; i < len(lower); i++ {
+		if i > 0 && isWordRune(lower[i-1]) {
+			continue
+		}
+		for _, term := range terms {
+			termRunes := []rune(term)
+			if !hasRunePrefix(lower[i:], termRunes) {
+				continue
+			}
+			end := i + len(termRunes)
+			for end < len(lower) && isWordRune(lower[end]) {
+				end++
+			}
+			spans = append(spans, span{i, end})
+			i = end - 1
+			break
+		}
+	}
+
+	// Fenêtre de l'extrait
+	start := 0
+	if len(spans) > 0 && spans[0].start > searchSnippetLead {
+		start = spans[0].start - searchSnippetLead
+		for start < spans[0].start && isWordRune(runes[start-1]) {
+			start++
+		}
+	}
+	end := start + SearchSnippetLength
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	position := start
+	for _, s := range spans {
+		if s.start >= end {
+			break
+		}
+		if s.start < position {
+			continue
+		}
+		spanEnd := s.end
+		if spanEnd > end {
+			spanEnd = end
+		}
+		snippet.WriteString(html.EscapeString(string(runes[position:s.start])))
+		snippet.WriteString("<mark>")
+		snippet.WriteString(html.EscapeString(string(runes[s.start:spanEnd])))
+		snippet.WriteString("</mark>")
+		position = spanEnd
+	}
+	snippet.WriteString(html.EscapeString(string(runes[position:end])))
+	if end < len(runes) {
+		snippet.WriteString("…")
+	}
+	return snippet.String()
+}
+
+// isWordRune indique si un caractère fait partie d'un mot
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// hasRunePrefix indique si s commence par prefix
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(prefix) == 0 || len(s) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if s[i] != r {
+			return false
+		}
+	}
+	return true
+}
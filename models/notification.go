@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limites du centre de notifications
+const (
+	DefaultNotificationLimit = 20
+	MaxNotificationLimit     = 50
+)
+
+// NotificationTypeGeneral type des notifications dont les données ne précisent ni "type" ni "action"
+const NotificationTypeGeneral = "general"
+
+// Notification notification du centre de notifications, enregistrée à chaque push envoyé à un utilisateur
+type Notification struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    string             `json:"user_id" bson:"user_id"` // Email du destinataire
+	Type      string             `json:"type" bson:"type"`       // Champ "type" (ou "action") des données du push
+	Title     string             `json:"title" bson:"title"`
+	Body      string             `json:"body" bson:"body"`
+	Data      map[string]string  `json:"data,omitempty" bson:"data,omitempty"` // Données du push (liens, IDs)
+	Read      bool               `json:"read" bson:"read"`
+	ReadAt    *time.Time         `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// NotificationType déduit le type d'une notification à partir des données du push
+func NotificationType(data map[string]string) string {
+	if data["type"] != "" {
+		return data["type"]
+	}
+	if data["action"] != "" {
+		return data["action"]
+	}
+	return NotificationTypeGeneral
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuts d'un message de l'outbox de notifications
+const (
+	OutboxStatusPending    = "pending"    // En attente d'envoi (premier essai ou nouvel essai programmé)
+	OutboxStatusProcessing = "processing" // Pris en charge par un worker (verrouillé jusqu'à locked_until)
+	OutboxStatusSent       = "sent"       // Envoyé
+	OutboxStatusDead       = "dead"       // Abandonné après le nombre maximal d'essais (relance manuelle possible)
+)
+
+// DefaultOutboxMaxAttempts nombre d'essais par défaut avant abandon d'un message
+const DefaultOutboxMaxAttempts = 5
+
+// Limites de la liste des messages de l'outbox (admin)
+const (
+	DefaultOutboxLimit = 20
+	MaxOutboxLimit     = 100
+)
+
+// OutboxMessage notification push enregistrée avant envoi, livrée par le worker de l'outbox avec relances
+type OutboxMessage struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	IdempotencyKey string             `json:"idempotency_key" bson:"idempotency_key"`                     // Une seule notification par clé (ex: "gallery_upload:<media_id>")
+	Category       string             `json:"category" bson:"category"`                                   // Catégorie de préférences (NotificationCategory*)
+	ConversationID string             `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"` // Réglages de la conversation (message privé)
+	HighPriority   bool               `json:"high_priority" bson:"high_priority"`                         // Réveille l'appareil (événement urgent)
+	Recipients     []string           `json:"recipients" bson:"recipients"`                               // Emails des destinataires, tokens résolus à chaque essai
+	Title          string             `json:"title" bson:"title"`
+	Body           string             `json:"body" bson:"body"`
+	Data           map[string]string  `json:"data,omitempty" bson:"data,omitempty"`
+	Status         string             `json:"status" bson:"status"`
+	Attempts       int                `json:"attempts" bson:"attempts"`
+	MaxAttempts    int                `json:"max_attempts" bson:"max_attempts"`
+	NextAttemptAt  time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil    *time.Time         `json:"-" bson:"locked_until,omitempty"`
+	LastError      string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	Delivered      int                `json:"delivered" bson:"delivered"` // Tokens atteints lors du dernier essai
+	Failed         int                `json:"failed" bson:"failed"`       // Tokens en échec lors du dernier essai
+	Recorded       bool               `json:"recorded" bson:"recorded"`   // Déjà enregistrée dans le centre de notifications
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+	SentAt         *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+}
+
+// IsValidOutboxStatus indique si le statut existe (filtre de la liste admin)
+func IsValidOutboxStatus(status string) bool {
+	switch status {
+	case OutboxStatusPending, OutboxStatusProcessing, OutboxStatusSent, OutboxStatusDead:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // Fuseaux horaires embarqués : les heures calmes ne dépendent pas du système
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Catégories de notifications push soumises aux préférences
+const (
+	NotificationCategoryGeneral        = "general"         // Sans réglage dédié (admin, alertes, liste d'attente…) : seuls la sourdine et les heures calmes s'appliquent
+	NotificationCategoryChat           = "chat"            // Messages privés et de groupe : réglages par conversation / groupe
+	NotificationCategoryEventOpening   = "event_opening"   // Ouverture des inscriptions d'un événement
+	NotificationCategoryGalleryUpload  = "gallery_upload"  // Nouveaux médias dans une galerie
+	NotificationCategoryNewInscription = "new_inscription" // Nouvelles inscriptions (admins)
+)
+
+// NotificationPreferences préférences de notifications push d'un utilisateur.
+// Sans document, toutes les notifications sont envoyées (DefaultNotificationPreferences).
+type NotificationPreferences struct {
+	ID            primitive.ObjectID                 `json:"-" bson:"_id,omitempty"`
+	UserID        string                             `json:"user_id" bson:"user_id"` // Email de l'utilisateur
+	Muted         bool                               `json:"muted" bson:"muted"`     // Toutes les notifications coupées jusqu'à réactivation
+	MutedUntil    *time.Time                         `json:"muted_until,omitempty" bson:"muted_until,omitempty"`
+	QuietHours    *QuietHours                        `json:"quiet_hours,omitempty" bson:"quiet_hours,omitempty"`
+	Categories    NotificationCategories             `json:"categories" bson:"categories"`
+	Conversations map[string]ChatNotificationSetting `json:"conversations" bson:"conversations,omitempty"` // Par ID de conversation
+	Groups        map[string]ChatNotificationSetting `json:"groups" bson:"groups,omitempty"`               // Par ID de groupe
+	UpdatedAt     time.Time                          `json:"updated_at" bson:"updated_at"`
+}
+
+// NotificationCategories catégories activées
+type NotificationCategories struct {
+	EventOpenings   bool `json:"event_openings" bson:"event_openings"`
+	GalleryUploads  bool `json:"gallery_uploads" bson:"gallery_uploads"`
+	NewInscriptions bool `json:"new_inscriptions" bson:"new_inscriptions"`
+}
+
+// DefaultNotificationCategories toutes les catégories activées
+var DefaultNotificationCategories = NotificationCategories{
+	EventOpenings:   true,
+	GalleryUploads:  true,
+	NewInscriptions: true,
+}
+
+// QuietHours plage horaire quotidienne sans notification (« ne pas déranger »), dans le fuseau de l'utilisateur.
+// La plage peut passer minuit (22:00 → 07:00).
+type QuietHours struct {
+	Start    string `json:"start" bson:"start"`       // "HH:MM"
+	End      string `json:"end" bson:"end"`           // "HH:MM"
+	Timezone string `json:"timezone" bson:"timezone"` // Fuseau IANA, ex. "Europe/Paris"
+}
+
+// ChatNotificationSetting réglage d'une conversation ou d'un groupe
+type ChatNotificationSetting struct {
+	Muted        bool       `json:"muted" bson:"muted"` // Sourdine jusqu'à réactivation
+	MutedUntil   *time.Time `json:"muted_until,omitempty" bson:"muted_until,omitempty"`
+	MentionsOnly bool       `json:"mentions_only" bson:"mentions_only"` // Groupes : seules les mentions sont notifiées
+}
+
+// NotificationContext décrit une notification à évaluer contre les préférences du destinataire
+type NotificationContext struct {
+	Category       string
+	ConversationID string // Message privé
+	GroupID        string // Message de groupe
+	Mention        bool   // Destinataire mentionné : passe outre la sourdine du groupe et le mode « mentions uniquement »
+}
+
+// UpdateNotificationPreferencesRequest modification des préférences globales (champs absents inchangés)
+type UpdateNotificationPreferencesRequest struct {
+	Muted      *bool                   `json:"muted"`       // Avec muted_until, remplace la sourdine actuelle
+	MutedUntil *time.Time              `json:"muted_until"` // Sourdine jusqu'à cette date
+	QuietHours *QuietHours             `json:"quiet_hours"` // {} (start et end vides) pour désactiver
+	Categories *NotificationCategories `json:"categories"`
+}
+
+// DefaultNotificationPreferences préférences d'un utilisateur qui n'a rien configuré
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:        userID,
+		Categories:    DefaultNotificationCategories,
+		Conversations: map[string]ChatNotificationSetting{},
+		Groups:        map[string]ChatNotificationSetting{},
+	}
+}
+
+// Allows indique si la notification doit être envoyée à l'instant now.
+// La sourdine globale et les heures calmes s'appliquent à tout, y compris aux mentions.
+func (p *NotificationPreferences) Allows(n NotificationContext, now time.Time) bool {
+	if p == nil {
+		return true
+	}
+	if isMutedAt(p.Muted, p.MutedUntil, now) || p.QuietHours.ActiveAt(now) {
+		return false
+	}
+
+	switch n.Category {
+	case NotificationCategoryEventOpening:
+		return p.Categories.EventOpenings
+	case NotificationCategoryGalleryUpload:
+		return p.Categories.GalleryUploads
+	case NotificationCategoryNewInscription:
+		return p.Categories.NewInscriptions
+	case NotificationCategoryChat:
+		var setting ChatNotificationSetting
+		var found bool
+		if n.GroupID != "" {
+			setting, found = p.Groups[n.GroupID]
+		} else if n.ConversationID != "" {
+			setting, found = p.Conversations[n.ConversationID]
+		}
+		if !found || n.Mention {
+			return true
+		}
+		return !setting.IsMutedAt(now) && !setting.MentionsOnly
+	}
+	return true
+}
+
+// IsMutedAt indique si la conversation ou le groupe est en sourdine à l'instant now
+func (s ChatNotificationSetting) IsMutedAt(now time.Time) bool {
+	return isMutedAt(s.Muted, s.MutedUntil, now)
+}
+
+// isMutedAt sourdine permanente, ou jusqu'à une date pas encore atteinte
+func isMutedAt(muted bool, until *time.Time, now time.Time) bool {
+	return muted || (until != nil && now.Before(*until))
+}
+
+// Validate vérifie le format des heures et le fuseau horaire
+func (q *QuietHours) Validate() error {
+	if _, err := parseClock(q.Start); err != nil {
+		return errors.New("heure de début invalide (HH:MM)")
+	}
+	if _, err := parseClock(q.End); err != nil {
+		return errors.New("heure de fin invalide (HH:MM)")
+	}
+	if q.Timezone == "" {
+		return errors.New("fuseau horaire requis (ex. Europe/Paris)")
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return fmt.Errorf("fuseau horaire inconnu : %s", q.Timezone)
+	}
+	return nil
+}
+
+// ActiveAt indique si l'instant now tombe dans les heures calmes
+func (q *QuietHours) ActiveAt(now time.Time) bool {
+	if q == nil {
+		return false
+	}
+	start, errStart := parseClock(q.Start)
+	end, errEnd := parseClock(q.End)
+	location, errLocation := time.LoadLocation(q.Timezone)
+	if errStart != nil || errEnd != nil || errLocation != nil || start == end {
+		return false
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock convertit "HH:MM" en minutes depuis minuit
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
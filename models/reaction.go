@@ -0,0 +1,180 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageReaction est la réaction d'un utilisateur à un message (stockée sur le message)
+type MessageReaction struct {
+	Emoji     string    `json:"emoji" bson:"emoji"`
+	UserID    string    `json:"user_id" bson:"user_id"` // ObjectID (conversation privée) ou email (groupe)
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ReactionSummary regroupe les réactions d'un message par emoji
+type ReactionSummary struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+}
+
+// ReactionRequest représente l'ajout d'une réaction
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// maxReactionLength taille maximale d'un emoji en octets (séquences ZWJ comprises)
+const maxReactionLength = 32
+
+// Composants des séquences emoji
+const (
+	variationSelector = '\uFE0F'     // Présentation emoji
+	zeroWidthJoiner   = '\u200D'     // Liaison des séquences ZWJ (👨‍👩‍👧)
+	combiningKeycap   = '\u20E3'     // Touche des emojis keycap (1️⃣)
+	cancelTag         = '\U000E007F' // Fin des drapeaux de subdivision (🏴󠁧󠁢󠁥󠁮󠁧󠁿)
+)
+
+// ValidReactionEmoji vérifie qu'une réaction est un seul emoji : pictogramme, éventuellement suivi d'un sélecteur
+// de variante et d'une couleur de peau, séquence ZWJ de pictogrammes, drapeau ou keycap (chiffre, # ou * puis U+20E3).
+// Le texte (lettres accentuées, idéogrammes, chiffres seuls) est refusé.
+func ValidReactionEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxReactionLength || !utf8.ValidString(emoji) {
+		return false
+	}
+
+	runes := []rune(emoji)
+	i := 0
+	for {
+		next, ok := emojiElement(runes, i)
+		if !ok {
+			return false
+		}
+		i = next
+		if i == len(runes) {
+			return true
+		}
+
+		// Séquence ZWJ : un autre élément doit suivre
+		if runes[i] != zeroWidthJoiner {
+			return false
+		}
+		i++
+	}
+}
+
+// emojiElement lit un élément emoji à partir de runes[i] et retourne la position qui le suit
+func emojiElement(runes []rune, i int) (int, bool) {
+	if i >= len(runes) {
+		return i, false
+	}
+	r := runes[i]
+	i++
+
+	switch {
+	case isRegionalIndicator(r):
+		// Drapeau : deux indicateurs régionaux
+		if i >= len(runes) || !isRegionalIndicator(runes[i]) {
+			return i, false
+		}
+		return i + 1, true
+
+	case strings.ContainsRune("0123456789#*", r):
+		// Keycap : le sélecteur de variante est facultatif, U+20E3 obligatoire
+		if i < len(runes) && runes[i] == variationSelector {
+			i++
+		}
+		if i >= len(runes) || runes[i] != combiningKeycap {
+			return i, false
+		}
+		return i + 1, true
+
+	case isPictograph(r):
+		// Sélecteur de variante et couleur de peau facultatifs, au plus une fois chacun
+		variation, skinTone := false, false
+		for i < len(runes) {
+			switch {
+			case runes[i] == variationSelector && !variation:
+				variation = true
+			case isSkinTone(runes[i]) && !skinTone:
+				skinTone = true
+			default:
+				return emojiTags(runes, i)
+			}
+			i++
+		}
+		return i, true
+	}
+
+	return i, false
+}
+
+// emojiTags lit les étiquettes d'un drapeau de subdivision (U+E0020 à U+E007E puis U+E007F), s'il y en a
+func emojiTags(runes []rune, i int) (int, bool) {
+	if runes[i] < 0xE0020 || runes[i] > 0xE007E {
+		return i, true
+	}
+	for i < len(runes) && runes[i] >= 0xE0020 && runes[i] <= 0xE007E {
+		i++
+	}
+	if i >= len(runes) || runes[i] != cancelTag {
+		return i, false
+	}
+	return i + 1, true
+}
+
+// isRegionalIndicator indique si r est une lettre de drapeau (🇦 à 🇿)
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isSkinTone indique si r est un modificateur de couleur de peau (🏻 à 🏿)
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+// isPictograph indique si r est un pictogramme emoji (hors indicateurs régionaux et couleurs de peau)
+func isPictograph(r rune) bool {
+	if isRegionalIndicator(r) || isSkinTone(r) {
+		return false
+	}
+
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictogrammes, émoticônes, transports, symboles étendus
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Symboles divers et dingbats (☀️, ❤️, ✅)
+		return true
+	case r >= 0x2190 && r <= 0x21FF, r >= 0x2300 && r <= 0x23FF, r >= 0x25A0 && r <= 0x25FF: // Flèches, techniques, formes (↩️, ⌚, ▶️)
+		return true
+	case r >= 0x2900 && r <= 0x297F, r >= 0x2B00 && r <= 0x2BFF: // Flèches supplémentaires (⤴️, ⬆️, ⭐)
+		return true
+	}
+
+	switch r {
+	case 0x00A9, 0x00AE, 0x203C, 0x2049, 0x2122, 0x2139, 0x24C2, 0x3030, 0x303D, 0x3297, 0x3299:
+		return true
+	}
+	return false
+}
+
+// SummarizeReactions regroupe les réactions par emoji, dans l'ordre de la première réaction
+func SummarizeReactions(reactions []MessageReaction) []ReactionSummary {
+	if len(reactions) == 0 {
+		return nil
+	}
+
+	summaries := []ReactionSummary{}
+	index := make(map[string]int)
+	for _, reaction := range reactions {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(summaries)
+			index[reaction.Emoji] = i
+			summaries = append(summaries, ReactionSummary{Emoji: reaction.Emoji, UserIDs: []string{}})
+		}
+		summaries[i].Count++
+		summaries[i].UserIDs = append(summaries[i].UserIDs, reaction.UserID)
+	}
+	return summaries
+}
@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestValidReactionEmoji(t *testing.T) {
+	tests := []struct {
+		emoji string
+		valid bool
+	}{
+		{"👍", true},
+		{"❤️", true},
+		{"☀", true},
+		{"👍🏽", true},
+		{"👨‍👩‍👧‍👦", true},
+		{"🧑🏽‍🦰", true},
+		{"🏳️‍🌈", true},
+		{"❤️‍🔥", true},
+		{"🇫🇷", true},
+		{"🏴\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F", true},
+		{"1️⃣", true},
+		{"#⃣", true},
+		{"*️⃣", true},
+
+		{"", false},
+		{"é", false},
+		{"漢字", false},
+		{"ñññ", false},
+		{"12345", false},
+		{"1", false},
+		{"1️", false},
+		{"a👍", false},
+		{"👍 ", false},
+		{"👍‍", false},
+		{"‍👍", false},
+		{"🏽", false},
+		{"🇫", false},
+		{"👍🏽🏽", false},
+		{"👍👍", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidReactionEmoji(tt.emoji); got != tt.valid {
+			t.Errorf("ValidReactionEmoji(%q) = %v, attendu %v", tt.emoji, got, tt.valid)
+		}
+	}
+}
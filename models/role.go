@@ -0,0 +1,180 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Rôles attribuables par un super-admin
+const (
+	RoleSuperAdmin        = "super_admin"        // Tous les droits (équivaut à admin = 1)
+	RoleOrganisateur      = "organisateur"       // Gère ses événements, leurs inscriptions et l'accueil
+	RoleAccueil           = "accueil"            // Check-in à l'entrée uniquement
+	RoleModerateurGalerie = "moderateur_galerie" // Modère les médias de la galerie
+)
+
+// Permissions vérifiées par le middleware RequirePermission
+const (
+	PermissionUsersRead         = "users:read"
+	PermissionUsersWrite        = "users:write"
+	PermissionUsersDelete       = "users:delete"
+	PermissionRolesManage       = "roles:manage"
+	PermissionEventsRead        = "events:read"
+	PermissionEventsCreate      = "events:create"
+	PermissionEventsWrite       = "events:write"
+	PermissionEventsDelete      = "events:delete"
+	PermissionInscriptionsRead  = "inscriptions:read"
+	PermissionInscriptionsWrite = "inscriptions:write"
+	PermissionCheckin           = "checkin"
+	PermissionGalleryModerate   = "gallery:moderate"
+	PermissionNotificationsSend = "notifications:send"
+	PermissionCodesManage       = "codes:manage"
+	PermissionSettingsManage    = "settings:manage"
+	PermissionStatsRead         = "stats:read"
+	PermissionChatAdmin         = "chat:admin"
+	PermissionAuditRead         = "audit:read"
+)
+
+// RolePermissions associe chaque rôle délégué à ses permissions.
+// Le super-admin possède toutes les permissions et n'apparaît donc pas ici.
+var RolePermissions = map[string][]string{
+	RoleOrganisateur: {
+		PermissionEventsRead,
+		PermissionEventsWrite,
+		PermissionInscriptionsRead,
+		PermissionInscriptionsWrite,
+		PermissionCheckin,
+	},
+	RoleAccueil: {
+		PermissionCheckin,
+	},
+	RoleModerateurGalerie: {
+		PermissionGalleryModerate,
+	},
+}
+
+// eventScopedPermissions sont les permissions qui portent sur un événement précis :
+// un rôle limité à certains événements ne les accorde que pour ceux-ci
+var eventScopedPermissions = map[string]bool{
+	PermissionEventsRead:        true,
+	PermissionEventsWrite:       true,
+	PermissionEventsDelete:      true,
+	PermissionInscriptionsRead:  true,
+	PermissionInscriptionsWrite: true,
+	PermissionCheckin:           true,
+	PermissionGalleryModerate:   true,
+}
+
+// IsEventScopedPermission indique si une permission dépend de l'événement ciblé
+func IsEventScopedPermission(permission string) bool {
+	return eventScopedPermissions[permission]
+}
+
+// IsValidRole indique si un rôle existe
+func IsValidRole(role string) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// RoleAssignment représente un rôle attribué à un utilisateur
+type RoleAssignment struct {
+	Role     string               `json:"role" bson:"role"`
+	EventIDs []primitive.ObjectID `json:"event_ids,omitempty" bson:"event_ids,omitempty"` // Vide = tous les événements
+}
+
+// UpdateRolesRequest représente l'attribution des rôles d'un utilisateur
+type UpdateRolesRequest struct {
+	Roles []RoleAssignmentRequest `json:"roles"`
+}
+
+// RoleAssignmentRequest représente un rôle dans la requête d'attribution
+type RoleAssignmentRequest struct {
+	Role     string   `json:"role"`
+	EventIDs []string `json:"event_ids"`
+}
+
+// IsSuperAdmin indique si l'utilisateur a tous les droits
+func (u *User) IsSuperAdmin() bool {
+	return u != nil && u.Admin == 1
+}
+
+// AllRoles retourne les rôles de l'utilisateur, super-admin compris
+func (u *User) AllRoles() []RoleAssignment {
+	roles := []RoleAssignment{}
+	if u == nil {
+		return roles
+	}
+	if u.IsSuperAdmin() {
+		roles = append(roles, RoleAssignment{Role: RoleSuperAdmin})
+	}
+	return append(roles, u.Roles...)
+}
+
+// HasPermission vérifie si l'utilisateur possède une permission.
+// Pour une permission liée à un événement, eventID restreint la vérification à cet événement ;
+// nil signifie que la route ne cible pas d'événement précis (le handler filtre alors lui-même).
+func (u *User) HasPermission(permission string, eventID *primitive.ObjectID) bool {
+	if u == nil {
+		return false
+	}
+	if u.IsSuperAdmin() {
+		return true
+	}
+
+	for _, assignment := range u.Roles {
+		if !roleGrants(assignment.Role, permission) {
+			continue
+		}
+		if eventID == nil || !IsEventScopedPermission(permission) || assignment.coversEvent(*eventID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EventScope retourne les événements sur lesquels l'utilisateur possède une permission.
+// all vaut true si la permission s'applique à tous les événements.
+func (u *User) EventScope(permission string) (eventIDs []primitive.ObjectID, all bool) {
+	if u == nil {
+		return nil, false
+	}
+	if u.IsSuperAdmin() {
+		return nil, true
+	}
+
+	for _, assignment := range u.Roles {
+		if !roleGrants(assignment.Role, permission) {
+			continue
+		}
+		if len(assignment.EventIDs) == 0 {
+			return nil, true
+		}
+		eventIDs = append(eventIDs, assignment.EventIDs...)
+	}
+
+	return eventIDs, false
+}
+
+// roleGrants indique si un rôle délégué accorde une permission
+func roleGrants(role string, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// coversEvent indique si le rôle s'applique à l'événement
+func (a RoleAssignment) coversEvent(eventID primitive.ObjectID) bool {
+	if len(a.EventIDs) == 0 {
+		return true
+	}
+	for _, id := range a.EventIDs {
+		if id == eventID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session représente une session de connexion (un appareil) avec son refresh token
+type Session struct {
+	ID                  primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserEmail           string             `json:"user_email" bson:"user_email"`
+	RefreshTokenHash    string             `json:"-" bson:"refresh_token_hash"`
+	PreviousRefreshHash string             `json:"-" bson:"previous_refresh_hash,omitempty"` // Détection de réutilisation après rotation
+	UserAgent           string             `json:"user_agent" bson:"user_agent"`
+	IP                  string             `json:"ip" bson:"ip"`
+	CreatedAt           time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt          time.Time          `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt           time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt           *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// RefreshRequest représente la requête de renouvellement du token d'accès
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
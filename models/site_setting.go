@@ -15,6 +15,11 @@ type SiteSetting struct {
 	UpdatedBy *primitive.ObjectID `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
 }
 
+// Clés des paramètres du site
+const (
+	SettingAllowUnverifiedInscriptions = "allow_unverified_inscriptions" // "true" ou "false"
+)
+
 // ThemeRequest représente la requête de modification du thème
 type ThemeRequest struct {
 	Theme string `json:"theme" validate:"required,oneof=medieval classic"`
@@ -26,3 +31,8 @@ type ThemeResponse struct {
 	Theme   string `json:"theme"`
 	Message string `json:"message,omitempty"`
 }
+
+// InscriptionSettings représente les paramètres d'inscription aux événements (admin)
+type InscriptionSettings struct {
+	AllowUnverified bool `json:"allow_unverified"` // Autoriser les comptes à l'e-mail non vérifié à s'inscrire
+}
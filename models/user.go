@@ -8,18 +8,22 @@ import (
 
 // User représente un utilisateur dans le système
 type User struct {
-	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	CodeSoiree      string             `json:"code_soiree" bson:"code_soiree,omitempty"`
-	Firstname       string             `json:"firstname" bson:"firstname"`
-	Lastname        string             `json:"lastname" bson:"lastname"`
-	Email           string             `json:"email" bson:"email"`
-	Phone           string             `json:"phone" bson:"phone"`
-	Password        string             `json:"-" bson:"password"` // Le "-" empêche la sérialisation du mot de passe
-	ProfileImageURL string             `json:"profileImageUrl,omitempty" bson:"profile_image_url,omitempty"` // URL de la photo de profil
-	FCMToken        string             `json:"fcm_token,omitempty" bson:"fcm_token,omitempty"` // Token FCM pour les notifications
-	Admin           int                `json:"admin" bson:"admin"` // 0 = utilisateur normal, 1 = admin
-	LastSeen        *time.Time         `json:"last_seen,omitempty" bson:"last_seen,omitempty"` // Dernière activité WebSocket
-	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	ID                  primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	CodeSoiree          string             `json:"code_soiree" bson:"code_soiree,omitempty"`
+	Firstname           string             `json:"firstname" bson:"firstname"`
+	Lastname            string             `json:"lastname" bson:"lastname"`
+	Email               string             `json:"email" bson:"email"`
+	Phone               string             `json:"phone" bson:"phone"`
+	Password            string             `json:"-" bson:"password"`                                            // Le "-" empêche la sérialisation du mot de passe
+	ProfileImageURL     string             `json:"profileImageUrl,omitempty" bson:"profile_image_url,omitempty"` // URL de la photo de profil
+	FCMToken            string             `json:"fcm_token,omitempty" bson:"fcm_token,omitempty"`               // Token FCM pour les notifications
+	Admin               int                `json:"admin" bson:"admin"`                                           // 0 = utilisateur normal, 1 = admin
+	Roles               []RoleAssignment   `json:"roles,omitempty" bson:"roles,omitempty"`                       // Rôles délégués (organisateur, accueil, modérateur)
+	LastSeen            *time.Time         `json:"last_seen,omitempty" bson:"last_seen,omitempty"`               // Dernière activité WebSocket
+	EmailVerified       bool               `json:"email_verified" bson:"email_verified"`
+	EmailVerifiedAt     *time.Time         `json:"email_verified_at,omitempty" bson:"email_verified_at,omitempty"`
+	DeletionScheduledAt *time.Time         `json:"deletion_scheduled_at,omitempty" bson:"deletion_scheduled_at,omitempty"` // Suppression demandée par l'utilisateur (délai de grâce)
+	CreatedAt           time.Time          `json:"created_at" bson:"created_at"`
 }
 
 // RegisterRequest représente la requête d'inscription
@@ -40,8 +44,10 @@ type LoginRequest struct {
 
 // AuthResponse représente la réponse d'authentification
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Durée de validité du token d'accès, en secondes
+	User         User   `json:"user"`
 }
 
 // ErrorResponse représente une réponse d'erreur
@@ -56,4 +62,3 @@ type SuccessResponse struct {
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 }
-
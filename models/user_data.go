@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AccountDeletionGracePeriod est le délai entre la demande de suppression d'un compte et sa suppression effective
+const AccountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// UserDataExport regroupe les données personnelles d'un utilisateur (export RGPD)
+type UserDataExport struct {
+	ExportedAt              time.Time                `json:"exported_at"`
+	Profile                 User                     `json:"profile"`
+	Inscriptions            []Inscription            `json:"inscriptions"` // Accompagnants inclus
+	Waitlist                []WaitlistEntry          `json:"waitlist"`
+	Medias                  []Media                  `json:"medias"` // Métadonnées des médias envoyés
+	PrivateMessages         []Message                `json:"private_messages"`
+	GroupMemberships        []ChatGroupMember        `json:"group_memberships"`
+	GroupMessages           []ChatGroupMessage       `json:"group_messages"`
+	FCMDevices              []FCMToken               `json:"fcm_devices"`
+	PushSubscriptions       []PushSubscription       `json:"push_subscriptions"`
+	NotificationPreferences *NotificationPreferences `json:"notification_preferences,omitempty"`
+	Notifications           []Notification           `json:"notifications"`
+}
+
+// DeleteAccountRequest représente la demande de suppression de son propre compte
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WaitlistEntry représente une demande en liste d'attente pour un événement complet
+type WaitlistEntry struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EventID         primitive.ObjectID `json:"event_id" bson:"event_id"`
+	UserEmail       string             `json:"user_email" bson:"user_email"`
+	NombrePersonnes int                `json:"nombre_personnes" bson:"nombre_personnes"`
+	Accompagnants   []Accompagnant     `json:"accompagnants" bson:"accompagnants"`
+	Statut          string             `json:"statut" bson:"statut"` // "en_attente", "promu"
+	PromotedAt      *time.Time         `json:"promoted_at,omitempty" bson:"promoted_at,omitempty"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// WaitlistEntryWithUserInfo contient les infos complètes d'une entrée pour l'admin
+type WaitlistEntryWithUserInfo struct {
+	ID              string         `json:"id"`
+	Position        int            `json:"position"`
+	UserEmail       string         `json:"user_email"`
+	UserName        string         `json:"user_name"`
+	UserPhone       string         `json:"user_phone"`
+	NombrePersonnes int            `json:"nombre_personnes"`
+	Accompagnants   []Accompagnant `json:"accompagnants"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// WSEventLogSize est le nombre d'événements WebSocket conservés par utilisateur pour la reprise
+const WSEventLogSize = 200
+
+// WSEvent est un événement WebSocket envoyé à un utilisateur (collection "ws_event_log")
+type WSEvent struct {
+	UserID    string    `bson:"user_id"`
+	Seq       int64     `bson:"seq"`
+	Payload   string    `bson:"payload"` // JSON envoyé au client, "seq" inclus
+	CreatedAt time.Time `bson:"created_at"`
+}
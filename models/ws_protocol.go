@@ -0,0 +1,467 @@
+package models
+
+import "time"
+
+// Version du protocole WebSocket.
+// Le client annonce la sienne dans authenticate (protocol_version) ; le serveur répond avec la
+// version retenue dans authenticated. Sans protocol_version, le client est considéré en version 1.
+const (
+	WSProtocolVersion    = 1 // Version courante (la plus récente supportée)
+	WSMinProtocolVersion = 1 // Plus ancienne version encore acceptée
+)
+
+// Types des messages envoyés par le client
+const (
+	WSTypeAuthenticate      = "authenticate"
+	WSTypeJoinConversation  = "join_conversation"
+	WSTypeLeaveConversation = "leave_conversation"
+	WSTypeTyping            = "typing"
+	WSTypeJoinGroup         = "join_group"
+	WSTypeLeaveGroup        = "leave_group"
+	WSTypeGroupTyping       = "group_typing"
+	WSTypeUserPresence      = "user_presence"
+)
+
+// Types des messages envoyés par le serveur
+const (
+	WSTypeAuthenticated           = "authenticated"
+	WSTypeError                   = "error"
+	WSTypeReplayComplete          = "replay_complete"
+	WSTypeResyncRequired          = "resync_required"
+	WSTypeJoinedGroup             = "joined_group"
+	WSTypeUserTyping              = "user_typing"
+	WSTypeGroupUserTyping         = "group_user_typing"
+	WSTypeNewMessage              = "new_message"
+	WSTypeMessagesRead            = "messages_read"
+	WSTypeNewInvitation           = "new_invitation"
+	WSTypeInvitationAccepted      = "invitation_accepted"
+	WSTypeInvitationRejected      = "invitation_rejected"
+	WSTypeGroupCreated            = "group_created"
+	WSTypeGroupInvitation         = "group_invitation"
+	WSTypeGroupInvitationAccepted = "group_invitation_accepted"
+	WSTypeGroupInvitationRejected = "group_invitation_rejected"
+	WSTypeGroupMemberJoined       = "group_member_joined"
+	WSTypeGroupMemberLeft         = "group_member_left"
+	WSTypeNewGroupMessage         = "new_group_message"
+	WSTypeGroupMessagesRead       = "group_messages_read"
+	WSTypeAdminRightsChanged      = "admin_rights_changed"
+	WSTypeMessageEdited           = "message_edited"
+	WSTypeMessageDeleted          = "message_deleted"
+	WSTypeReactionAdded           = "reaction_added"
+	WSTypeReactionRemoved         = "reaction_removed"
+	WSTypeGroupUpdated            = "group_updated"
+	WSTypeNotification            = "notification"
+	WSTypeNotificationsRead       = "notifications_read"
+)
+
+// Codes des frames d'erreur
+const (
+	WSErrorAuthenticationRequired = "authentication_required" // Premier message différent de authenticate
+	WSErrorInvalidToken           = "invalid_token"           // JWT manquant, invalide ou expiré
+	WSErrorSessionRevoked         = "session_revoked"         // Session révoquée ou expirée
+	WSErrorUnsupportedVersion     = "unsupported_protocol_version"
+	WSErrorInvalidJSON            = "invalid_json"  // Message illisible
+	WSErrorUnknownType            = "unknown_type"  // Type de message inconnu
+	WSErrorMissingField           = "missing_field" // Champ obligatoire absent (voir field)
+	WSErrorInvalidField           = "invalid_field" // Champ de mauvais type ou format (voir field)
+)
+
+// ====================================
+// Messages client → serveur
+// ====================================
+
+// WSAuthenticate doit être le premier message de la connexion
+type WSAuthenticate struct {
+	Type            string `json:"type"`
+	Token           string `json:"token"`                      // JWT de connexion
+	ProtocolVersion int    `json:"protocol_version,omitempty"` // Version du protocole du client (1 par défaut)
+	LastSeq         *int64 `json:"last_seq,omitempty"`         // Dernier événement reçu, pour la reprise
+}
+
+// WSConversationRoom rejoint ou quitte la room d'une conversation privée (join_conversation, leave_conversation)
+type WSConversationRoom struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversation_id"`
+}
+
+// WSTyping signale la frappe dans une conversation privée ou, avec group_id, dans un groupe
+type WSTyping struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversation_id,omitempty"` // conversation_id OU group_id
+	GroupID        string `json:"group_id,omitempty"`
+	IsTyping       bool   `json:"is_typing"`
+}
+
+// WSGroupRoom rejoint ou quitte la room d'un groupe (join_group, leave_group)
+type WSGroupRoom struct {
+	Type    string `json:"type"`
+	GroupID string `json:"group_id"`
+}
+
+// WSGroupTyping signale la frappe dans un groupe
+type WSGroupTyping struct {
+	Type     string `json:"type"`
+	GroupID  string `json:"group_id"`
+	IsTyping bool   `json:"is_typing"`
+}
+
+// WSPresenceUpdate met à jour la présence de l'utilisateur (envoyé quand il navigue sur le site)
+type WSPresenceUpdate struct {
+	Type     string  `json:"type"`
+	IsOnline *bool   `json:"is_online"`
+	LastSeen *string `json:"last_seen,omitempty"` // ISO 8601, pris en compte quand is_online = false
+}
+
+// ====================================
+// Messages serveur → client
+// ====================================
+
+// WSAuthenticated confirme l'authentification
+type WSAuthenticated struct {
+	Type            string `json:"type"`
+	UserID          string `json:"user_id"`
+	ProtocolVersion int    `json:"protocol_version"` // Version retenue pour la connexion
+	LastSeq         int64  `json:"last_seq"`         // Dernier numéro de séquence attribué à l'utilisateur
+}
+
+// WSError signale un message refusé ; la connexion n'est fermée que pendant l'authentification
+type WSError struct {
+	Type        string `json:"type"`
+	Code        string `json:"code"`                   // Voir les constantes WSError*
+	Message     string `json:"message"`                // Message lisible (français)
+	Field       string `json:"field,omitempty"`        // Champ en cause (missing_field, invalid_field)
+	RequestType string `json:"request_type,omitempty"` // Type du message refusé
+}
+
+// WSReplayComplete termine le renvoi des événements manqués
+type WSReplayComplete struct {
+	Type     string `json:"type"`
+	Replayed int    `json:"replayed"`
+	LastSeq  int64  `json:"last_seq"`
+}
+
+// WSResyncRequired indique que les événements manqués ne sont plus disponibles : tout recharger
+type WSResyncRequired struct {
+	Type    string `json:"type"`
+	LastSeq int64  `json:"last_seq"`
+}
+
+// WSJoinedGroup confirme join_group
+type WSJoinedGroup struct {
+	Type    string `json:"type"`
+	GroupID string `json:"group_id"`
+}
+
+// WSUserPresence annonce la présence d'un participant
+type WSUserPresence struct {
+	Type     string  `json:"type"`
+	UserID   string  `json:"user_id"`
+	IsOnline bool    `json:"is_online"`
+	LastSeen *string `json:"last_seen"` // ISO 8601, null si inconnu
+}
+
+// WSUserTyping annonce qu'un participant écrit dans une conversation privée
+type WSUserTyping struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversation_id"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	IsTyping       bool   `json:"is_typing"`
+}
+
+// WSGroupUserTyping annonce qu'un membre écrit dans un groupe
+type WSGroupUserTyping struct {
+	Type     string `json:"type"`
+	GroupID  string `json:"group_id"`
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsTyping bool   `json:"is_typing"`
+}
+
+// WSUserRef identifie un utilisateur dans un événement
+type WSUserRef struct {
+	ID        string `json:"id,omitempty"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+	Email     string `json:"email,omitempty"`
+}
+
+// WSChatMessage message de conversation privée
+type WSChatMessage struct {
+	ID             string       `json:"id"`
+	ConversationID string       `json:"conversation_id"`
+	SenderID       string       `json:"sender_id"`
+	Content        string       `json:"content"`
+	Timestamp      time.Time    `json:"timestamp"`
+	DeliveredAt    *time.Time   `json:"delivered_at"`
+	ReadAt         *time.Time   `json:"read_at"`
+	Type           string       `json:"type,omitempty"` // "text", "image", "file"
+	Attachments    []Attachment `json:"attachments,omitempty"`
+}
+
+// WSNewMessage nouveau message dans une conversation privée
+type WSNewMessage struct {
+	Type           string        `json:"type"`
+	ConversationID string        `json:"conversation_id"`
+	Message        WSChatMessage `json:"message"`
+}
+
+// WSMessagesRead le destinataire a lu les messages de la conversation
+type WSMessagesRead struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversation_id"`
+	ReadAt         string `json:"read_at"` // ISO 8601
+}
+
+// WSChatInvitation invitation à une conversation privée
+type WSChatInvitation struct {
+	ID         string    `json:"id"`
+	FromUserID string    `json:"from_user_id"`
+	ToUserID   string    `json:"to_user_id"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+	FromUser   WSUserRef `json:"fromUser"`
+}
+
+// WSNewInvitation nouvelle invitation à une conversation privée
+type WSNewInvitation struct {
+	Type       string           `json:"type"`
+	Invitation WSChatInvitation `json:"invitation"`
+}
+
+// WSAcceptedConversation conversation créée par l'acceptation d'une invitation
+type WSAcceptedConversation struct {
+	ID          string    `json:"id"`
+	Participant WSUserRef `json:"participant"`
+	Status      string    `json:"status"`
+	UnreadCount int       `json:"unread_count"`
+}
+
+// WSInvitationAccepted l'invitation envoyée a été acceptée
+type WSInvitationAccepted struct {
+	Type         string                 `json:"type"`
+	InvitationID string                 `json:"invitation_id"`
+	Conversation WSAcceptedConversation `json:"conversation"`
+}
+
+// WSInvitationRejected l'invitation envoyée a été refusée
+type WSInvitationRejected struct {
+	Type         string `json:"type"`
+	InvitationID string `json:"invitation_id"`
+}
+
+// WSGroupCreated le groupe vient d'être créé (envoyé au créateur)
+type WSGroupCreated struct {
+	Type  string           `json:"type"`
+	Group GroupWithDetails `json:"group"`
+}
+
+// WSInvitedGroup groupe décrit dans une invitation
+type WSInvitedGroup struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	CreatedBy   WSUserRef `json:"created_by"`
+	MemberCount int       `json:"member_count"`
+}
+
+// WSGroupInvitationDetails invitation à rejoindre un groupe
+type WSGroupInvitationDetails struct {
+	ID        string         `json:"id"`
+	Group     WSInvitedGroup `json:"group"`
+	InvitedBy WSUserRef      `json:"invited_by"`
+	Message   string         `json:"message"`
+	InvitedAt time.Time      `json:"invited_at"`
+}
+
+// WSGroupInvitation nouvelle invitation à rejoindre un groupe
+type WSGroupInvitation struct {
+	Type       string                   `json:"type"`
+	Invitation WSGroupInvitationDetails `json:"invitation"`
+}
+
+// WSGroupInvitationAccepted un invité a accepté (envoyé à l'auteur de l'invitation)
+type WSGroupInvitationAccepted struct {
+	Type       string     `json:"type"`
+	GroupID    string     `json:"group_id"`
+	User       WSUserRef  `json:"user"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
+
+// WSGroupInvitationRejected un invité a refusé (envoyé à l'auteur de l'invitation)
+type WSGroupInvitationRejected struct {
+	Type       string     `json:"type"`
+	GroupID    string     `json:"group_id"`
+	User       WSUserRef  `json:"user"`
+	RejectedAt *time.Time `json:"rejected_at"`
+}
+
+// WSSystemMessage message système d'un groupe (arrivée, départ)
+type WSSystemMessage struct {
+	ID          string    `json:"id"`
+	SenderID    string    `json:"sender_id,omitempty"`
+	Content     string    `json:"content"`
+	MessageType string    `json:"message_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WSGroupMemberJoined un membre a rejoint le groupe
+type WSGroupMemberJoined struct {
+	Type          string          `json:"type"`
+	GroupID       string          `json:"group_id"`
+	User          WSUserRef       `json:"user"`
+	SystemMessage WSSystemMessage `json:"system_message"`
+}
+
+// WSGroupMemberLeft un membre a quitté le groupe
+type WSGroupMemberLeft struct {
+	Type     string          `json:"type"`
+	GroupID  string          `json:"group_id"`
+	UserID   string          `json:"user_id"`
+	UserName string          `json:"user_name"`
+	Message  WSSystemMessage `json:"message"`
+}
+
+// WSGroupInfo informations d'un groupe après une action d'administration
+type WSGroupInfo struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	AvatarURL   string     `json:"avatar_url,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	IsActive    bool       `json:"is_active"`
+}
+
+// WSGroupUpdated action d'administration sur un groupe (infos, rôles, exclusion, propriété, archivage, dissolution)
+type WSGroupUpdated struct {
+	Type          string          `json:"type"`
+	GroupID       string          `json:"group_id"`
+	Action        string          `json:"action"`   // Voir GroupAction*
+	ActorID       string          `json:"actor_id"` // Email de l'administrateur
+	TargetUserID  string          `json:"target_user_id,omitempty"`
+	Role          string          `json:"role,omitempty"` // Nouveau rôle (member_role_changed)
+	Group         WSGroupInfo     `json:"group"`
+	SystemMessage WSSystemMessage `json:"system_message"`
+}
+
+// WSNewGroupMessage nouveau message dans un groupe
+type WSNewGroupMessage struct {
+	Type    string                  `json:"type"`
+	GroupID string                  `json:"group_id"`
+	Message *GroupMessageWithSender `json:"message"`
+}
+
+// WSGroupMessagesRead un membre a lu les messages du groupe
+type WSGroupMessagesRead struct {
+	Type    string       `json:"type"`
+	GroupID string       `json:"group_id"`
+	UserID  string       `json:"user_id"`
+	ReadAt  FlexibleTime `json:"read_at"`
+}
+
+// WSMessageEdited un message a été modifié (conversation_id ou group_id)
+type WSMessageEdited struct {
+	Type           string    `json:"type"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	GroupID        string    `json:"group_id,omitempty"`
+	MessageID      string    `json:"message_id"`
+	Content        string    `json:"content"`
+	Mentions       []Mention `json:"mentions,omitempty"` // Groupes uniquement
+	EditedAt       time.Time `json:"edited_at"`
+}
+
+// WSMessageDeleted un message a été supprimé (conversation_id ou group_id) : afficher une mention à la place
+type WSMessageDeleted struct {
+	Type           string    `json:"type"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	GroupID        string    `json:"group_id,omitempty"`
+	MessageID      string    `json:"message_id"`
+	DeletedAt      time.Time `json:"deleted_at"`
+}
+
+// WSReaction une réaction a été ajoutée ou retirée (conversation_id ou group_id), avec le résumé à jour
+type WSReaction struct {
+	Type           string            `json:"type"`
+	ConversationID string            `json:"conversation_id,omitempty"`
+	GroupID        string            `json:"group_id,omitempty"`
+	MessageID      string            `json:"message_id"`
+	Emoji          string            `json:"emoji"`
+	UserID         string            `json:"user_id"`
+	Reactions      []ReactionSummary `json:"reactions"`
+}
+
+// WSAdminRightsChanged les droits de l'utilisateur ont changé : ses sessions ont été révoquées
+type WSAdminRightsChanged struct {
+	Type      string           `json:"type"`
+	UserID    string           `json:"user_id"`
+	UserEmail string           `json:"user_email"`
+	Admin     int              `json:"admin"`
+	Roles     []RoleAssignment `json:"roles"`
+}
+
+// WSNotification nouvelle notification dans le centre de notifications
+type WSNotification struct {
+	Type         string       `json:"type"`
+	Notification Notification `json:"notification"`
+	UnreadCount  int64        `json:"unread_count"` // Pour le badge
+}
+
+// WSNotificationsRead des notifications ont été lues (sur un autre appareil) : badge à jour
+type WSNotificationsRead struct {
+	Type           string `json:"type"`
+	NotificationID string `json:"notification_id,omitempty"` // Absent pour « tout marquer comme lu »
+	UnreadCount    int64  `json:"unread_count"`
+}
+
+// WSMessageSpec décrit un type de message du protocole (utilisé pour générer le JSON Schema)
+type WSMessageSpec struct {
+	Type        string
+	Description string
+	Payload     interface{}
+}
+
+// WSInboundMessages liste les messages acceptés par le serveur
+var WSInboundMessages = []WSMessageSpec{
+	{WSTypeAuthenticate, "Premier message obligatoire de la connexion", WSAuthenticate{}},
+	{WSTypeJoinConversation, "Rejoindre la room d'une conversation privée", WSConversationRoom{}},
+	{WSTypeLeaveConversation, "Quitter la room d'une conversation privée", WSConversationRoom{}},
+	{WSTypeTyping, "Indicateur de frappe (conversation_id ou group_id)", WSTyping{}},
+	{WSTypeJoinGroup, "Rejoindre la room d'un groupe", WSGroupRoom{}},
+	{WSTypeLeaveGroup, "Quitter la room d'un groupe", WSGroupRoom{}},
+	{WSTypeGroupTyping, "Indicateur de frappe dans un groupe", WSGroupTyping{}},
+	{WSTypeUserPresence, "Mise à jour de la présence", WSPresenceUpdate{}},
+}
+
+// WSOutboundMessages liste les messages envoyés par le serveur
+var WSOutboundMessages = []WSMessageSpec{
+	{WSTypeAuthenticated, "Authentification réussie", WSAuthenticated{}},
+	{WSTypeError, "Message refusé", WSError{}},
+	{WSTypeReplayComplete, "Fin du renvoi des événements manqués", WSReplayComplete{}},
+	{WSTypeResyncRequired, "Événements manqués indisponibles, tout recharger", WSResyncRequired{}},
+	{WSTypeJoinedGroup, "Confirmation de join_group", WSJoinedGroup{}},
+	{WSTypeUserPresence, "Présence d'un participant", WSUserPresence{}},
+	{WSTypeUserTyping, "Frappe dans une conversation privée", WSUserTyping{}},
+	{WSTypeGroupUserTyping, "Frappe dans un groupe", WSGroupUserTyping{}},
+	{WSTypeNewMessage, "Nouveau message privé", WSNewMessage{}},
+	{WSTypeMessagesRead, "Messages privés lus", WSMessagesRead{}},
+	{WSTypeNewInvitation, "Nouvelle invitation de conversation", WSNewInvitation{}},
+	{WSTypeInvitationAccepted, "Invitation de conversation acceptée", WSInvitationAccepted{}},
+	{WSTypeInvitationRejected, "Invitation de conversation refusée", WSInvitationRejected{}},
+	{WSTypeGroupCreated, "Groupe créé", WSGroupCreated{}},
+	{WSTypeGroupInvitation, "Nouvelle invitation de groupe", WSGroupInvitation{}},
+	{WSTypeGroupInvitationAccepted, "Invitation de groupe acceptée", WSGroupInvitationAccepted{}},
+	{WSTypeGroupInvitationRejected, "Invitation de groupe refusée", WSGroupInvitationRejected{}},
+	{WSTypeGroupMemberJoined, "Nouveau membre dans un groupe", WSGroupMemberJoined{}},
+	{WSTypeGroupMemberLeft, "Départ d'un membre d'un groupe", WSGroupMemberLeft{}},
+	{WSTypeNewGroupMessage, "Nouveau message de groupe", WSNewGroupMessage{}},
+	{WSTypeGroupMessagesRead, "Messages de groupe lus", WSGroupMessagesRead{}},
+	{WSTypeAdminRightsChanged, "Droits modifiés, reconnexion nécessaire", WSAdminRightsChanged{}},
+	{WSTypeMessageEdited, "Message modifié", WSMessageEdited{}},
+	{WSTypeMessageDeleted, "Message supprimé", WSMessageDeleted{}},
+	{WSTypeReactionAdded, "Réaction ajoutée à un message", WSReaction{}},
+	{WSTypeReactionRemoved, "Réaction retirée d'un message", WSReaction{}},
+	{WSTypeGroupUpdated, "Action d'administration sur un groupe", WSGroupUpdated{}},
+	{WSTypeNotification, "Nouvelle notification (centre de notifications)", WSNotification{}},
+	{WSTypeNotificationsRead, "Notifications lues, compteur de non lues à jour", WSNotificationsRead{}},
+}
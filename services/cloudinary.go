@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudinaryClient envoie et supprime des fichiers stockés sur Cloudinary (API signée)
+type CloudinaryClient struct {
+	cloudName string
+	apiKey    string
+	apiSecret string
+	client    *http.Client
+}
+
+// NewCloudinaryClient crée une nouvelle instance de CloudinaryClient
+func NewCloudinaryClient(cloudName, apiKey, apiSecret string) *CloudinaryClient {
+	if cloudName == "" || apiKey == "" || apiSecret == "" {
+		log.Println("⚠️  Identifiants Cloudinary incomplets - envoi et suppression des fichiers désactivés")
+	}
+
+	return &CloudinaryClient{
+		cloudName: cloudName,
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// CloudinaryUploadResult représente la réponse de Cloudinary à un upload
+type CloudinaryUploadResult struct {
+	PublicID     string `json:"public_id"`
+	SecureURL    string `json:"secure_url"`
+	ResourceType string `json:"resource_type"`
+	Format       string `json:"format"`
+	Bytes        int64  `json:"bytes"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// Configured indique si les identifiants Cloudinary sont renseignés
+func (c *CloudinaryClient) Configured() bool {
+	return c.cloudName != "" && c.apiKey != "" && c.apiSecret != ""
+}
+
+// Upload envoie un fichier dans un dossier Cloudinary (type de ressource détecté par Cloudinary)
+func (c *CloudinaryClient) Upload(file io.Reader, filename, folder string) (*CloudinaryUploadResult, error) {
+	if !c.Configured() {
+		return nil, fmt.Errorf("cloudinary non configuré")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// Signature : SHA-1 des paramètres triés suivis du secret
+	hash := sha1.Sum([]byte("folder=" + folder + "&timestamp=" + timestamp + c.apiSecret))
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, err
+	}
+	writer.WriteField("folder", folder)
+	writer.WriteField("timestamp", timestamp)
+	writer.WriteField("api_key", c.apiKey)
+	writer.WriteField("signature", hex.EncodeToString(hash[:]))
+	writer.Close()
+
+	uploadURL := fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/auto/upload", c.cloudName)
+	resp, err := c.client.Post(uploadURL, writer.FormDataContentType(), body)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de l'appel à Cloudinary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudinary a répondu %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result CloudinaryUploadResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("réponse Cloudinary invalide: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Destroy supprime un fichier Cloudinary.
+// resourceType vaut "image", "video" ou "raw". Un fichier déjà absent n'est pas une erreur.
+func (c *CloudinaryClient) Destroy(publicID string, resourceType string) error {
+	if !c.Configured() {
+		return fmt.Errorf("cloudinary non configuré")
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// Signature : SHA-1 des paramètres triés suivis du secret
+	hash := sha1.Sum([]byte("public_id=" + publicID + "&timestamp=" + timestamp + c.apiSecret))
+
+	form := url.Values{}
+	form.Set("public_id", publicID)
+	form.Set("timestamp", timestamp)
+	form.Set("api_key", c.apiKey)
+	form.Set("signature", hex.EncodeToString(hash[:]))
+
+	destroyURL := fmt.Sprintf("https://api.cloudinary.com/v1_1/%s/%s/destroy", c.cloudName, resourceType)
+	resp, err := c.client.Post(destroyURL, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("erreur lors de l'appel à Cloudinary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cloudinary a répondu %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("réponse Cloudinary invalide: %w", err)
+	}
+	if result.Result != "ok" && result.Result != "not found" {
+		return fmt.Errorf("suppression Cloudinary refusée: %s", result.Result)
+	}
+
+	return nil
+}
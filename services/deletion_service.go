@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"premier-an-backend/database"
+	"premier-an-backend/models"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DeletionService supprime un utilisateur ou un événement avec toutes ses données liées.
+// Les données partagées (messages de groupe, historique des codes) sont anonymisées plutôt que supprimées.
+type DeletionService struct {
+	userRepo              *database.UserRepository
+	eventRepo             *database.EventRepository
+	inscriptionRepo       *database.InscriptionRepository
+	waitlistRepo          *database.WaitlistRepository
+	mediaRepo             *database.MediaRepository
+	fcmTokenRepo          *database.FCMTokenRepository
+	subscriptionRepo      *database.SubscriptionRepository
+	preferenceRepo        *database.NotificationPreferenceRepository
+	notificationRepo      *database.NotificationRepository
+	sessionRepo           *database.SessionRepository
+	passwordResetRepo     *database.PasswordResetRepository
+	emailVerificationRepo *database.EmailVerificationRepository
+	codeSoireeRepo        *database.CodeSoireeRepository
+	chatRepo              *database.ChatRepository
+	groupRepo             *database.ChatGroupRepository
+	groupInvitationRepo   *database.ChatGroupInvitationRepository
+	groupMessageRepo      *database.ChatGroupMessageRepository
+	cloudinary            *CloudinaryClient
+	waitlistService       *WaitlistService
+	cron                  *cron.Cron
+}
+
+// NewDeletionService crée une nouvelle instance
+func NewDeletionService(db *mongo.Database, cloudinary *CloudinaryClient, waitlistService *WaitlistService) *DeletionService {
+	return &DeletionService{
+		userRepo:              database.NewUserRepository(db),
+		eventRepo:             database.NewEventRepository(db),
+		inscriptionRepo:       database.NewInscriptionRepository(db),
+		waitlistRepo:          database.NewWaitlistRepository(db),
+		mediaRepo:             database.NewMediaRepository(db),
+		fcmTokenRepo:          database.NewFCMTokenRepository(db),
+		subscriptionRepo:      database.NewSubscriptionRepository(db),
+		preferenceRepo:        database.NewNotificationPreferenceRepository(db),
+		notificationRepo:      database.NewNotificationRepository(db),
+		sessionRepo:           database.NewSessionRepository(db),
+		passwordResetRepo:     database.NewPasswordResetRepository(db),
+		emailVerificationRepo: database.NewEmailVerificationRepository(db),
+		codeSoireeRepo:        database.NewCodeSoireeRepository(db),
+		chatRepo:              database.NewChatRepository(db),
+		groupRepo:             database.NewChatGroupRepository(db),
+		groupInvitationRepo:   database.NewChatGroupInvitationRepository(db),
+		groupMessageRepo:      database.NewChatGroupMessageRepository(db),
+		cloudinary:            cloudinary,
+		waitlistService:       waitlistService,
+		cron:                  cron.New(),
+	}
+}
+
+// warn ajoute une étape en échec au rapport sans interrompre la suppression
+func warn(report *models.DeletionReport, step string, err error) {
+	log.Printf("⚠️  Suppression %s %s - %s: %v", report.TargetType, report.TargetID, step, err)
+	report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", step, err))
+}
+
+// DeleteUser supprime un utilisateur et ses données personnelles.
+// Les places de ses inscriptions sont libérées et le compteur de photos des événements concernés est recalculé.
+func (s *DeletionService) DeleteUser(user *models.User) (*models.DeletionReport, error) {
+	report := models.NewDeletionReport("user", user.ID.Hex())
+
+	// Événements impactés et places à libérer, pour mettre à jour les compteurs après suppression
+	affectedEvents := map[primitive.ObjectID]bool{}
+	releasedPlaces := map[primitive.ObjectID]int{}
+
+	// 1. Inscriptions et liste d'attente
+	inscriptions, err := s.inscriptionRepo.FindByUser(user.Email)
+	if err != nil {
+		return nil, err
+	}
+	for _, inscription := range inscriptions {
+		affectedEvents[inscription.EventID] = true
+		releasedPlaces[inscription.EventID] += inscription.NombrePersonnes
+	}
+
+	deleted, err := s.inscriptionRepo.DeleteByUser(user.Email)
+	if err != nil {
+		return nil, err
+	}
+	report.Deleted["inscriptions"] = deleted
+
+	if deleted, err := s.waitlistRepo.DeleteByUser(user.Email); err != nil {
+		warn(report, "waitlist", err)
+	} else {
+		report.Deleted["waitlist"] = deleted
+	}
+
+	// 2. Médias (documents et fichiers Cloudinary)
+	medias, err := s.mediaRepo.FindByUser(user.Email)
+	if err != nil {
+		warn(report, "medias", err)
+	} else {
+		for _, media := range medias {
+			affectedEvents[media.EventID] = true
+			s.destroyMedia(report, media)
+		}
+
+		if deleted, err := s.mediaRepo.DeleteByUser(user.Email); err != nil {
+			warn(report, "medias", err)
+		} else {
+			report.Deleted["medias"] = deleted
+		}
+	}
+
+	// 3. Notifications push
+	if tokens, err := s.fcmTokenRepo.FindByUserID(user.Email); err != nil {
+		warn(report, "fcm_tokens", err)
+	} else if len(tokens) > 0 {
+		if err := s.fcmTokenRepo.DeleteByUserID(user.Email); err != nil {
+			warn(report, "fcm_tokens", err)
+		} else {
+			report.Deleted["fcm_tokens"] = int64(len(tokens))
+		}
+	}
+
+	if subscriptions, err := s.subscriptionRepo.FindByUserID(user.Email); err != nil {
+		warn(report, "subscriptions", err)
+	} else if len(subscriptions) > 0 {
+		if err := s.subscriptionRepo.DeleteByUserID(user.Email); err != nil {
+			warn(report, "subscriptions", err)
+		} else {
+			report.Deleted["subscriptions"] = int64(len(subscriptions))
+		}
+	}
+
+	if deleted, err := s.preferenceRepo.DeleteByUserID(user.Email); err != nil {
+		warn(report, "notification_preferences", err)
+	} else if deleted > 0 {
+		report.Deleted["notification_preferences"] = deleted
+	}
+
+	if deleted, err := s.notificationRepo.DeleteByUserID(user.Email); err != nil {
+		warn(report, "notifications", err)
+	} else if deleted > 0 {
+		report.Deleted["notifications"] = deleted
+	}
+
+	// 4. Groupes de chat : on quitte les groupes, les messages restent visibles mais anonymisés
+	if deleted, err := s.groupRepo.RemoveUserFromAllGroups(user.Email); err != nil {
+		warn(report, "chat_group_members", err)
+	} else {
+		report.Deleted["chat_group_members"] = deleted
+	}
+
+	if deleted, err := s.groupInvitationRepo.DeleteByUser(user.Email); err != nil {
+		warn(report, "chat_group_invitations", err)
+	} else {
+		report.Deleted["chat_group_invitations"] = deleted
+	}
+
+	if anonymized, err := s.groupMessageRepo.AnonymizeSender(user.Email, models.DeletedUserID); err != nil {
+		warn(report, "chat_group_messages", err)
+	} else {
+		report.Anonymized["chat_group_messages"] = anonymized
+	}
+
+	if anonymized, err := s.groupRepo.AnonymizeCreator(user.Email, models.DeletedUserID); err != nil {
+		warn(report, "chat_groups", err)
+	} else {
+		report.Anonymized["chat_groups"] = anonymized
+	}
+
+	// 5. Conversations privées : conservées pour l'autre participant, comme les messages de groupe
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	conversations, messages, invitations, err := s.chatRepo.AnonymizeUserConversations(ctx, user.ID, models.DeletedUserObjectID)
+	cancel()
+	if err != nil {
+		warn(report, "conversations", err)
+	}
+	report.Anonymized["conversations"] = conversations
+	report.Anonymized["messages"] = messages
+	report.Deleted["chat_invitations"] = invitations
+
+	// 6. Sessions et liens envoyés par email
+	if deleted, err := s.sessionRepo.DeleteAllForUser(user.Email); err != nil {
+		warn(report, "sessions", err)
+	} else {
+		report.Deleted["sessions"] = deleted
+	}
+
+	if deleted, err := s.passwordResetRepo.DeleteAllForUser(user.Email); err != nil {
+		warn(report, "password_resets", err)
+	} else {
+		report.Deleted["password_resets"] = deleted
+	}
+
+	if deleted, err := s.emailVerificationRepo.DeleteAllForUser(user.Email); err != nil {
+		warn(report, "email_verifications", err)
+	} else {
+		report.Deleted["email_verifications"] = deleted
+	}
+
+	// 7. Historique des codes de soirée (le nombre d'utilisations reste juste)
+	if anonymized, err := s.codeSoireeRepo.AnonymizeUsage(user.Email, models.DeletedUserID); err != nil {
+		warn(report, "codes_soiree", err)
+	} else {
+		report.Anonymized["codes_soiree"] = anonymized
+	}
+
+	// 8. L'utilisateur lui-même
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return nil, err
+	}
+	report.Deleted["users"] = 1
+
+	// 9. Compteurs des événements impactés ; des places se sont libérées pour la liste d'attente
+	for eventID := range affectedEvents {
+		s.updateEventCounters(report, eventID, releasedPlaces[eventID])
+		if s.waitlistService != nil {
+			go s.waitlistService.PromoteFromWaitlist(eventID)
+		}
+	}
+
+	log.Printf("🗑️  Utilisateur %s supprimé en cascade (%d avertissement(s))", user.Email, len(report.Warnings))
+	return report, nil
+}
+
+// DeleteEvent supprime un événement avec ses inscriptions, sa liste d'attente, ses médias et son trailer
+func (s *DeletionService) DeleteEvent(event *models.Event) (*models.DeletionReport, error) {
+	report := models.NewDeletionReport("event", event.ID.Hex())
+
+	deleted, err := s.inscriptionRepo.DeleteByEvent(event.ID)
+	if err != nil {
+		return nil, err
+	}
+	report.Deleted["inscriptions"] = deleted
+
+	if deleted, err := s.waitlistRepo.DeleteByEvent(event.ID); err != nil {
+		warn(report, "waitlist", err)
+	} else {
+		report.Deleted["waitlist"] = deleted
+	}
+
+	medias, err := s.mediaRepo.FindByEvent(event.ID)
+	if err != nil {
+		warn(report, "medias", err)
+	} else {
+		for _, media := range medias {
+			s.destroyMedia(report, media)
+		}
+
+		if deleted, err := s.mediaRepo.DeleteByEvent(event.ID); err != nil {
+			warn(report, "medias", err)
+		} else {
+			report.Deleted["medias"] = deleted
+		}
+	}
+
+	if event.Trailer != nil && event.Trailer.PublicID != "" {
+		if err := s.cloudinary.Destroy(event.Trailer.PublicID, "video"); err != nil {
+			warn(report, "trailer", err)
+		} else {
+			report.CloudinaryDeleted++
+		}
+	}
+
+	if updated, err := s.codeSoireeRepo.RemoveEvent(event.ID); err != nil {
+		warn(report, "codes_soiree", err)
+	} else {
+		report.Anonymized["codes_soiree"] = updated
+	}
+
+	if err := s.eventRepo.Delete(event.ID); err != nil {
+		return nil, err
+	}
+	report.Deleted["events"] = 1
+
+	log.Printf("🗑️  Événement %s supprimé en cascade (%d avertissement(s))", event.ID.Hex(), len(report.Warnings))
+	return report, nil
+}
+
+// purgeLease durée du verrou d'un compte en cours de suppression programmée
+const purgeLease = 15 * time.Minute
+
+// StartScheduledPurge supprime toutes les heures les comptes dont le délai de grâce est écoulé
+func (s *DeletionService) StartScheduledPurge() {
+	s.cron.AddFunc("@every 1h", s.PurgeScheduledAccounts)
+	s.cron.Start()
+	log.Println("✓ Cron job suppression des comptes démarré (vérification toutes les heures)")
+}
+
+// PurgeScheduledAccounts supprime les comptes dont la suppression programmée est arrivée à échéance.
+// Chaque compte est verrouillé avant la suppression : une seule instance le traite.
+func (s *DeletionService) PurgeScheduledAccounts() {
+	for {
+		user, err := s.userRepo.ClaimDueForDeletion(purgeLease)
+		if err != nil {
+			log.Printf("Erreur recherche comptes à supprimer: %v", err)
+			return
+		}
+		if user == nil {
+			return
+		}
+
+		// En cas d'échec, le compte est repris à l'expiration du verrou
+		if _, err := s.DeleteUser(user); err != nil {
+			log.Printf("⚠️  Erreur suppression programmée du compte %s: %v", user.Email, err)
+		}
+	}
+}
+
+// destroyMedia supprime le fichier Cloudinary d'un média (les autres stockages sont ignorés)
+func (s *DeletionService) destroyMedia(report *models.DeletionReport, media models.Media) {
+	if media.StoragePath == "" || !strings.Contains(media.URL, "res.cloudinary.com") {
+		return
+	}
+
+	resourceType := "image"
+	if media.Type == "video" {
+		resourceType = "video"
+	}
+
+	if err := s.cloudinary.Destroy(media.StoragePath, resourceType); err != nil {
+		warn(report, "cloudinary "+media.StoragePath, err)
+		return
+	}
+	report.CloudinaryDeleted++
+}
+
+// updateEventCounters libère les places des inscriptions supprimées et recalcule le compteur de photos.
+// Les places sont libérées par décrément atomique : une réservation concurrente n'est jamais écrasée.
+func (s *DeletionService) updateEventCounters(report *models.DeletionReport, eventID primitive.ObjectID, releasedPlaces int) {
+	if releasedPlaces > 0 {
+		if err := s.eventRepo.ReleasePlaces(eventID, releasedPlaces); err != nil {
+			warn(report, "inscrits "+eventID.Hex(), err)
+			return
+		}
+	}
+
+	totalMedias, err := s.mediaRepo.CountByEvent(eventID)
+	if err != nil {
+		warn(report, "photos_count "+eventID.Hex(), err)
+		return
+	}
+
+	if err := s.eventRepo.Update(eventID, map[string]interface{}{
+		"photos_count": int(totalMedias),
+	}); err != nil {
+		warn(report, "photos_count "+eventID.Hex(), err)
+		return
+	}
+
+	report.EventsRecalculated = append(report.EventsRecalculated, eventID.Hex())
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"time"
 
+	"premier-an-backend/database"
+
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
 	"google.golang.org/api/option"
@@ -15,8 +17,10 @@ import (
 
 // FCMService gère l'envoi des notifications via Firebase Cloud Messaging
 type FCMService struct {
-	client  *messaging.Client
-	enabled bool // Indique si Firebase est configuré
+	client      *messaging.Client
+	enabled     bool                                       // Indique si Firebase est configuré
+	preferences *database.NotificationPreferenceRepository // Préférences des destinataires (voir UsePreferences)
+	center      *notificationCenter                        // Historique des notifications (voir UseNotificationCenter)
 }
 
 // NewFCMService crée une nouvelle instance de FCMService
@@ -104,6 +108,8 @@ func NewDisabledFCMService() *FCMService {
 
 // SendToToken envoie une notification à un token spécifique
 func (s *FCMService) SendToToken(token string, title, body string, data map[string]string) error {
+	s.center.record([]string{token}, title, body, data)
+
 	// Si Firebase n'est pas activé, ne rien faire
 	if !s.enabled || s.client == nil {
 		log.Println("⚠️  FCM désactivé - notification non envoyée")
@@ -158,6 +164,12 @@ func (s *FCMService) SendToToken(token string, title, body string, data map[stri
 
 // SendToMultipleTokens envoie une notification à plusieurs tokens
 func (s *FCMService) SendToMultipleTokens(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string, err error) {
+	return s.sendMulticast(tokens, title, body, data, false)
+}
+
+// sendMulticast envoie une notification à plusieurs tokens.
+// En haute priorité, la notification réveille l'appareil (Android high, APNs 10 et time-sensitive).
+func (s *FCMService) sendMulticast(tokens []string, title, body string, data map[string]string, highPriority bool) (success int, failed int, failedTokens []string, err error) {
 	if len(tokens) == 0 {
 		return 0, 0, nil, nil
 	}
@@ -199,6 +211,18 @@ func (s *FCMService) SendToMultipleTokens(tokens []string, title, body string, d
 		Tokens:  tokens,
 	}
 
+	if highPriority {
+		message.Android = &messaging.AndroidConfig{Priority: "high"}
+		message.APNS = &messaging.APNSConfig{
+			Headers: map[string]string{"apns-priority": "10"},
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{
+					CustomData: map[string]interface{}{"interruption-level": "time-sensitive"},
+				},
+			},
+		}
+	}
+
 	response, err := s.client.SendEachForMulticast(ctx, message)
 	if err != nil {
 		return 0, 0, nil, fmt.Errorf("erreur lors de l'envoi multicast: %w", err)
@@ -226,6 +250,27 @@ func (s *FCMService) SendToMultipleTokens(tokens []string, title, body string, d
 
 // SendToAll envoie une notification à tous les tokens fournis
 func (s *FCMService) SendToAll(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string) {
+	return s.sendToAll(tokens, title, body, data, false)
+}
+
+// SendToAllHighPriority envoie une notification prioritaire (mentions) à tous les tokens fournis
+func (s *FCMService) SendToAllHighPriority(tokens []string, title, body string, data map[string]string) (success int, failed int, failedTokens []string) {
+	return s.sendToAll(tokens, title, body, data, true)
+}
+
+// sendToAll envoie une notification par lots de 500 tokens
+func (s *FCMService) sendToAll(tokens []string, title, body string, data map[string]string, highPriority bool) (success int, failed int, failedTokens []string) {
+	s.center.record(tokens, title, body, data)
+	return s.deliver(tokens, title, body, data, highPriority)
+}
+
+// isEnabled indique si Firebase est configuré
+func (s *FCMService) isEnabled() bool {
+	return s.enabled && s.client != nil
+}
+
+// deliver envoie les push sans les enregistrer dans le centre de notifications (relances de l'outbox)
+func (s *FCMService) deliver(tokens []string, title, body string, data map[string]string, highPriority bool) (success int, failed int, failedTokens []string) {
 	// Si Firebase n'est pas activé, ne rien faire
 	if !s.enabled || s.client == nil {
 		log.Println("⚠️  FCM désactivé - notifications non envoyées")
@@ -246,7 +291,7 @@ func (s *FCMService) SendToAll(tokens []string, title, body string, data map[str
 		}
 
 		batch := tokens[i:end]
-		s, f, ft, err := s.SendToMultipleTokens(batch, title, body, data)
+		s, f, ft, err := s.sendMulticast(batch, title, body, data, highPriority)
 
 		if err != nil {
 			log.Printf("❌ Erreur pour le batch %d: %v", i/batchSize+1, err)
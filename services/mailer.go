@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mailer envoie des e-mails transactionnels (réinitialisation de mot de passe, vérification...)
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer retourne un SMTPMailer si SMTP_HOST est configuré, sinon un FileMailer
+// qui écrit les e-mails dans outboxDir (ou dans les logs si outboxDir est vide)
+func NewMailer(host, port, username, password, from, outboxDir string) Mailer {
+	if host == "" {
+		log.Println("⚠️  SMTP non configuré - les e-mails sont écrits localement au lieu d'être envoyés")
+		return NewFileMailer(outboxDir)
+	}
+	log.Printf("✓ Mailer SMTP configuré (%s:%s)", host, port)
+	return NewSMTPMailer(host, port, username, password, from)
+}
+
+// ========== SMTP ==========
+
+// SMTPMailer envoie les e-mails via un serveur SMTP
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer crée une nouvelle instance de SMTPMailer
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send envoie un e-mail texte
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	msg := buildMessage(m.from, to, subject, body)
+	if err := smtp.SendMail(m.host+":"+m.port, auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("erreur lors de l'envoi de l'e-mail: %w", err)
+	}
+
+	log.Printf("📧 E-mail envoyé à %s: %s", to, subject)
+	return nil
+}
+
+// ========== FICHIER / LOG (développement) ==========
+
+// FileMailer écrit les e-mails dans un dossier (.eml) ou dans les logs, pour les tests en local
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer crée une nouvelle instance de FileMailer
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+// Send écrit l'e-mail au lieu de l'envoyer
+func (m *FileMailer) Send(to, subject, body string) error {
+	msg := buildMessage("noreply@localhost", to, subject, body)
+
+	if m.dir == "" {
+		log.Printf("📧 [E-mail non envoyé] À: %s | Sujet: %s\n%s", to, subject, body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("erreur lors de la création du dossier d'e-mails: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, []byte(msg), 0o644); err != nil {
+		return fmt.Errorf("erreur lors de l'écriture de l'e-mail: %w", err)
+	}
+
+	log.Printf("📧 E-mail écrit dans %s", path)
+	return nil
+}
+
+// buildMessage construit un message texte brut au format RFC 5322
+func buildMessage(from, to, subject, body string) string {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n") // Accents encodés (RFC 2047)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return b.String()
+}
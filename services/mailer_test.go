@@ -0,0 +1,38 @@
+package services
+
+import (
+	"mime"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessageEncodesSubject(t *testing.T) {
+	subject := "Réinitialisation de votre mot de passe"
+	message := buildMessage("noreply@example.com", "alice@example.com", subject, "Corps")
+
+	var header string
+	for _, line := range strings.Split(message, "\r\n") {
+		if strings.HasPrefix(line, "Subject: ") {
+			header = strings.TrimPrefix(line, "Subject: ")
+		}
+	}
+	if header == "" {
+		t.Fatal("en-tête Subject absent")
+	}
+	if !strings.HasPrefix(header, "=?utf-8?q?") {
+		t.Errorf("Subject = %q, attendu un encoded-word RFC 2047", header)
+	}
+
+	decoded, err := new(mime.WordDecoder).DecodeHeader(header)
+	if err != nil {
+		t.Fatalf("décodage: %v", err)
+	}
+	if decoded != subject {
+		t.Errorf("Subject décodé = %q, attendu %q", decoded, subject)
+	}
+
+	// Un sujet ASCII reste lisible tel quel
+	if message := buildMessage("a@example.com", "b@example.com", "Bienvenue", ""); !strings.Contains(message, "Subject: Bienvenue\r\n") {
+		t.Errorf("sujet ASCII modifié: %q", message)
+	}
+}
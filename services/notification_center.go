@@ -0,0 +1,101 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"premier-an-backend/database"
+	"premier-an-backend/models"
+)
+
+// UserNotifier relaie un événement temps réel à un utilisateur (hub WebSocket)
+type UserNotifier interface {
+	SendToUser(userID string, payload interface{})
+}
+
+// notificationCenter enregistre les push envoyés dans le centre de notifications
+type notificationCenter struct {
+	notificationRepo *database.NotificationRepository
+	fcmTokenRepo     *database.FCMTokenRepository
+	notifier         UserNotifier
+}
+
+// UseNotificationCenter enregistre chaque push (SendToToken, SendToAll) dans la collection notifications,
+// une notification par utilisateur destinataire, et la relaie en temps réel sur le WebSocket
+func (s *FCMService) UseNotificationCenter(notificationRepo *database.NotificationRepository, fcmTokenRepo *database.FCMTokenRepository, notifier UserNotifier) {
+	s.center = &notificationCenter{
+		notificationRepo: notificationRepo,
+		fcmTokenRepo:     fcmTokenRepo,
+		notifier:         notifier,
+	}
+}
+
+// record enregistre la notification pour les propriétaires des tokens, même si Firebase est désactivé
+func (c *notificationCenter) record(tokens []string, title, body string, data map[string]string) {
+	if c == nil || len(tokens) == 0 {
+		return
+	}
+
+	owners, err := c.fcmTokenRepo.FindByTokens(tokens)
+	if err != nil {
+		log.Printf("⚠️  Centre de notifications: destinataires introuvables: %v", err)
+		return
+	}
+
+	// Une notification par utilisateur, quel que soit son nombre d'appareils
+	now := time.Now()
+	seen := make(map[string]bool)
+	var notifications []models.Notification
+	for _, owner := range owners {
+		if owner.UserID == "" || seen[owner.UserID] {
+			continue
+		}
+		seen[owner.UserID] = true
+
+		notificationData := make(map[string]string, len(data))
+		for key, value := range data {
+			notificationData[key] = value
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:    owner.UserID,
+			Type:      models.NotificationType(data),
+			Title:     title,
+			Body:      body,
+			Data:      notificationData,
+			CreatedAt: now,
+		})
+	}
+
+	if err := c.notificationRepo.CreateMany(notifications); err != nil {
+		log.Printf("❌ Centre de notifications: %v", err)
+		return
+	}
+
+	if c.notifier == nil || len(notifications) == 0 {
+		return
+	}
+
+	// Relais WebSocket hors du chemin de la requête
+	go c.broadcast(notifications)
+}
+
+// broadcast relaie les notifications enregistrées avec le compteur de non lues de chaque destinataire
+func (c *notificationCenter) broadcast(notifications []models.Notification) {
+	userIDs := make([]string, 0, len(notifications))
+	for _, notification := range notifications {
+		userIDs = append(userIDs, notification.UserID)
+	}
+
+	unread, err := c.notificationRepo.CountUnreadByUsers(userIDs)
+	if err != nil {
+		log.Printf("⚠️  Centre de notifications: comptage impossible: %v", err)
+	}
+
+	for _, notification := range notifications {
+		c.notifier.SendToUser(notification.UserID, models.WSNotification{
+			Type:         models.WSTypeNotification,
+			Notification: notification,
+			UnreadCount:  unread[notification.UserID],
+		})
+	}
+}
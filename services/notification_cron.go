@@ -79,10 +79,10 @@ func (nc *NotificationCron) sendEventOpeningNotification(event models.Event) {
 		return
 	}
 
-	// Extraire les tokens
-	var tokens []string
-	for _, t := range allFCMTokens {
-		tokens = append(tokens, t.Token)
+	// Extraire les tokens des utilisateurs qui acceptent cette catégorie
+	tokens := nc.fcmService.FilterTokens(allFCMTokens, models.NotificationContext{Category: models.NotificationCategoryEventOpening})
+	if len(tokens) == 0 {
+		return
 	}
 
 	// Préparer la notification
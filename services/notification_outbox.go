@@ -0,0 +1,237 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"premier-an-backend/database"
+	"premier-an-backend/models"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Réglages du worker de l'outbox
+const (
+	outboxBaseDelay = 30 * time.Second // Délai avant le 2e essai, doublé à chaque échec
+	outboxMaxDelay  = time.Hour        // Délai maximal entre deux essais
+	outboxLease     = 2 * time.Minute  // Durée du verrou d'un message en cours d'envoi
+	outboxBatchSize = 100              // Messages traités au maximum par passage
+)
+
+// NotificationOutbox enregistre les notifications push avant envoi et les livre avec relances :
+// un message survit à une lenteur de FCM ou à un redémarrage du serveur
+type NotificationOutbox struct {
+	outboxRepo   *database.NotificationOutboxRepository
+	fcmTokenRepo *database.FCMTokenRepository
+	fcmService   *FCMService
+	maxAttempts  int
+	cron         *cron.Cron
+}
+
+// NewNotificationOutbox crée une nouvelle instance (maxAttempts <= 0 : DefaultOutboxMaxAttempts)
+func NewNotificationOutbox(db *mongo.Database, fcmService *FCMService, maxAttempts int) *NotificationOutbox {
+	if maxAttempts <= 0 {
+		maxAttempts = models.DefaultOutboxMaxAttempts
+	}
+	return &NotificationOutbox{
+		outboxRepo:   database.NewNotificationOutboxRepository(db),
+		fcmTokenRepo: database.NewFCMTokenRepository(db),
+		fcmService:   fcmService,
+		maxAttempts:  maxAttempts,
+		cron:         cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger))),
+	}
+}
+
+// Start démarre le worker (envoi immédiat des nouveaux messages, relances vérifiées toutes les 15 secondes)
+func (o *NotificationOutbox) Start() {
+	o.cron.AddFunc("@every 15s", o.ProcessDue)
+	o.cron.Start()
+	log.Printf("✓ Outbox notifications démarrée (%d essais maximum)", o.maxAttempts)
+
+	// Reprendre les messages laissés en attente avant le redémarrage
+	go o.ProcessDue()
+}
+
+// Stop arrête le worker
+func (o *NotificationOutbox) Stop() {
+	o.cron.Stop()
+}
+
+// Enqueue enregistre une notification puis déclenche son envoi.
+// Retourne false si une notification existe déjà pour la même clé d'idempotence.
+func (o *NotificationOutbox) Enqueue(message *models.OutboxMessage) (bool, error) {
+	if message.IdempotencyKey == "" {
+		return false, fmt.Errorf("clé d'idempotence requise")
+	}
+	if message.Category == "" {
+		message.Category = models.NotificationCategoryGeneral
+	}
+
+	now := time.Now()
+	message.Status = models.OutboxStatusPending
+	message.Attempts = 0
+	message.MaxAttempts = o.maxAttempts
+	message.NextAttemptAt = now
+	message.CreatedAt = now
+	message.UpdatedAt = now
+
+	created, err := o.outboxRepo.Create(message)
+	if err != nil || !created {
+		return created, err
+	}
+
+	go o.ProcessDue()
+	return true, nil
+}
+
+// Retry relance un message abandonné. Retourne false s'il n'existe pas ou n'est pas abandonné.
+func (o *NotificationOutbox) Retry(id primitive.ObjectID) (bool, error) {
+	requeued, err := o.outboxRepo.Requeue(id)
+	if err != nil || !requeued {
+		return requeued, err
+	}
+
+	go o.ProcessDue()
+	return true, nil
+}
+
+// ProcessDue envoie les messages dont l'échéance est atteinte.
+// Les réservations sont atomiques : plusieurs workers (ou instances) peuvent tourner en parallèle.
+func (o *NotificationOutbox) ProcessDue() {
+	// Sans Firebase, les messages restent en attente jusqu'à ce qu'il soit configuré,
+	// mais apparaissent déjà dans le centre de notifications (comme un envoi direct)
+	if !o.fcmService.isEnabled() {
+		o.recordPending()
+		return
+	}
+
+	for i := 0; i < outboxBatchSize; i++ {
+		message, err := o.outboxRepo.ClaimNext(outboxLease)
+		if err != nil {
+			log.Printf("❌ Outbox: %v", err)
+			return
+		}
+		if message == nil {
+			return
+		}
+		o.deliver(message)
+	}
+}
+
+// deliver effectue un essai d'envoi et enregistre son résultat
+func (o *NotificationOutbox) deliver(message *models.OutboxMessage) {
+	tokens, err := o.resolveTokens(message)
+	if err != nil {
+		o.fail(message, err.Error(), 0, 0)
+		return
+	}
+
+	if len(tokens) == 0 {
+		log.Printf("ℹ️  Outbox %s: aucun destinataire joignable", message.IdempotencyKey)
+		if err := o.outboxRepo.MarkSent(message.ID, 0, 0); err != nil {
+			log.Printf("❌ Outbox: %v", err)
+		}
+		return
+	}
+
+	o.record(message, tokens)
+
+	success, failed, _ := o.fcmService.deliver(tokens, message.Title, message.Body, message.Data, message.HighPriority)
+	if success == 0 && failed > 0 {
+		o.fail(message, fmt.Sprintf("%d envoi(s) en échec", failed), success, failed)
+		return
+	}
+
+	if err := o.outboxRepo.MarkSent(message.ID, success, failed); err != nil {
+		log.Printf("❌ Outbox: %v", err)
+		return
+	}
+	log.Printf("📨 Outbox %s envoyé (essai %d): %d succès, %d échecs", message.IdempotencyKey, message.Attempts, success, failed)
+}
+
+// record enregistre la notification dans le centre de notifications, une seule fois quel que soit le nombre d'essais
+func (o *NotificationOutbox) record(message *models.OutboxMessage, tokens []string) {
+	if message.Recorded {
+		return
+	}
+
+	recorded, err := o.outboxRepo.MarkRecorded(message.ID)
+	if err != nil {
+		log.Printf("⚠️  Outbox: %v", err)
+		return
+	}
+	if recorded {
+		o.fcmService.center.record(tokens, message.Title, message.Body, message.Data)
+	}
+}
+
+// recordPending enregistre dans le centre de notifications les messages en attente de Firebase
+func (o *NotificationOutbox) recordPending() {
+	messages, err := o.outboxRepo.FindUnrecorded(outboxBatchSize)
+	if err != nil {
+		log.Printf("❌ Outbox: %v", err)
+		return
+	}
+
+	for i := range messages {
+		tokens, err := o.resolveTokens(&messages[i])
+		if err != nil {
+			log.Printf("❌ Outbox %s: %v", messages[i].IdempotencyKey, err)
+			continue
+		}
+		o.record(&messages[i], tokens)
+	}
+}
+
+// resolveTokens récupère les tokens des destinataires qui acceptent la notification (catégorie, réglages de la conversation)
+func (o *NotificationOutbox) resolveTokens(message *models.OutboxMessage) ([]string, error) {
+	var candidates []models.FCMToken
+	for _, recipient := range message.Recipients {
+		tokens, err := o.fcmTokenRepo.FindByUserID(recipient)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, tokens...)
+	}
+	return o.fcmService.FilterTokens(candidates, models.NotificationContext{
+		Category:       message.Category,
+		ConversationID: message.ConversationID,
+	}), nil
+}
+
+// fail programme un nouvel essai avec un délai exponentiel, ou abandonne le message après le dernier essai
+func (o *NotificationOutbox) fail(message *models.OutboxMessage, reason string, delivered, failed int) {
+	maxAttempts := message.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = o.maxAttempts
+	}
+
+	if message.Attempts >= maxAttempts {
+		log.Printf("💀 Outbox %s abandonné après %d essai(s): %s", message.IdempotencyKey, message.Attempts, reason)
+		if err := o.outboxRepo.MarkDead(message.ID, reason, delivered, failed); err != nil {
+			log.Printf("❌ Outbox: %v", err)
+		}
+		return
+	}
+
+	delay := outboxBackoff(message.Attempts)
+	log.Printf("🔁 Outbox %s en échec (essai %d/%d): %s - nouvel essai dans %s", message.IdempotencyKey, message.Attempts, maxAttempts, reason, delay)
+	if err := o.outboxRepo.ScheduleRetry(message.ID, time.Now().Add(delay), reason, delivered, failed); err != nil {
+		log.Printf("❌ Outbox: %v", err)
+	}
+}
+
+// outboxBackoff délai avant l'essai suivant : 30s, 1min, 2min, 4min… plafonné à 1h
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"premier-an-backend/database"
+	"premier-an-backend/models"
+)
+
+// UsePreferences active le filtrage des destinataires selon leurs préférences de notifications
+func (s *FCMService) UsePreferences(repo *database.NotificationPreferenceRepository) {
+	s.preferences = repo
+}
+
+// Allows indique si l'utilisateur accepte cette notification maintenant.
+// En cas d'erreur de lecture des préférences, la notification est envoyée.
+func (s *FCMService) Allows(userID string, n models.NotificationContext) bool {
+	if s == nil || s.preferences == nil {
+		return true
+	}
+
+	preferences, err := s.preferences.FindByUserID(userID)
+	if err != nil {
+		log.Printf("⚠️  Préférences de notifications illisibles pour %s: %v", userID, err)
+		return true
+	}
+	return preferences.Allows(n, time.Now())
+}
+
+// FilterTokens retourne les tokens des utilisateurs qui acceptent cette notification
+func (s *FCMService) FilterTokens(tokens []models.FCMToken, n models.NotificationContext) []string {
+	result := make([]string, 0, len(tokens))
+	if s == nil || s.preferences == nil {
+		for _, token := range tokens {
+			result = append(result, token.Token)
+		}
+		return result
+	}
+
+	userIDs := make([]string, 0, len(tokens))
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if !seen[token.UserID] {
+			seen[token.UserID] = true
+			userIDs = append(userIDs, token.UserID)
+		}
+	}
+
+	preferences, err := s.preferences.FindByUserIDs(userIDs)
+	if err != nil {
+		log.Printf("⚠️  Préférences de notifications illisibles: %v", err)
+	}
+
+	now := time.Now()
+	skipped := 0
+	for _, token := range tokens {
+		if preferences[token.UserID].Allows(n, now) {
+			result = append(result, token.Token)
+		} else {
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		log.Printf("🔕 %d token(s) ignoré(s) selon les préférences (%s)", skipped, n.Category)
+	}
+	return result
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" // Décodeurs pour les miniatures
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ThumbnailSize taille maximale (largeur et hauteur) des miniatures d'images
+const ThumbnailSize = 320
+
+// maxThumbnailSourcePixels nombre de pixels au-delà duquel aucune miniature n'est générée en local
+const maxThumbnailSourcePixels = 50_000_000
+
+// StoredFile emplacement d'un fichier enregistré
+type StoredFile struct {
+	ID           string // Identifiant à fournir à Delete
+	ResourceType string // "image" ou "raw"
+	URL          string
+	ThumbnailURL string // Images uniquement
+	Width        int
+	Height       int
+}
+
+// FileStorage stocke les fichiers envoyés (pièces jointes du chat)
+type FileStorage interface {
+	Upload(file io.Reader, filename, folder string) (*StoredFile, error)
+	Delete(id, resourceType string) error
+}
+
+// NewFileStorage retourne un stockage Cloudinary si les identifiants sont configurés,
+// sinon un stockage sur disque dans localDir (fichiers servis sous localURL)
+func NewFileStorage(cloudinary *CloudinaryClient, localDir, localURL string) FileStorage {
+	if cloudinary == nil || !cloudinary.Configured() {
+		log.Printf("⚠️  Cloudinary non configuré - pièces jointes stockées localement dans %s", localDir)
+		return NewLocalStorage(localDir, localURL)
+	}
+	log.Println("✓ Stockage des pièces jointes : Cloudinary")
+	return NewCloudinaryStorage(cloudinary)
+}
+
+// ========== CLOUDINARY ==========
+
+// CloudinaryStorage stocke les fichiers sur Cloudinary
+type CloudinaryStorage struct {
+	client *CloudinaryClient
+}
+
+// NewCloudinaryStorage crée une nouvelle instance de CloudinaryStorage
+func NewCloudinaryStorage(client *CloudinaryClient) *CloudinaryStorage {
+	return &CloudinaryStorage{client: client}
+}
+
+// Upload envoie le fichier sur Cloudinary ; la miniature est une transformation à la volée
+func (s *CloudinaryStorage) Upload(file io.Reader, filename, folder string) (*StoredFile, error) {
+	result, err := s.client.Upload(file, filename, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &StoredFile{
+		ID:           result.PublicID,
+		ResourceType: result.ResourceType,
+		URL:          result.SecureURL,
+		Width:        result.Width,
+		Height:       result.Height,
+	}
+	if result.ResourceType == "image" {
+		transformation := fmt.Sprintf("/upload/c_limit,w_%d,h_%d/", ThumbnailSize, ThumbnailSize)
+		stored.ThumbnailURL = strings.Replace(result.SecureURL, "/upload/", transformation, 1)
+	}
+	return stored, nil
+}
+
+// Delete supprime le fichier de Cloudinary
+func (s *CloudinaryStorage) Delete(id, resourceType string) error {
+	return s.client.Destroy(id, resourceType)
+}
+
+// ========== DISQUE LOCAL (développement, tests) ==========
+
+// LocalStorage stocke les fichiers dans un dossier local, servis par Handler
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage crée une nouvelle instance de LocalStorage
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	return &LocalStorage{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Upload écrit le fichier sous un nom aléatoire et génère une miniature pour les images.
+// L'extension vient du type détecté dans le contenu, jamais du nom fourni par le client.
+func (s *LocalStorage) Upload(file io.Reader, filename, folder string) (*StoredFile, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("erreur lors de la lecture du fichier: %w", err)
+	}
+
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	name := hex.EncodeToString(random)
+	id := path.Join(folder, name+extensionForContent(data))
+
+	if err := s.write(id, data); err != nil {
+		return nil, err
+	}
+
+	stored := &StoredFile{ID: id, ResourceType: "raw", URL: s.baseURL + "/" + id}
+
+	// Miniature (formats décodables par la bibliothèque standard : JPEG, PNG, GIF),
+	// sauf pour les images démesurées qui satureraient la mémoire une fois décodées
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width*config.Height > maxThumbnailSourcePixels {
+		return stored, nil
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return stored, nil
+	}
+	stored.ResourceType = "image"
+	stored.Width = img.Bounds().Dx()
+	stored.Height = img.Bounds().Dy()
+
+	var thumb bytes.Buffer
+	thumbID := path.Join(folder, name+"_thumb.jpg")
+	if format == "png" || format == "gif" {
+		thumbID = path.Join(folder, name+"_thumb.png")
+		err = png.Encode(&thumb, resizeToFit(img, ThumbnailSize))
+	} else {
+		err = jpeg.Encode(&thumb, resizeToFit(img, ThumbnailSize), &jpeg.Options{Quality: 80})
+	}
+	if err != nil {
+		log.Printf("⚠️  Miniature non générée pour %s: %v", id, err)
+		return stored, nil
+	}
+	if err := s.write(thumbID, thumb.Bytes()); err != nil {
+		log.Printf("⚠️  Miniature non enregistrée pour %s: %v", id, err)
+		return stored, nil
+	}
+	stored.ThumbnailURL = s.baseURL + "/" + thumbID
+
+	return stored, nil
+}
+
+// Delete supprime le fichier et sa miniature. Un fichier déjà absent n'est pas une erreur.
+func (s *LocalStorage) Delete(id, resourceType string) error {
+	filePath := filepath.Join(s.dir, filepath.FromSlash(id))
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("erreur lors de la suppression du fichier: %w", err)
+	}
+
+	base := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+	for _, ext := range []string{".jpg", ".png"} {
+		if err := os.Remove(base + "_thumb" + ext); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("erreur lors de la suppression de la miniature: %w", err)
+		}
+	}
+	return nil
+}
+
+// Handler sert les fichiers stockés (sans liste des dossiers).
+// Servis depuis l'origine de l'API : seules les images s'affichent dans le navigateur, le reste est téléchargé.
+func (s *LocalStorage) Handler() http.Handler {
+	fileServer := http.FileServer(http.Dir(s.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if !isInlineImage(path.Ext(r.URL.Path)) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", "attachment")
+		}
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// write écrit un fichier dans le dossier de stockage
+func (s *LocalStorage) write(id string, data []byte) error {
+	filePath := filepath.Join(s.dir, filepath.FromSlash(id))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("erreur lors de la création du dossier de stockage: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		return fmt.Errorf("erreur lors de l'écriture du fichier: %w", err)
+	}
+	return nil
+}
+
+// preferredExtensions extensions retenues pour les types acceptés en pièce jointe
+// (mime.ExtensionsByType en retourne plusieurs, par ordre alphabétique)
+var preferredExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+	"audio/mpeg":      ".mp3",
+	"video/mp4":       ".mp4",
+}
+
+// extensionForContent retourne l'extension correspondant au type détecté dans le contenu du fichier.
+// Les contenus interprétables par un navigateur (HTML, XML, SVG) sont enregistrés en .bin.
+func extensionForContent(data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return ".bin"
+	}
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	if strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "xml") || strings.Contains(mediaType, "html") {
+		return ".bin"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// isInlineImage indique si une extension correspond à une image matricielle affichable sans risque (pas de SVG)
+func isInlineImage(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+		return true
+	}
+	return false
+}
+
+// resizeToFit réduit une image pour qu'elle tienne dans un carré de maxSize pixels (plus proche voisin)
+func resizeToFit(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	newWidth, newHeight := maxSize, height*maxSize/width
+	if height > width {
+		newWidth, newHeight = width*maxSize/height, maxSize
+	}
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		for x := 0; x < newWidth; x++ {
+			dst.Set(x, y, img.At(bounds.Min.X+x*width/newWidth, bounds.Min.Y+y*height/newHeight))
+		}
+	}
+	return dst
+}
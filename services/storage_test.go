@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testPNG génère une image PNG de la taille demandée
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encodage PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// storedPath retourne le chemin sur disque d'un fichier stocké
+func storedPath(dir, id string) string {
+	return filepath.Join(dir, filepath.FromSlash(id))
+}
+
+func TestLocalStorageUploadImage(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewLocalStorage(dir, "http://localhost/uploads/")
+
+	stored, err := storage.Upload(bytes.NewReader(testPNG(t, 800, 400)), "photo.html", "chat/conv")
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	if stored.ResourceType != "image" {
+		t.Errorf("ResourceType = %q, attendu image", stored.ResourceType)
+	}
+	if stored.Width != 800 || stored.Height != 400 {
+		t.Errorf("dimensions = %dx%d, attendu 800x400", stored.Width, stored.Height)
+	}
+	if !strings.HasPrefix(stored.ID, "chat/conv/") || !strings.HasSuffix(stored.ID, ".png") {
+		t.Errorf("ID = %q, attendu chat/conv/<nom>.png (extension issue du contenu)", stored.ID)
+	}
+	if stored.URL != "http://localhost/uploads/"+stored.ID {
+		t.Errorf("URL = %q", stored.URL)
+	}
+	if _, err := os.Stat(storedPath(dir, stored.ID)); err != nil {
+		t.Fatalf("fichier non écrit: %v", err)
+	}
+
+	// Miniature PNG réduite à ThumbnailSize en conservant les proportions
+	thumbID := strings.TrimSuffix(stored.ID, ".png") + "_thumb.png"
+	if stored.ThumbnailURL != "http://localhost/uploads/"+thumbID {
+		t.Fatalf("ThumbnailURL = %q", stored.ThumbnailURL)
+	}
+	thumbFile, err := os.Open(storedPath(dir, thumbID))
+	if err != nil {
+		t.Fatalf("miniature non écrite: %v", err)
+	}
+	defer thumbFile.Close()
+	config, err := png.DecodeConfig(thumbFile)
+	if err != nil {
+		t.Fatalf("miniature illisible: %v", err)
+	}
+	if config.Width != ThumbnailSize || config.Height != ThumbnailSize/2 {
+		t.Errorf("miniature = %dx%d, attendu %dx%d", config.Width, config.Height, ThumbnailSize, ThumbnailSize/2)
+	}
+}
+
+func TestLocalStorageUploadIgnoresClientExtension(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewLocalStorage(dir, "http://localhost/uploads")
+
+	for _, filename := range []string{"x.html", "x.svg", "x"} {
+		stored, err := storage.Upload(strings.NewReader("simple texte"), filename, "chat")
+		if err != nil {
+			t.Fatalf("Upload(%s): %v", filename, err)
+		}
+		if stored.ResourceType != "raw" || stored.ThumbnailURL != "" {
+			t.Errorf("%s: ResourceType = %q, ThumbnailURL = %q, attendu raw sans miniature", filename, stored.ResourceType, stored.ThumbnailURL)
+		}
+		if !strings.HasSuffix(stored.ID, ".txt") {
+			t.Errorf("%s: ID = %q, attendu une extension .txt", filename, stored.ID)
+		}
+	}
+}
+
+func TestLocalStorageHandlerHeaders(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewLocalStorage(dir, "http://localhost/uploads")
+
+	img, err := storage.Upload(bytes.NewReader(testPNG(t, 10, 10)), "a.png", "chat")
+	if err != nil {
+		t.Fatalf("Upload image: %v", err)
+	}
+	html, err := storage.Upload(strings.NewReader("<html><script>alert(1)</script></html>"), "a.html", "chat")
+	if err != nil {
+		t.Fatalf("Upload HTML: %v", err)
+	}
+	if !strings.HasSuffix(html.ID, ".bin") {
+		t.Errorf("ID = %q, attendu une extension .bin pour un contenu HTML", html.ID)
+	}
+
+	handler := storage.Handler()
+	tests := []struct {
+		id         string
+		attachment bool
+	}{
+		{img.ID, false},
+		{html.ID, true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+tt.id, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: statut %d", tt.id, rec.Code)
+		}
+		if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%s: X-Content-Type-Options = %q", tt.id, got)
+		}
+		if got := rec.Header().Get("Content-Disposition"); (got == "attachment") != tt.attachment {
+			t.Errorf("%s: Content-Disposition = %q", tt.id, got)
+		}
+		if ct := rec.Header().Get("Content-Type"); strings.Contains(ct, "html") || strings.Contains(ct, "svg") {
+			t.Errorf("%s: Content-Type = %q", tt.id, ct)
+		}
+	}
+
+	// Pas de liste des dossiers
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/chat/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("liste du dossier: statut %d, attendu 404", rec.Code)
+	}
+}
+
+func TestLocalStorageDelete(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewLocalStorage(dir, "http://localhost/uploads")
+
+	stored, err := storage.Upload(bytes.NewReader(testPNG(t, 400, 400)), "a.png", "chat")
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	thumbID := strings.TrimSuffix(stored.ID, ".png") + "_thumb.png"
+
+	if err := storage.Delete(stored.ID, stored.ResourceType); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, id := range []string{stored.ID, thumbID} {
+		if _, err := os.Stat(storedPath(dir, id)); !os.IsNotExist(err) {
+			t.Errorf("%s toujours présent après Delete (err = %v)", id, err)
+		}
+	}
+
+	// Un fichier déjà supprimé n'est pas une erreur
+	if err := storage.Delete(stored.ID, stored.ResourceType); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+}
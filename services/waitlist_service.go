@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"premier-an-backend/database"
+	"premier-an-backend/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// waitlistMutex évite que deux promotions simultanées traitent la même entrée
+var waitlistMutex sync.Mutex
+
+// WaitlistService gère la promotion automatique des listes d'attente
+type WaitlistService struct {
+	waitlistRepo    *database.WaitlistRepository
+	inscriptionRepo *database.InscriptionRepository
+	eventRepo       *database.EventRepository
+	outbox          *NotificationOutbox
+}
+
+// NewWaitlistService crée une nouvelle instance
+func NewWaitlistService(db *mongo.Database, outbox *NotificationOutbox) *WaitlistService {
+	return &WaitlistService{
+		waitlistRepo:    database.NewWaitlistRepository(db),
+		inscriptionRepo: database.NewInscriptionRepository(db),
+		eventRepo:       database.NewEventRepository(db),
+		outbox:          outbox,
+	}
+}
+
+// PromoteFromWaitlist inscrit les personnes en attente tant qu'il reste des places.
+// L'ordre d'arrivée est strict : si la première demande ne rentre pas, on s'arrête.
+func (s *WaitlistService) PromoteFromWaitlist(eventID primitive.ObjectID) {
+	waitlistMutex.Lock()
+	defer waitlistMutex.Unlock()
+
+	event, err := s.eventRepo.FindByID(eventID)
+	if err != nil || event == nil {
+		log.Printf("⚠️  Liste d'attente: événement %s introuvable", eventID.Hex())
+		return
+	}
+
+	// Pas de promotion pour un événement annulé ou terminé
+	if event.Statut == "annule" || event.Statut == "termine" {
+		return
+	}
+
+	entries, err := s.waitlistRepo.FindPendingByEvent(eventID)
+	if err != nil {
+		log.Printf("⚠️  Erreur récupération liste d'attente: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		// Déjà inscrit entre-temps : l'entrée n'a plus lieu d'être
+		existing, err := s.inscriptionRepo.FindByEventAndUser(eventID, entry.UserEmail)
+		if err != nil {
+			log.Printf("⚠️  Erreur vérification inscription %s: %v", entry.UserEmail, err)
+			return
+		}
+		if existing != nil {
+			s.waitlistRepo.MarkPromoted(entry.ID)
+			continue
+		}
+
+		// Réserver les places de façon atomique ; s'il n'y en a pas assez, on s'arrête
+		reserved, err := s.eventRepo.ReservePlaces(eventID, entry.NombrePersonnes)
+		if err != nil {
+			log.Printf("⚠️  Erreur réservation places: %v", err)
+			return
+		}
+		if !reserved {
+			break
+		}
+
+		inscription := &models.Inscription{
+			EventID:         eventID,
+			UserEmail:       entry.UserEmail,
+			NombrePersonnes: entry.NombrePersonnes,
+			Accompagnants:   entry.Accompagnants,
+		}
+		if err := s.inscriptionRepo.Create(inscription); err != nil {
+			log.Printf("⚠️  Erreur promotion %s: %v", entry.UserEmail, err)
+			s.eventRepo.ReleasePlaces(eventID, entry.NombrePersonnes)
+			return
+		}
+
+		if _, err := s.waitlistRepo.MarkPromoted(entry.ID); err != nil {
+			log.Printf("⚠️  Erreur marquage entrée promue: %v", err)
+		}
+
+		log.Printf("✓ Liste d'attente: %s promu à l'événement %s (%d personnes)", entry.UserEmail, event.Titre, entry.NombrePersonnes)
+
+		s.notifyPromotion(entry.UserEmail, event, inscription)
+	}
+}
+
+// notifyPromotion prévient l'utilisateur que sa place est confirmée.
+// Notification prioritaire, envoyée via l'outbox : elle est relancée si FCM échoue ou si le serveur redémarre.
+func (s *WaitlistService) notifyPromotion(userEmail string, event *models.Event, inscription *models.Inscription) {
+	if s.outbox == nil {
+		return
+	}
+
+	title := "🎉 Une place s'est libérée !"
+	message := fmt.Sprintf("Votre inscription à %s est confirmée (%d personne(s))", event.Titre, inscription.NombrePersonnes)
+
+	data := map[string]string{
+		"type":             "waitlist_promoted",
+		"event_id":         event.ID.Hex(),
+		"event_titre":      event.Titre,
+		"inscription_id":   inscription.ID.Hex(),
+		"nombre_personnes": fmt.Sprintf("%d", inscription.NombrePersonnes),
+	}
+
+	_, err := s.outbox.Enqueue(&models.OutboxMessage{
+		IdempotencyKey: "waitlist_promoted:" + inscription.ID.Hex(),
+		Category:       models.NotificationCategoryGeneral,
+		HighPriority:   true,
+		Recipients:     []string{userEmail},
+		Title:          title,
+		Body:           message,
+		Data:           data,
+	})
+	if err != nil {
+		log.Printf("❌ Erreur mise en file de la notification liste d'attente pour %s: %v", userEmail, err)
+		return
+	}
+	log.Printf("📧 Notification liste d'attente mise en file pour %s", userEmail)
+}
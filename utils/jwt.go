@@ -7,21 +7,27 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AccessTokenDuration est la durée de vie d'un token d'accès JWT.
+// Il est renouvelé via le refresh token de la session.
+const AccessTokenDuration = 15 * time.Minute
+
 // Claims représente les revendications JWT personnalisées
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken génère un token JWT pour un utilisateur
-func GenerateToken(userID string, email string, secret string) (string, error) {
-	// Créer les revendications avec une expiration de 24 heures
+// GenerateToken génère un token d'accès JWT rattaché à une session
+func GenerateToken(userID string, email string, sessionID string, secret string) (string, error) {
+	// Créer les revendications avec une expiration courte
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenDuration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
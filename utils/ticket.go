@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// GenerateTicketToken génère le token signé (HMAC-SHA256) d'un billet d'inscription.
+// Format : <inscription_id>.<event_id>.<signature>, assez court pour un QR code.
+func GenerateTicketToken(inscriptionID string, eventID string, secret string) string {
+	payload := inscriptionID + "." + eventID
+	return payload + "." + signTicket(payload, secret)
+}
+
+// ValidateTicketToken vérifie la signature d'un billet et retourne ses identifiants
+func ValidateTicketToken(token string, secret string) (inscriptionID string, eventID string, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("format de billet invalide")
+	}
+
+	payload := parts[0] + "." + parts[1]
+	expected := signTicket(payload, secret)
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return "", "", fmt.Errorf("signature du billet invalide")
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// signTicket calcule la signature d'un billet
+func signTicket(payload string, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("ticket:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
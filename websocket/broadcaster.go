@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// Types d'envoi relayés entre les instances du serveur
+const (
+	EnvelopeUser         = "user"         // Destinataires listés dans UserIDs
+	EnvelopeConversation = "conversation" // Membres d'une room de conversation
+	EnvelopeGroup        = "group"        // Membres d'une room de groupe
+	EnvelopeGroupLeave   = "group_leave"  // Retrait des UserIDs de la room de groupe (sans payload)
+)
+
+// Envelope est un envoi WebSocket publié sur le backplane pour les autres instances
+type Envelope struct {
+	NodeID         string          `json:"node_id" bson:"node_id"`
+	Kind           string          `json:"kind" bson:"kind"`
+	UserIDs        []string        `json:"user_ids,omitempty" bson:"user_ids,omitempty"`
+	ConversationID string          `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"`
+	GroupID        string          `json:"group_id,omitempty" bson:"group_id,omitempty"`
+	ExcludeUserID  string          `json:"exclude_user_id,omitempty" bson:"exclude_user_id,omitempty"`
+	Seq            int64           `json:"seq,omitempty" bson:"seq,omitempty"` // Numéro de séquence du destinataire (EnvelopeUser)
+	Payload        json.RawMessage `json:"payload" bson:"-"`
+}
+
+// Broadcaster relaie les envois WebSocket entre les instances du serveur.
+// Chaque instance livre d'abord à ses propres connexions, puis publie l'envoi ;
+// les autres instances le reçoivent via Subscribe et le livrent à leurs connexions.
+type Broadcaster interface {
+	// Publish diffuse un envoi à toutes les instances abonnées
+	Publish(envelope *Envelope) error
+
+	// Subscribe enregistre la fonction appelée pour chaque envoi publié (y compris les siens)
+	Subscribe(handler func(envelope *Envelope)) error
+
+	// Close arrête la réception
+	Close() error
+}
+
+// newNodeID génère l'identifiant de l'instance, pour ignorer ses propres envois
+func newNodeID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// MemoryBroadcaster relaie les envois en mémoire.
+// Suffisant pour une seule instance ; partagé entre plusieurs Hub, il simule un cluster dans un même processus.
+type MemoryBroadcaster struct {
+	mu       sync.RWMutex
+	handlers []func(envelope *Envelope)
+}
+
+// NewMemoryBroadcaster crée un broadcaster en mémoire
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{}
+}
+
+// Publish appelle chaque abonné
+func (b *MemoryBroadcaster) Publish(envelope *Envelope) error {
+	b.mu.RLock()
+	handlers := make([]func(envelope *Envelope), len(b.handlers))
+	copy(handlers, b.handlers)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(envelope)
+	}
+	return nil
+}
+
+// Subscribe enregistre un abonné
+func (b *MemoryBroadcaster) Subscribe(handler func(envelope *Envelope)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+// Close retire tous les abonnés
+func (b *MemoryBroadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = nil
+	return nil
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Codes d'erreur MongoDB : le jeton de reprise n'est plus utilisable
+const (
+	changeStreamFatalError  = 280
+	changeStreamHistoryLost = 286
+)
+
+// eventTTL durée de conservation des envois dans la collection (le change stream les lit immédiatement)
+const eventTTL = 60 * time.Second
+
+// mongoEnvelope est le document stocké dans la collection "ws_events"
+type mongoEnvelope struct {
+	Envelope  `bson:",inline"`
+	Payload   string    `bson:"payload"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// MongoBroadcaster relaie les envois entre instances via un change stream MongoDB
+// (nécessite un replica set, ce qui est le cas de MongoDB Atlas)
+type MongoBroadcaster struct {
+	collection *mongo.Collection
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// NewMongoBroadcaster crée un broadcaster basé sur la collection "ws_events"
+func NewMongoBroadcaster(db *mongo.Database) *MongoBroadcaster {
+	collection := db.Collection("ws_events")
+
+	// Les envois sont purgés automatiquement par MongoDB
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(eventTTL.Seconds())),
+	})
+	if err != nil {
+		log.Printf("⚠️  Impossible de créer l'index TTL ws_events: %v", err)
+	}
+
+	streamCtx, streamCancel := context.WithCancel(context.Background())
+	return &MongoBroadcaster{
+		collection: collection,
+		ctx:        streamCtx,
+		cancel:     streamCancel,
+	}
+}
+
+// Publish insère l'envoi dans la collection
+func (b *MongoBroadcaster) Publish(envelope *Envelope) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := b.collection.InsertOne(ctx, mongoEnvelope{
+		Envelope:  *envelope,
+		Payload:   string(envelope.Payload),
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+// Subscribe écoute les insertions en continu (reconnexion automatique en cas d'erreur).
+// La reprise repart du dernier envoi lu : aucun envoi n'est perdu pendant l'interruption.
+func (b *MongoBroadcaster) Subscribe(handler func(envelope *Envelope)) error {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+
+		var resumeToken bson.Raw
+		for b.ctx.Err() == nil {
+			token, err := b.watch(handler, resumeToken)
+			resumeToken = token
+			if err == nil || b.ctx.Err() != nil {
+				continue
+			}
+
+			// Position sortie de l'oplog : reprendre à partir de maintenant
+			var serverErr mongo.ServerError
+			if errors.As(err, &serverErr) && (serverErr.HasErrorCode(changeStreamHistoryLost) || serverErr.HasErrorCode(changeStreamFatalError)) {
+				log.Printf("⚠️  Reprise du change stream ws_events impossible, des envois ont pu être perdus: %v", err)
+				resumeToken = nil
+			}
+
+			log.Printf("⚠️  Change stream ws_events interrompu: %v (nouvelle tentative dans 5s)", err)
+			select {
+			case <-time.After(5 * time.Second):
+			case <-b.ctx.Done():
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watch lit le change stream jusqu'à une erreur ou l'arrêt, en reprenant après resumeAfter s'il est fourni.
+// Retourne le jeton de reprise du dernier envoi lu.
+func (b *MongoBroadcaster) watch(handler func(envelope *Envelope), resumeAfter bson.Raw) (bson.Raw, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": "insert"}}},
+	}
+	opts := options.ChangeStream()
+	if resumeAfter != nil {
+		opts.SetResumeAfter(resumeAfter)
+	}
+
+	stream, err := b.collection.Watch(b.ctx, pipeline, opts)
+	if err != nil {
+		return resumeAfter, err
+	}
+	defer stream.Close(context.Background())
+
+	resumeToken := resumeAfter
+	for stream.Next(b.ctx) {
+		var change struct {
+			FullDocument mongoEnvelope `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			log.Printf("❌ Envoi ws_events illisible: %v", err)
+		} else {
+			envelope := change.FullDocument.Envelope
+			envelope.Payload = json.RawMessage(change.FullDocument.Payload)
+			handler(&envelope)
+		}
+		resumeToken = stream.ResumeToken()
+	}
+
+	if token := stream.ResumeToken(); token != nil {
+		resumeToken = token
+	}
+	return resumeToken, stream.Err()
+}
+
+// Close arrête le change stream
+func (b *MongoBroadcaster) Close() error {
+	b.cancel()
+	b.wg.Wait()
+	return nil
+}
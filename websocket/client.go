@@ -1,10 +1,11 @@
 package websocket
 
 import (
-	"encoding/json"
 	"log"
 	"time"
 
+	"premier-an-backend/models"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -28,6 +29,15 @@ type Client struct {
 	conn   *websocket.Conn
 	send   chan interface{}
 	UserID string
+
+	protocolVersion int // Version du protocole négociée à l'authentification
+
+	// Reprise après reconnexion (last_seq envoyé avec authenticate)
+	resume      bool
+	resumeFrom  int64
+	replayedSeq int64          // Dernier événement déjà renvoyé par le replay
+	replaying   bool           // Événements manqués en cours de chargement
+	pending     []pendingEvent // Événements reçus en direct pendant le chargement
 }
 
 // readPump pompe les messages de la connexion WebSocket vers le hub
@@ -51,82 +61,58 @@ func (c *Client) readPump() {
 			break
 		}
 
-		// Parser le message JSON
-		var msg map[string]interface{}
-		if err := json.Unmarshal(message, &msg); err != nil {
-			log.Printf("❌ Erreur parsing message: %v", err)
-			continue
-		}
-
-		msgType, ok := msg["type"].(string)
-		if !ok {
+		// Décoder et valider le message (erreurs renvoyées au client sous forme de frame "error")
+		msg, protoErr := decodeInbound(message)
+		if protoErr != nil {
+			log.Printf("⚠️  Message WebSocket refusé de %s: %s (%s)", c.UserID, protoErr.Code, protoErr.Message)
+			c.reply(protoErr)
 			continue
 		}
 
 		// Traiter les messages
-		switch msgType {
-		case "join_conversation":
-			if convID, ok := msg["conversation_id"].(string); ok {
-				c.hub.JoinConversation(c.UserID, convID)
-			}
-
-		case "leave_conversation":
-			if convID, ok := msg["conversation_id"].(string); ok {
-				c.hub.LeaveConversation(c.UserID, convID)
+		switch m := msg.(type) {
+		case *models.WSConversationRoom:
+			if m.Type == models.WSTypeJoinConversation {
+				c.hub.JoinConversation(c.UserID, m.ConversationID)
+			} else {
+				c.hub.LeaveConversation(c.UserID, m.ConversationID)
 			}
 
-		case "typing":
-			// ⌨️ Gérer le typing indicator
-			if convID, ok := msg["conversation_id"].(string); ok {
-				isTyping, _ := msg["is_typing"].(bool)
-				c.hub.HandleTyping(c.UserID, convID, isTyping)
-			} else if groupID, ok := msg["group_id"].(string); ok {
-				// ⌨️ Gérer le typing indicator pour les groupes
-				isTyping, _ := msg["is_typing"].(bool)
-				c.hub.HandleGroupTyping(c.UserID, groupID, isTyping)
+		case *models.WSTyping:
+			// ⌨️ Gérer le typing indicator (conversation privée ou groupe)
+			if m.GroupID != "" {
+				c.hub.HandleGroupTyping(c.UserID, m.GroupID, m.IsTyping)
 			} else {
+				c.hub.HandleTyping(c.UserID, m.ConversationID, m.IsTyping)
 			}
 
-		case "join_group":
-			// 👥 Rejoindre un groupe
-			if groupID, ok := msg["group_id"].(string); ok {
-				c.hub.JoinGroup(c.UserID, groupID)
+		case *models.WSGroupRoom:
+			if m.Type == models.WSTypeJoinGroup {
+				// 👥 Rejoindre un groupe
+				c.hub.JoinGroup(c.UserID, m.GroupID)
 				// Confirmer au client
-				c.send <- map[string]interface{}{
-					"type":     "joined_group",
-					"group_id": groupID,
-				}
+				c.reply(models.WSJoinedGroup{
+					Type:    models.WSTypeJoinedGroup,
+					GroupID: m.GroupID,
+				})
 			} else {
+				// 👋 Quitter un groupe
+				c.hub.LeaveGroup(c.UserID, m.GroupID)
 			}
 
-		case "leave_group":
-			// 👋 Quitter un groupe
-			if groupID, ok := msg["group_id"].(string); ok {
-				c.hub.LeaveGroup(c.UserID, groupID)
-			}
-
-		case "group_typing":
+		case *models.WSGroupTyping:
 			// ⌨️ Gérer le typing indicator dans un groupe
-			if groupID, ok := msg["group_id"].(string); ok {
-				isTyping, _ := msg["is_typing"].(bool)
-				c.hub.HandleGroupTyping(c.UserID, groupID, isTyping)
-			}
+			c.hub.HandleGroupTyping(c.UserID, m.GroupID, m.IsTyping)
 
-		case "user_presence":
+		case *models.WSPresenceUpdate:
 			// 👤 Gérer la présence utilisateur (mise à jour automatique)
 			// Le frontend envoie cet événement quand l'utilisateur navigue sur le site
-			isOnline, ok := msg["is_online"].(bool)
-			if !ok {
-				continue
-			}
+			isOnline := *m.IsOnline
+			lastSeen, _ := parseLastSeen(m.LastSeen) // Format déjà validé
 
-			// Extraire last_seen si fourni (format ISO 8601 string)
-			var lastSeen *time.Time
-			if lastSeenStr, ok := msg["last_seen"].(string); ok && lastSeenStr != "" && lastSeenStr != "null" {
-				parsed, err := time.Parse(time.RFC3339, lastSeenStr)
-				if err == nil {
-					lastSeen = &parsed
-				}
+			// Un autre onglet / appareil reste connecté (sur n'importe quelle instance) : l'utilisateur n'est pas hors ligne
+			if !isOnline && c.hub.ClusterConnectionCount(c.UserID) > 1 {
+				continue
 			}
 
 			// Mettre à jour la présence via le gestionnaire
@@ -135,22 +121,27 @@ func (c *Client) readPump() {
 
 				// Si l'utilisateur est actif, mettre à jour last_activity en DB
 				if isOnline {
-					if err := c.hub.updateUserActivityInDB(c.UserID); err != nil {
-					}
+					c.hub.updateUserActivityInDB(c.UserID)
 				} else if lastSeen != nil {
 					// Si hors ligne avec last_seen, mettre à jour en DB
-					if err := c.hub.updateUserLastSeenInDB(c.UserID, lastSeen); err != nil {
-					}
+					c.hub.updateUserLastSeenInDB(c.UserID, lastSeen)
 				}
-
-			} else {
 			}
 
-		default:
+		case *models.WSAuthenticate:
+			frame := protocolError(models.WSErrorInvalidField, "type", "Connexion déjà authentifiée")
+			frame.RequestType = m.Type
+			c.reply(frame)
 		}
 	}
 }
 
+// reply envoie une frame à cette connexion en passant par le hub.
+// readPump n'écrit jamais directement dans c.send : le hub peut l'avoir fermé (connexion trop lente).
+func (c *Client) reply(payload interface{}) {
+	c.hub.replies <- &clientReply{client: c, payload: payload}
+}
+
 // writePump pompe les messages du hub vers la connexion WebSocket
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
@@ -1,9 +1,9 @@
 package websocket
 
 import (
-	"encoding/json"
 	"log"
 	"net/http"
+	"premier-an-backend/models"
 	"premier-an-backend/utils"
 
 	"github.com/gorilla/websocket"
@@ -18,17 +18,24 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// SessionRepository interface pour vérifier qu'une session n'a pas été révoquée
+type SessionRepository interface {
+	IsActive(sessionID string) (bool, error)
+}
+
 // Handler gère les connexions WebSocket
 type Handler struct {
-	hub       *Hub
-	jwtSecret string
+	hub         *Hub
+	jwtSecret   string
+	sessionRepo SessionRepository
 }
 
 // NewHandler crée un nouveau handler WebSocket
-func NewHandler(hub *Hub, jwtSecret string) *Handler {
+func NewHandler(hub *Hub, jwtSecret string, sessionRepo SessionRepository) *Handler {
 	return &Handler{
-		hub:       hub,
-		jwtSecret: jwtSecret,
+		hub:         hub,
+		jwtSecret:   jwtSecret,
+		sessionRepo: sessionRepo,
 	}
 }
 
@@ -59,56 +66,59 @@ func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Parser le message d'authentification
-		var authMsg map[string]interface{}
-		if err := json.Unmarshal(message, &authMsg); err != nil {
-			log.Printf("❌ Erreur parsing auth: %v", err)
-			conn.Close()
-			return
+		// Le premier message doit être un authenticate valide
+		msg, protoErr := decodeInbound(message)
+		authMsg, ok := msg.(*models.WSAuthenticate)
+		if !ok && (protoErr == nil || protoErr.RequestType != models.WSTypeAuthenticate) {
+			protoErr = protocolError(models.WSErrorAuthenticationRequired, "type", "Authentification requise")
 		}
-
-		// Vérifier le type
-		if authMsg["type"] != "authenticate" {
-			log.Println("❌ Premier message doit être 'authenticate'")
-			conn.WriteJSON(map[string]interface{}{
-				"type":    "error",
-				"message": "Authentification requise",
-			})
-			conn.Close()
+		if protoErr != nil {
+			log.Printf("❌ Authentification WebSocket refusée: %s (%s)", protoErr.Code, protoErr.Message)
+			rejectConn(conn, protoErr)
 			return
 		}
 
-		// Extraire et valider le token
-		token, ok := authMsg["token"].(string)
-		if !ok || token == "" {
-			log.Println("❌ Token manquant")
-			conn.WriteJSON(map[string]interface{}{
-				"type":    "error",
-				"message": "Token requis",
-			})
-			conn.Close()
+		// Négocier la version du protocole
+		version, ok := negotiateVersion(authMsg.ProtocolVersion)
+		if !ok {
+			log.Printf("❌ Version de protocole non supportée: %d", authMsg.ProtocolVersion)
+			rejectConn(conn, protocolError(models.WSErrorUnsupportedVersion, "protocol_version", "Version de protocole non supportée"))
 			return
 		}
 
 		// Valider le JWT token
-		claims, err := utils.ValidateToken(token, h.jwtSecret)
+		claims, err := utils.ValidateToken(authMsg.Token, h.jwtSecret)
 		if err != nil {
 			log.Printf("❌ Token invalide: %v", err)
-			conn.WriteJSON(map[string]interface{}{
-				"type":    "error",
-				"message": "Token invalide ou expiré",
-			})
-			conn.Close()
+			rejectConn(conn, protocolError(models.WSErrorInvalidToken, "token", "Token invalide ou expiré"))
+			return
+		}
+
+		// Vérifier que la session n'a pas été révoquée
+		active, err := h.sessionRepo.IsActive(claims.SessionID)
+		if err != nil || !active {
+			log.Printf("❌ Session révoquée ou expirée pour %s", claims.UserID)
+			rejectConn(conn, protocolError(models.WSErrorSessionRevoked, "", "Session révoquée ou expirée"))
 			return
 		}
 
 		// Authentification réussie
 		client.UserID = claims.UserID
+		client.protocolVersion = version
+
+		// Reprise : le client indique le dernier événement reçu
+		if authMsg.LastSeq != nil {
+			client.resume = true
+			client.replaying = true
+			client.resumeFrom = *authMsg.LastSeq
+		}
 
 		// Envoyer la confirmation
-		conn.WriteJSON(map[string]interface{}{
-			"type":    "authenticated",
-			"user_id": client.UserID,
+		conn.WriteJSON(models.WSAuthenticated{
+			Type:            models.WSTypeAuthenticated,
+			UserID:          client.UserID,
+			ProtocolVersion: version,
+			LastSeq:         h.hub.CurrentSeq(client.UserID),
 		})
 
 		// Enregistrer le client dans le hub
@@ -117,5 +127,16 @@ func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 		// Démarrer les pumps
 		go client.writePump()
 		go client.readPump()
+
+		// 🔁 Reprise : charger les événements manqués hors de la boucle du hub, qui les renverra
+		if client.resume {
+			h.hub.replays <- h.hub.loadReplay(client)
+		}
 	}()
 }
+
+// rejectConn envoie une frame d'erreur puis ferme une connexion non authentifiée
+func rejectConn(conn *websocket.Conn, frame *models.WSError) {
+	conn.WriteJSON(frame)
+	conn.Close()
+}
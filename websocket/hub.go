@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
@@ -28,8 +29,8 @@ type ChatRepository interface {
 
 // Hub gère les connexions WebSocket actives
 type Hub struct {
-	// Connexions actives par user_id
-	connections map[string]*Client
+	// Connexions actives par user_id (un utilisateur peut avoir plusieurs onglets / appareils)
+	connections map[string]map[*Client]bool
 
 	// Rooms de conversations (conversation_id -> [user_id])
 	rooms map[string]map[string]bool
@@ -49,12 +50,39 @@ type Hub struct {
 	// Canal pour diffuser les messages
 	broadcast chan *Message
 
+	// Canal des réponses à une seule connexion (erreurs de protocole, accusés)
+	replies chan *clientReply
+
+	// Canal des événements manqués chargés pour les connexions qui reprennent
+	replays chan *replayBatch
+
 	// Repositories pour la gestion de la présence
 	userRepo *database.UserRepository
 	chatRepo *database.ChatRepository
 
 	// Gestionnaire de présence avec timeouts automatiques
 	presenceManager *PresenceManager
+
+	// Journal des événements par utilisateur (numéros de séquence, reprise après reconnexion)
+	eventLog *database.WSEventLogRepository
+
+	// Relais des envois vers les autres instances du serveur
+	broadcaster Broadcaster
+	nodeID      string
+	clustered   bool // Plusieurs instances : la présence se lit en base
+
+	// Connexions par instance : un utilisateur ne passe hors ligne qu'une fois déconnecté de toutes les instances
+	presence *database.WSPresenceRepository
+
+	// File des mises à jour de présence, traitées dans l'ordre hors de la boucle du hub (accès base)
+	presenceUpdates chan presenceUpdate
+}
+
+// presenceUpdate connexion ou déconnexion à répercuter sur les compteurs et la présence
+type presenceUpdate struct {
+	userID     string
+	connect    bool
+	lastDevice bool // Déconnexion de la dernière connexion locale de l'utilisateur
 }
 
 // Message représente un message WebSocket à diffuser
@@ -64,19 +92,49 @@ type Message struct {
 	UserIDs        []string // Si vide, envoyer à toute la conversation
 	ExcludeUserID  string   // Ne pas envoyer à cet utilisateur
 	Payload        interface{}
+	Seq            int64 // Numéro de séquence (envoi à un seul utilisateur), 0 si non journalisé
+}
+
+// clientReply frame destinée à une seule connexion.
+// Seule la boucle du hub écrit dans client.send : une connexion déjà fermée est ignorée.
+type clientReply struct {
+	client  *Client
+	payload interface{}
 }
 
-// NewHub crée un nouveau hub WebSocket
-func NewHub(userRepo *database.UserRepository, chatRepo *database.ChatRepository) *Hub {
+// NewHub crée un nouveau hub WebSocket.
+// Sans broadcaster, les envois restent dans ce processus (une seule instance).
+func NewHub(userRepo *database.UserRepository, chatRepo *database.ChatRepository, broadcaster Broadcaster, eventLog *database.WSEventLogRepository, presence *database.WSPresenceRepository) *Hub {
+	_, inMemory := broadcaster.(*MemoryBroadcaster)
+	if broadcaster == nil {
+		broadcaster = NewMemoryBroadcaster()
+		inMemory = true
+	}
+
 	hub := &Hub{
-		connections: make(map[string]*Client),
-		rooms:       make(map[string]map[string]bool),
-		groupRooms:  make(map[string]map[string]bool),
-		register:    make(chan *Client),
-		unregister:  make(chan *Client),
-		broadcast:   make(chan *Message, 256),
-		userRepo:    userRepo,
-		chatRepo:    chatRepo,
+		connections:     make(map[string]map[*Client]bool),
+		rooms:           make(map[string]map[string]bool),
+		groupRooms:      make(map[string]map[string]bool),
+		register:        make(chan *Client),
+		unregister:      make(chan *Client),
+		broadcast:       make(chan *Message, 256),
+		replies:         make(chan *clientReply, 256),
+		replays:         make(chan *replayBatch),
+		presenceUpdates: make(chan presenceUpdate, 1024),
+		userRepo:        userRepo,
+		chatRepo:        chatRepo,
+		eventLog:        eventLog,
+		broadcaster:     broadcaster,
+		nodeID:          newNodeID(),
+		clustered:       !inMemory,
+	}
+	if hub.clustered {
+		hub.presence = presence
+	}
+
+	// Recevoir les envois des autres instances
+	if err := broadcaster.Subscribe(hub.handleEnvelope); err != nil {
+		log.Printf("❌ Abonnement au broadcaster impossible: %v", err)
 	}
 
 	// Initialiser le gestionnaire de présence
@@ -86,6 +144,12 @@ func NewHub(userRepo *database.UserRepository, chatRepo *database.ChatRepository
 		hub.getCurrentUserStatus,
 	)
 
+	go hub.applyPresenceUpdates()
+
+	if hub.presence != nil {
+		go hub.heartbeat()
+	}
+
 	return hub
 }
 
@@ -95,51 +159,52 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
-			h.connections[client.UserID] = client
+			if h.connections[client.UserID] == nil {
+				h.connections[client.UserID] = make(map[*Client]bool)
+			}
+			h.connections[client.UserID][client] = true
 			h.mu.Unlock()
 
+			// 🔌 Compter la connexion et mettre à jour la présence avec timeout automatique
+			h.presenceUpdates <- presenceUpdate{userID: client.UserID, connect: true}
+
 			// 🔌 Auto-joindre toutes les conversations de l'utilisateur
 			go h.autoJoinUserConversations(client.UserID)
 
 			// 🔌 Auto-joindre tous les groupes de l'utilisateur
 			go h.autoJoinUserGroups(client.UserID)
 
-			// 🔌 Mettre à jour la présence avec timeout automatique
-			if h.presenceManager != nil {
-				h.presenceManager.UpdateUserPresence(client.UserID, true)
-			}
-
 		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.connections[client.UserID]; ok {
-				delete(h.connections, client.UserID)
-				close(client.send)
-
-				// Retirer de toutes les rooms
-				for roomID, members := range h.rooms {
-					delete(members, client.UserID)
-					if len(members) == 0 {
-						delete(h.rooms, roomID)
-					}
-				}
+			h.disconnectClient(client)
 
-				// Retirer de toutes les group rooms
-				for groupID, members := range h.groupRooms {
-					delete(members, client.UserID)
-					if len(members) == 0 {
-						delete(h.groupRooms, groupID)
-					}
+		case batch := <-h.replays:
+			// 🔁 Reprise : renvoyer les événements manqués avant les envois reçus entre-temps
+			if h.replay(batch) {
+				h.disconnectClient(batch.client)
+			}
+
+		case reply := <-h.replies:
+			h.mu.RLock()
+			registered := h.connections[reply.client.UserID][reply.client]
+			full := false
+			if registered {
+				select {
+				case reply.client.send <- reply.payload:
+				default:
+					full = true
 				}
 			}
-			h.mu.Unlock()
+			h.mu.RUnlock()
 
-			// 🔌 Mettre à jour la présence (marquer comme hors ligne immédiatement)
-			if h.presenceManager != nil {
-				h.presenceManager.UpdateUserPresence(client.UserID, false)
-				h.presenceManager.RemoveUser(client.UserID)
+			// Le client envoie sans lire sa socket : la connexion est fermée
+			if full {
+				log.Printf("❌ Canal plein pour %s", reply.client.UserID)
+				h.disconnectClient(reply.client)
 			}
 
 		case message := <-h.broadcast:
+			var slowClients []*Client
+
 			h.mu.RLock()
 
 			// Si UserIDs spécifié, envoyer uniquement à ces utilisateurs
@@ -148,20 +213,8 @@ func (h *Hub) Run() {
 					if userID == message.ExcludeUserID {
 						continue
 					}
-					if client, ok := h.connections[userID]; ok {
-						select {
-						case client.send <- message.Payload:
-							// Message envoyé avec succès
-						default:
-							log.Printf("❌ Canal plein pour %s", userID)
-							close(client.send)
-							delete(h.connections, userID)
-						}
-					} else {
-						// Utilisateur non connecté - c'est normal s'il n'est pas sur une page avec WebSocket
-						// Ne pas logger comme erreur pour éviter de polluer les logs
-						_ = userID // Utilisé implicitement dans le commentaire ci-dessus
-					}
+					// Utilisateur non connecté : c'est normal s'il n'est pas sur une page avec WebSocket
+					slowClients = append(slowClients, h.deliver(userID, message.Payload, message.Seq)...)
 				}
 			} else if message.ConversationID != "" {
 				// Sinon, envoyer à tous les membres de la conversation
@@ -170,25 +223,140 @@ func (h *Hub) Run() {
 						if userID == message.ExcludeUserID {
 							continue
 						}
-						if client, ok := h.connections[userID]; ok {
-							select {
-							case client.send <- message.Payload:
-								// Message envoyé avec succès
-							default:
-								log.Printf("❌ Canal plein pour %s", userID)
-								close(client.send)
-								delete(h.connections, userID)
-							}
-						} else {
-							// Utilisateur dans la room mais pas connecté - c'est normal
-							// Ne pas logger comme erreur pour éviter de polluer les logs
-							_ = userID // Utilisé implicitement dans le commentaire ci-dessus
-						}
+						slowClients = append(slowClients, h.deliver(userID, message.Payload, 0)...)
 					}
 				}
 			}
 
 			h.mu.RUnlock()
+
+			// Les connexions qui ne suivent plus sont fermées
+			for _, client := range slowClients {
+				h.disconnectClient(client)
+			}
+		}
+	}
+}
+
+// deliver envoie un payload à toutes les connexions d'un utilisateur.
+// Une connexion qui a déjà reçu cet événement par replay est ignorée ; pendant le chargement du replay,
+// les événements numérotés (envoyés uniquement par Run) sont mis de côté.
+// Retourne les connexions dont le canal est plein. L'appelant doit détenir h.mu.
+func (h *Hub) deliver(userID string, payload interface{}, seq int64) []*Client {
+	var slowClients []*Client
+
+	for client := range h.connections[userID] {
+		if seq > 0 && seq <= client.replayedSeq {
+			continue
+		}
+
+		// Replay en cours de chargement : envoyé après les événements manqués
+		if seq > 0 && client.replaying {
+			if len(client.pending) >= cap(client.send) {
+				log.Printf("❌ Canal plein pour %s", userID)
+				slowClients = append(slowClients, client)
+				continue
+			}
+			client.pending = append(client.pending, pendingEvent{seq: seq, payload: payload})
+			continue
+		}
+
+		select {
+		case client.send <- payload:
+			// Message envoyé avec succès
+		default:
+			log.Printf("❌ Canal plein pour %s", userID)
+			slowClients = append(slowClients, client)
+		}
+	}
+
+	return slowClients
+}
+
+// disconnectClient retire une connexion du hub.
+// L'utilisateur ne quitte ses rooms et ne passe hors ligne qu'à la fermeture de sa dernière connexion.
+func (h *Hub) disconnectClient(client *Client) {
+	h.mu.Lock()
+	clients, ok := h.connections[client.UserID]
+	if !ok || !clients[client] {
+		// Déjà retirée (canal plein ou arrêt du hub)
+		h.mu.Unlock()
+		return
+	}
+
+	delete(clients, client)
+	close(client.send)
+
+	lastDevice := len(clients) == 0
+	if lastDevice {
+		delete(h.connections, client.UserID)
+
+		// Retirer de toutes les rooms
+		for roomID, members := range h.rooms {
+			delete(members, client.UserID)
+			if len(members) == 0 {
+				delete(h.rooms, roomID)
+			}
+		}
+
+		// Retirer de toutes les group rooms
+		for groupID, members := range h.groupRooms {
+			delete(members, client.UserID)
+			if len(members) == 0 {
+				delete(h.groupRooms, groupID)
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	// 🔌 Décompter la connexion et mettre à jour la présence
+	h.presenceUpdates <- presenceUpdate{userID: client.UserID, lastDevice: lastDevice}
+}
+
+// applyPresenceUpdates traite les connexions et déconnexions dans l'ordre où le hub les a enregistrées.
+// Les compteurs et la présence sont en base : ces accès ne doivent pas bloquer la boucle du hub.
+func (h *Hub) applyPresenceUpdates() {
+	for update := range h.presenceUpdates {
+		if update.connect {
+			if h.presence != nil {
+				if err := h.presence.Connect(h.nodeID, update.userID); err != nil {
+					log.Printf("❌ Erreur comptage connexion de %s: %v", update.userID, err)
+				}
+			}
+			if h.presenceManager != nil {
+				h.presenceManager.UpdateUserPresence(update.userID, true)
+			}
+			continue
+		}
+
+		// Connexions restantes sur les autres instances
+		var remaining int64
+		if h.presence != nil {
+			count, err := h.presence.Disconnect(h.nodeID, update.userID)
+			if err != nil {
+				log.Printf("❌ Erreur décompte connexion de %s: %v", update.userID, err)
+			}
+			remaining = count
+		}
+
+		// Marquer comme hors ligne immédiatement
+		if update.lastDevice && h.presenceManager != nil {
+			if remaining == 0 {
+				h.presenceManager.UpdateUserPresence(update.userID, false)
+			}
+			h.presenceManager.RemoveUser(update.userID)
+		}
+	}
+}
+
+// heartbeat prolonge régulièrement les compteurs de connexions de cette instance
+func (h *Hub) heartbeat() {
+	ticker := time.NewTicker(database.WSPresenceTTL / 3)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := h.presence.Touch(h.nodeID); err != nil {
+			log.Printf("❌ Erreur battement de cœur présence: %v", err)
 		}
 	}
 }
@@ -217,30 +385,106 @@ func (h *Hub) LeaveConversation(userID, conversationID string) {
 	}
 }
 
-// SendToUser envoie un message à un utilisateur spécifique
+// SendToUser envoie un message à un utilisateur spécifique (sur toutes les instances)
 func (h *Hub) SendToUser(userID string, payload interface{}) {
+	payload, seq := h.sequence(userID, payload)
+
 	h.broadcast <- &Message{
 		UserIDs: []string{userID},
 		Payload: payload,
+		Seq:     seq,
 	}
+
+	h.publish(&Envelope{Kind: EnvelopeUser, UserIDs: []string{userID}, Seq: seq}, payload)
 }
 
-// SendToConversation envoie un message à tous les membres d'une conversation
+// SendToConversation envoie un message à tous les membres d'une conversation (sur toutes les instances).
+// Réservé aux événements éphémères (typing) : l'envoi n'est pas journalisé ni rejoué à la reconnexion,
+// les autres événements passent par SendToUser pour chaque participant.
 func (h *Hub) SendToConversation(conversationID string, payload interface{}, excludeUserID string) {
 	h.broadcast <- &Message{
 		ConversationID: conversationID,
 		ExcludeUserID:  excludeUserID,
 		Payload:        payload,
 	}
+
+	h.publish(&Envelope{Kind: EnvelopeConversation, ConversationID: conversationID, ExcludeUserID: excludeUserID}, payload)
+}
+
+// publish relaie un envoi aux autres instances
+func (h *Hub) publish(envelope *Envelope, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("❌ Payload WebSocket non sérialisable: %v", err)
+		return
+	}
+
+	envelope.NodeID = h.nodeID
+	envelope.Payload = data
+	if err := h.broadcaster.Publish(envelope); err != nil {
+		log.Printf("❌ Erreur publication broadcaster: %v", err)
+	}
+}
+
+// handleEnvelope livre aux connexions locales un envoi publié par une autre instance
+func (h *Hub) handleEnvelope(envelope *Envelope) {
+	if envelope.NodeID == h.nodeID {
+		return // Déjà livré localement
+	}
+
+	switch envelope.Kind {
+	case EnvelopeUser:
+		h.broadcast <- &Message{
+			UserIDs:       envelope.UserIDs,
+			ExcludeUserID: envelope.ExcludeUserID,
+			Payload:       envelope.Payload,
+			Seq:           envelope.Seq,
+		}
+	case EnvelopeConversation:
+		h.broadcast <- &Message{
+			ConversationID: envelope.ConversationID,
+			ExcludeUserID:  envelope.ExcludeUserID,
+			Payload:        envelope.Payload,
+		}
+	case EnvelopeGroup:
+		h.broadcastToLocalGroup(envelope.GroupID, envelope.Payload)
+	case EnvelopeGroupLeave:
+		for _, userID := range envelope.UserIDs {
+			h.LeaveGroup(userID, envelope.GroupID)
+		}
+	}
 }
 
-// IsUserOnline vérifie si un utilisateur est actuellement connecté
+// IsUserOnline vérifie si un utilisateur est actuellement connecté (sur n'importe quelle instance)
 func (h *Hub) IsUserOnline(userID string) bool {
+	return h.ClusterConnectionCount(userID) > 0
+}
+
+// ClusterConnectionCount retourne le nombre de connexions ouvertes d'un utilisateur sur toutes les instances
+func (h *Hub) ClusterConnectionCount(userID string) int {
+	local := h.ConnectionCount(userID)
+	if h.presence == nil {
+		return local
+	}
+
+	// Les connexions des autres instances sont comptées en base
+	count, err := h.presence.Count(userID)
+	if err != nil {
+		log.Printf("❌ Erreur comptage connexions de %s: %v", userID, err)
+		return local
+	}
+	if int(count) < local {
+		return local
+	}
+	return int(count)
+}
+
+// ConnectionCount retourne le nombre de connexions ouvertes d'un utilisateur
+func (h *Hub) ConnectionCount(userID string) int {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	_, online := h.connections[userID]
-	return online
+	return len(h.connections[userID])
 }
 
 // notifyUserPresence envoie un événement de présence à tous les contacts d'un utilisateur
@@ -274,11 +518,11 @@ func (h *Hub) notifyUserPresence(userID string, isOnline bool) {
 	}
 
 	// Payload de présence
-	payload := map[string]interface{}{
-		"type":      "user_presence",
-		"user_id":   userID,
-		"is_online": isOnline,
-		"last_seen": lastSeenStr, // ✅ Format ISO 8601 string
+	payload := models.WSUserPresence{
+		Type:     models.WSTypeUserPresence,
+		UserID:   userID,
+		IsOnline: isOnline,
+		LastSeen: &lastSeenStr, // ✅ Format ISO 8601 string
 	}
 
 	// Envoyer à tous les autres participants (éviter doublons)
@@ -328,6 +572,10 @@ func (h *Hub) autoJoinUserConversations(userID string) {
 
 // autoJoinUserGroups ajoute automatiquement l'utilisateur à tous ses groupes
 func (h *Hub) autoJoinUserGroups(userID string) {
+	if h.userRepo == nil {
+		return
+	}
+
 	// Récupérer l'utilisateur par email
 	user, err := h.userRepo.FindByEmail(userID)
 	if err != nil || user == nil {
@@ -352,12 +600,12 @@ func (h *Hub) HandleTyping(userID, conversationID string, isTyping bool) {
 	}
 
 	// Payload à envoyer aux autres participants
-	payload := map[string]interface{}{
-		"type":            "user_typing",
-		"conversation_id": conversationID,
-		"user_id":         userID,
-		"username":        username,
-		"is_typing":       isTyping,
+	payload := models.WSUserTyping{
+		Type:           models.WSTypeUserTyping,
+		ConversationID: conversationID,
+		UserID:         userID,
+		Username:       username,
+		IsTyping:       isTyping,
 	}
 
 	// Envoyer via SendToConversation (qui envoie à tous SAUF l'expéditeur)
@@ -379,7 +627,7 @@ func (h *Hub) JoinGroup(userID, groupID string) {
 	h.groupRooms[groupID][userID] = true
 }
 
-// LeaveGroup retire un utilisateur d'une room de groupe
+// LeaveGroup retire un utilisateur d'une room de groupe (connexions de cette instance)
 func (h *Hub) LeaveGroup(userID, groupID string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -392,45 +640,53 @@ func (h *Hub) LeaveGroup(userID, groupID string) {
 	}
 }
 
-// BroadcastToGroup envoie un message à tous les membres d'un groupe (y compris l'expéditeur)
+// RemoveFromGroup retire un utilisateur d'une room de groupe sur toutes les instances
+// (exclusion, départ ou dissolution : il ne doit plus recevoir les envois du groupe)
+func (h *Hub) RemoveFromGroup(userID, groupID string) {
+	h.LeaveGroup(userID, groupID)
+	h.publish(&Envelope{Kind: EnvelopeGroupLeave, UserIDs: []string{userID}, GroupID: groupID}, nil)
+}
+
+// BroadcastToGroup envoie un message à tous les membres d'un groupe (y compris l'expéditeur), sur toutes les instances.
+// Réservé aux événements éphémères (typing), comme SendToConversation.
 func (h *Hub) BroadcastToGroup(groupID string, payload interface{}) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.broadcastToLocalGroup(groupID, payload)
+	h.publish(&Envelope{Kind: EnvelopeGroup, GroupID: groupID}, payload)
+}
+
+// broadcastToLocalGroup envoie un message aux membres d'un groupe connectés à cette instance
+func (h *Hub) broadcastToLocalGroup(groupID string, payload interface{}) {
+	var slowClients []*Client
 
+	h.mu.RLock()
 	if members, ok := h.groupRooms[groupID]; ok {
 		for userID := range members {
-			if client, ok := h.connections[userID]; ok {
-				select {
-				case client.send <- payload:
-					// Message envoyé avec succès
-				default:
-					log.Printf("❌ Canal plein pour %s", userID)
-				}
-			} else {
-				// Utilisateur dans le groupe mais pas connecté - c'est normal
-				// Ne pas logger comme erreur pour éviter de polluer les logs
-			}
+			// Utilisateur dans le groupe mais pas connecté : c'est normal
+			slowClients = append(slowClients, h.deliver(userID, payload, 0)...)
 		}
-	} else {
+	}
+	h.mu.RUnlock()
+
+	// Les connexions qui ne suivent plus sont fermées
+	for _, client := range slowClients {
+		h.disconnectClient(client)
 	}
 }
 
 // BroadcastToUser envoie un message à un utilisateur spécifique (alias pour SendToUser)
 func (h *Hub) BroadcastToUser(userID string, payload []byte) {
 	h.mu.RLock()
-	defer h.mu.RUnlock()
 
-	if client, ok := h.connections[userID]; ok {
-		select {
-		case client.send <- payload:
-			// Message envoyé avec succès
-		default:
-			log.Printf("❌ Canal plein pour l'utilisateur %s", userID)
-		}
-	} else {
-		// Utilisateur non connecté - c'est normal
-		// Ne pas logger comme erreur pour éviter de polluer les logs
+	// Utilisateur non connecté : c'est normal
+	slowClients := h.deliver(userID, payload, 0)
+	h.mu.RUnlock()
+
+	// Les connexions qui ne suivent plus sont fermées
+	for _, client := range slowClients {
+		h.disconnectClient(client)
 	}
+
+	h.publish(&Envelope{Kind: EnvelopeUser, UserIDs: []string{userID}}, payload)
 }
 
 // HandleGroupTyping gère l'événement "typing" dans un groupe
@@ -455,12 +711,12 @@ func (h *Hub) HandleGroupTyping(userID, groupID string, isTyping bool) {
 	}
 
 	// Payload à envoyer à tous les membres (y compris l'utilisateur courant)
-	payload := map[string]interface{}{
-		"type":      "group_user_typing",
-		"group_id":  groupID,
-		"user_id":   userID,
-		"username":  username,
-		"is_typing": isTyping,
+	payload := models.WSGroupUserTyping{
+		Type:     models.WSTypeGroupUserTyping,
+		GroupID:  groupID,
+		UserID:   userID,
+		Username: username,
+		IsTyping: isTyping,
 	}
 
 	// Envoyer via BroadcastToGroup (qui envoie à tout le monde, y compris l'expéditeur)
@@ -604,25 +860,22 @@ func (h *Hub) broadcastPresenceUpdate(userID string, isOnline bool, lastSeen *ti
 	}
 
 	// Préparer le payload de présence
-	payload := map[string]interface{}{
-		"type":      "user_presence",
-		"user_id":   userID,
-		"is_online": isOnline,
+	payload := models.WSUserPresence{
+		Type:     models.WSTypeUserPresence,
+		UserID:   userID,
+		IsOnline: isOnline,
 	}
 
 	// Ajouter last_seen (format ISO 8601 string ou null)
-	if isOnline {
-		// Si en ligne, last_seen est null
-		payload["last_seen"] = nil
-	} else if lastSeen != nil {
-		// Si hors ligne avec last_seen fourni, l'inclure
-		payload["last_seen"] = lastSeen.Format(time.RFC3339)
-	} else {
-		// Si hors ligne sans last_seen, utiliser celui de l'utilisateur en DB
-		if user.LastSeen != nil {
-			payload["last_seen"] = user.LastSeen.Format(time.RFC3339)
-		} else {
-			payload["last_seen"] = nil
+	// Si en ligne, last_seen reste null
+	if !isOnline {
+		if lastSeen == nil {
+			// Si hors ligne sans last_seen, utiliser celui de l'utilisateur en DB
+			lastSeen = user.LastSeen
+		}
+		if lastSeen != nil {
+			formatted := lastSeen.Format(time.RFC3339)
+			payload.LastSeen = &formatted
 		}
 	}
 
@@ -644,12 +897,24 @@ func (h *Hub) Shutdown() {
 		h.presenceManager.Shutdown()
 	}
 
+	// Arrêter la réception des autres instances
+	h.broadcaster.Close()
+
+	// Les connexions de cette instance ne comptent plus
+	if h.presence != nil {
+		if err := h.presence.DeleteNode(h.nodeID); err != nil {
+			log.Printf("❌ Erreur suppression présence de l'instance: %v", err)
+		}
+	}
+
 	// Fermer toutes les connexions
 	h.mu.Lock()
-	for _, client := range h.connections {
-		close(client.send)
-		client.conn.Close()
+	for _, clients := range h.connections {
+		for client := range clients {
+			close(client.send)
+			client.conn.Close()
+		}
 	}
-	h.connections = make(map[string]*Client)
+	h.connections = make(map[string]map[*Client]bool)
 	h.mu.Unlock()
 }
@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPayload payload reconnaissable par son marqueur
+type testPayload struct {
+	Type   string `json:"type"`
+	Marker string `json:"marker"`
+}
+
+// newTestCluster crée deux hubs reliés par le même MemoryBroadcaster (deux instances simulées)
+func newTestCluster(t *testing.T) (*Hub, *Hub) {
+	t.Helper()
+
+	broadcaster := NewMemoryBroadcaster()
+	first := NewHub(nil, nil, broadcaster, nil, nil)
+	second := NewHub(nil, nil, broadcaster, nil, nil)
+	go first.Run()
+	go second.Run()
+
+	return first, second
+}
+
+// connectTestClient enregistre une connexion (sans socket) auprès d'un hub
+func connectTestClient(hub *Hub, userID string) *Client {
+	client := &Client{
+		hub:    hub,
+		send:   make(chan interface{}, 256),
+		UserID: userID,
+	}
+	hub.register <- client
+	return client
+}
+
+// expectPayload attend un payload portant le marqueur sur la connexion
+func expectPayload(t *testing.T, client *Client, marker string) {
+	t.Helper()
+
+	select {
+	case payload := <-client.send:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("payload non sérialisable: %v", err)
+		}
+		if !strings.Contains(string(data), marker) {
+			t.Fatalf("%s: payload %s, attendu le marqueur %q", client.UserID, data, marker)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%s: aucun payload reçu (marqueur %q)", client.UserID, marker)
+	}
+}
+
+// expectNothing vérifie qu'aucun autre payload n'arrive sur la connexion
+func expectNothing(t *testing.T, client *Client) {
+	t.Helper()
+
+	select {
+	case payload := <-client.send:
+		data, _ := json.Marshal(payload)
+		t.Fatalf("%s: payload inattendu %s", client.UserID, data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSendToUserAcrossHubs(t *testing.T) {
+	origin, remote := newTestCluster(t)
+
+	local := connectTestClient(origin, "alice@example.com")
+	other := connectTestClient(remote, "alice@example.com")
+
+	origin.SendToUser("alice@example.com", testPayload{Type: "test", Marker: "to-user"})
+
+	expectPayload(t, local, "to-user")
+	expectPayload(t, other, "to-user")
+
+	// Pas d'écho : l'instance d'origine ne relivre pas son propre envoi
+	expectNothing(t, local)
+	expectNothing(t, other)
+}
+
+func TestSendToConversationAcrossHubs(t *testing.T) {
+	origin, remote := newTestCluster(t)
+
+	sender := connectTestClient(origin, "alice@example.com")
+	local := connectTestClient(origin, "bob@example.com")
+	other := connectTestClient(remote, "carol@example.com")
+	origin.JoinConversation("alice@example.com", "conv")
+	origin.JoinConversation("bob@example.com", "conv")
+	remote.JoinConversation("carol@example.com", "conv")
+
+	origin.SendToConversation("conv", testPayload{Type: "test", Marker: "to-conversation"}, "alice@example.com")
+
+	expectPayload(t, local, "to-conversation")
+	expectPayload(t, other, "to-conversation")
+
+	expectNothing(t, sender)
+	expectNothing(t, local)
+	expectNothing(t, other)
+}
+
+func TestBroadcastToGroupAcrossHubs(t *testing.T) {
+	origin, remote := newTestCluster(t)
+
+	local := connectTestClient(origin, "alice@example.com")
+	other := connectTestClient(remote, "bob@example.com")
+	outsider := connectTestClient(remote, "carol@example.com")
+	origin.JoinGroup("alice@example.com", "group")
+	remote.JoinGroup("bob@example.com", "group")
+
+	origin.BroadcastToGroup("group", testPayload{Type: "test", Marker: "to-group"})
+
+	expectPayload(t, local, "to-group")
+	expectPayload(t, other, "to-group")
+
+	expectNothing(t, local)
+	expectNothing(t, other)
+	expectNothing(t, outsider)
+}
+
+func TestRemoveFromGroupAcrossHubs(t *testing.T) {
+	origin, remote := newTestCluster(t)
+
+	local := connectTestClient(origin, "alice@example.com")
+	removed := connectTestClient(remote, "bob@example.com")
+	origin.JoinGroup("alice@example.com", "group")
+	remote.JoinGroup("bob@example.com", "group")
+
+	// Exclusion traitée par l'instance d'origine : le membre est aussi retiré de la room de l'autre instance
+	origin.RemoveFromGroup("bob@example.com", "group")
+	origin.BroadcastToGroup("group", testPayload{Type: "test", Marker: "after-remove"})
+
+	expectPayload(t, local, "after-remove")
+	expectNothing(t, removed)
+}
+
+func TestBroadcastToGroupClosesSlowClient(t *testing.T) {
+	hub := NewHub(nil, nil, nil, nil, nil)
+	go hub.Run()
+
+	// Connexion qui ne lit plus sa socket : son canal est déjà plein
+	slow := &Client{hub: hub, send: make(chan interface{}, 1), UserID: "alice@example.com"}
+	slow.send <- testPayload{Type: "test", Marker: "unread"}
+	hub.register <- slow
+	hub.JoinGroup("alice@example.com", "group")
+
+	deadline := time.Now().Add(time.Second)
+	for hub.ConnectionCount("alice@example.com") == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hub.BroadcastToGroup("group", testPayload{Type: "test", Marker: "to-group"})
+
+	if count := hub.ConnectionCount("alice@example.com"); count != 0 {
+		t.Fatalf("connexion lente toujours enregistrée (%d connexion(s))", count)
+	}
+	expectPayload(t, slow, "unread")
+	if _, open := <-slow.send; open {
+		t.Fatal("canal de la connexion lente toujours ouvert")
+	}
+}
@@ -0,0 +1,160 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"premier-an-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// inboundTypes associe chaque type de message client à sa structure
+var inboundTypes = map[string]func() interface{}{
+	models.WSTypeAuthenticate:      func() interface{} { return &models.WSAuthenticate{} },
+	models.WSTypeJoinConversation:  func() interface{} { return &models.WSConversationRoom{} },
+	models.WSTypeLeaveConversation: func() interface{} { return &models.WSConversationRoom{} },
+	models.WSTypeTyping:            func() interface{} { return &models.WSTyping{} },
+	models.WSTypeJoinGroup:         func() interface{} { return &models.WSGroupRoom{} },
+	models.WSTypeLeaveGroup:        func() interface{} { return &models.WSGroupRoom{} },
+	models.WSTypeGroupTyping:       func() interface{} { return &models.WSGroupTyping{} },
+	models.WSTypeUserPresence:      func() interface{} { return &models.WSPresenceUpdate{} },
+}
+
+// protocolError construit une frame d'erreur
+func protocolError(code, field, message string) *models.WSError {
+	return &models.WSError{
+		Type:    models.WSTypeError,
+		Code:    code,
+		Message: message,
+		Field:   field,
+	}
+}
+
+// decodeInbound lit un message client, le convertit dans sa structure et le valide.
+// En cas d'échec, la frame d'erreur à renvoyer au client est retournée.
+func decodeInbound(data []byte) (interface{}, *models.WSError) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, protocolError(models.WSErrorInvalidField, "type", "Le champ type doit être une chaîne")
+		}
+		return nil, protocolError(models.WSErrorInvalidJSON, "", "Message JSON invalide")
+	}
+
+	if envelope.Type == "" {
+		return nil, protocolError(models.WSErrorMissingField, "type", "Champ type requis")
+	}
+
+	newMessage, ok := inboundTypes[envelope.Type]
+	if !ok {
+		frame := protocolError(models.WSErrorUnknownType, "type", "Type de message inconnu")
+		frame.RequestType = envelope.Type
+		return nil, frame
+	}
+
+	msg := newMessage()
+	if err := json.Unmarshal(data, msg); err != nil {
+		frame := protocolError(models.WSErrorInvalidJSON, "", "Message JSON invalide")
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			frame = protocolError(models.WSErrorInvalidField, typeErr.Field, "Type de champ invalide (attendu: "+typeErr.Type.String()+")")
+		}
+		frame.RequestType = envelope.Type
+		return nil, frame
+	}
+
+	if frame := validateInbound(msg); frame != nil {
+		frame.RequestType = envelope.Type
+		return nil, frame
+	}
+
+	return msg, nil
+}
+
+// validateInbound vérifie les champs obligatoires et leur format
+func validateInbound(msg interface{}) *models.WSError {
+	switch m := msg.(type) {
+	case *models.WSAuthenticate:
+		if m.Token == "" {
+			return protocolError(models.WSErrorMissingField, "token", "Token requis")
+		}
+		if m.ProtocolVersion < 0 {
+			return protocolError(models.WSErrorInvalidField, "protocol_version", "Version de protocole invalide")
+		}
+		if m.LastSeq != nil && *m.LastSeq < 0 {
+			return protocolError(models.WSErrorInvalidField, "last_seq", "last_seq doit être positif")
+		}
+
+	case *models.WSConversationRoom:
+		return validateObjectID("conversation_id", m.ConversationID)
+
+	case *models.WSTyping:
+		switch {
+		case m.ConversationID != "" && m.GroupID != "":
+			return protocolError(models.WSErrorInvalidField, "group_id", "conversation_id et group_id sont exclusifs")
+		case m.GroupID != "":
+			return validateObjectID("group_id", m.GroupID)
+		default:
+			return validateObjectID("conversation_id", m.ConversationID)
+		}
+
+	case *models.WSGroupRoom:
+		return validateObjectID("group_id", m.GroupID)
+
+	case *models.WSGroupTyping:
+		return validateObjectID("group_id", m.GroupID)
+
+	case *models.WSPresenceUpdate:
+		if m.IsOnline == nil {
+			return protocolError(models.WSErrorMissingField, "is_online", "is_online requis")
+		}
+		if _, err := parseLastSeen(m.LastSeen); err != nil {
+			return protocolError(models.WSErrorInvalidField, "last_seen", "last_seen doit être au format ISO 8601")
+		}
+	}
+
+	return nil
+}
+
+// validateObjectID vérifie qu'un identifiant est présent et au format ObjectID
+func validateObjectID(field, value string) *models.WSError {
+	if value == "" {
+		return protocolError(models.WSErrorMissingField, field, field+" requis")
+	}
+	if !primitive.IsValidObjectID(value) {
+		return protocolError(models.WSErrorInvalidField, field, field+" invalide")
+	}
+	return nil
+}
+
+// parseLastSeen lit le last_seen d'un message user_presence (absent, vide ou "null" : nil)
+func parseLastSeen(value *string) (*time.Time, error) {
+	if value == nil || *value == "" || *value == "null" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// negotiateVersion retient la version demandée par le client, plafonnée à la version du serveur.
+// Sans version annoncée (0), le client est en version 1 ; en dessous du minimum, il est refusé.
+func negotiateVersion(requested int) (int, bool) {
+	if requested == 0 {
+		requested = 1
+	}
+	if requested < models.WSMinProtocolVersion {
+		return 0, false
+	}
+	if requested > models.WSProtocolVersion {
+		return models.WSProtocolVersion, true
+	}
+	return requested, true
+}
@@ -0,0 +1,162 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"premier-an-backend/models"
+)
+
+// withSeq ajoute le numéro de séquence à un payload JSON objet
+func withSeq(data []byte, seq int64) json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[0] != '{' {
+		return data
+	}
+
+	sequenced := []byte(`{"seq":` + strconv.FormatInt(seq, 10))
+	if inner := bytes.TrimSpace(data[1 : len(data)-1]); len(inner) > 0 {
+		sequenced = append(sequenced, ',')
+		sequenced = append(sequenced, inner...)
+	}
+	return append(sequenced, '}')
+}
+
+// sequence enregistre un événement destiné à un utilisateur et lui attribue son numéro de séquence.
+// Sans journal (ou si le payload n'est pas un objet JSON), le payload est envoyé tel quel avec seq = 0.
+func (h *Hub) sequence(userID string, payload interface{}) (interface{}, int64) {
+	if h.eventLog == nil {
+		return payload, 0
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil || len(data) == 0 || data[0] != '{' {
+		return payload, 0
+	}
+
+	seq, err := h.eventLog.Append(userID, data)
+	if err != nil {
+		log.Printf("❌ Erreur journal WebSocket pour %s: %v", userID, err)
+		return payload, 0
+	}
+
+	return withSeq(data, seq), seq
+}
+
+// CurrentSeq retourne le dernier numéro de séquence envoyé à un utilisateur
+func (h *Hub) CurrentSeq(userID string) int64 {
+	if h.eventLog == nil {
+		return 0
+	}
+
+	seq, err := h.eventLog.CurrentSeq(userID)
+	if err != nil {
+		log.Printf("❌ Erreur lecture séquence WebSocket pour %s: %v", userID, err)
+		return 0
+	}
+	return seq
+}
+
+// replayBatch événements manqués chargés pour une connexion qui reprend
+type replayBatch struct {
+	client   *Client
+	current  int64 // Dernier numéro de séquence au chargement
+	events   []models.WSEvent
+	complete bool // Tous les événements depuis resumeFrom sont disponibles
+}
+
+// pendingEvent événement reçu en direct pendant le chargement du replay
+type pendingEvent struct {
+	seq     int64
+	payload interface{}
+}
+
+// loadReplay charge les événements manqués par une connexion qui reprend (authenticate avec last_seq).
+// Appelé par le handler une fois la connexion enregistrée, hors de la boucle du hub :
+// les événements reçus en direct entre-temps sont mis de côté par deliver.
+func (h *Hub) loadReplay(client *Client) *replayBatch {
+	batch := &replayBatch{client: client}
+	if h.eventLog == nil {
+		return batch
+	}
+
+	batch.current = h.CurrentSeq(client.UserID)
+	if client.resumeFrom == batch.current {
+		batch.complete = true
+		return batch
+	}
+
+	events, err := h.eventLog.FindAfter(client.UserID, client.resumeFrom, models.WSEventLogSize)
+	if err != nil {
+		log.Printf("❌ Erreur replay WebSocket pour %s: %v", client.UserID, err)
+		return batch
+	}
+
+	batch.complete = client.resumeFrom < batch.current &&
+		len(events) > 0 &&
+		events[0].Seq == client.resumeFrom+1 &&
+		events[len(events)-1].Seq >= batch.current
+	if batch.complete {
+		batch.events = events
+	}
+	return batch
+}
+
+// replay renvoie les événements chargés puis ceux reçus en direct pendant le chargement.
+// Appelé par Run : aucun envoi en direct ne peut s'intercaler, et aucun accès base n'est fait ici.
+// Si les événements ne sont plus tous dans le journal, le client doit tout recharger (resync_required).
+// Retourne true si le canal de la connexion est plein : elle doit être fermée.
+func (h *Hub) replay(batch *replayBatch) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client := batch.client
+	if !h.connections[client.UserID][client] {
+		// Connexion fermée pendant le chargement
+		return false
+	}
+
+	pending := client.pending
+	client.pending = nil
+	client.replaying = false
+	client.replayedSeq = batch.current
+
+	// Les envois du replay ne doivent pas bloquer le hub : le canal doit pouvoir tout contenir
+	if !batch.complete || len(batch.events)+1 > cap(client.send)-len(client.send) {
+		client.send <- models.WSResyncRequired{
+			Type:    models.WSTypeResyncRequired,
+			LastSeq: batch.current,
+		}
+	} else {
+		for _, event := range batch.events {
+			client.send <- withSeq([]byte(event.Payload), event.Seq)
+		}
+		if len(batch.events) > 0 {
+			client.replayedSeq = batch.events[len(batch.events)-1].Seq
+		}
+
+		client.send <- models.WSReplayComplete{
+			Type:     models.WSTypeReplayComplete,
+			Replayed: len(batch.events),
+			LastSeq:  client.replayedSeq,
+		}
+	}
+
+	// Événements reçus en direct pendant le chargement, sauf ceux déjà renvoyés
+	for _, event := range pending {
+		if event.seq <= client.replayedSeq {
+			continue
+		}
+		select {
+		case client.send <- event.payload:
+			client.replayedSeq = event.seq
+		default:
+			log.Printf("❌ Canal plein pour %s", client.UserID)
+			return true
+		}
+	}
+
+	return false
+}